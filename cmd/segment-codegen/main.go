@@ -0,0 +1,55 @@
+// Command segment-codegen generates typed Go analytics helpers from a tracking plan.
+//
+// The plan is read from a file or directory exported with the planfile package, or fetched from
+// the Segment Config API with the access token and workspace of the -token and -workspace flags,
+// the ACCESS_TOKEN and SEGMENT_WORKSPACE environment variables or a segmentctl profile.
+// It is meant to be run from go:generate:
+//
+//	//go:generate go run github.com/fenderdigital/segment-apis-go/cmd/segment-codegen -file tracking-plan.yaml -package analytics -out analytics_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment/codegen"
+)
+
+func main() {
+	var pf cli.PlanFlags
+	pf.Register(flag.CommandLine)
+	var (
+		pkg     = flag.String("package", os.Getenv("GOPACKAGE"), "name of the generated package, defaults to $GOPACKAGE")
+		out     = flag.String("out", "", "output file, defaults to stdout")
+		verbose = flag.Bool("v", false, "print the output file name")
+	)
+	flag.Parse()
+
+	if err := run(pf, *pkg, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "segment-codegen: %v\n", err)
+		os.Exit(1)
+	}
+	if *verbose && *out != "" {
+		fmt.Fprintf(os.Stderr, "segment-codegen: wrote %s\n", *out)
+	}
+}
+
+func run(pf cli.PlanFlags, pkg, out string) error {
+	tp, err := pf.LoadPlan()
+	if err != nil {
+		return err
+	}
+
+	src, err := codegen.Generate(tp, codegen.Options{Package: pkg})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
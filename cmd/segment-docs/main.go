@@ -0,0 +1,61 @@
+// Command segment-docs renders a tracking plan as documentation, either a single Markdown file or
+// a static HTML site with an index and search.
+//
+// The plan is read from a file or directory exported with the planfile package, or fetched from
+// the Segment Config API with the access token and workspace of the -token and -workspace flags,
+// the ACCESS_TOKEN and SEGMENT_WORKSPACE environment variables or a segmentctl profile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment/docs"
+)
+
+func main() {
+	var pf cli.PlanFlags
+	pf.Register(flag.CommandLine)
+	var (
+		output = flag.String("format", "markdown", "output format, markdown or html")
+		out    = flag.String("out", "", "output file for markdown, defaults to stdout, or output directory for html")
+	)
+	flag.Parse()
+
+	if err := run(pf, *output, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "segment-docs: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pf cli.PlanFlags, output, out string) error {
+	tp, err := pf.LoadPlan()
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "markdown":
+		if out == "" {
+			return docs.WriteMarkdown(os.Stdout, tp)
+		}
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		if err := docs.WriteMarkdown(f, tp); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "html":
+		if out == "" {
+			return fmt.Errorf("-out is required for html")
+		}
+		return docs.WriteHTML(out, tp)
+	default:
+		return fmt.Errorf("unknown format %q", output)
+	}
+}
@@ -0,0 +1,86 @@
+// Command segment-drift reports the changes made to a Segment workspace since a baseline, either a
+// spec applied with the spec package or a snapshot taken with the snapshot package.
+//
+// The workspace is read from the Segment Config API with the access token and workspace of the
+// -token and -workspace flags, the ACCESS_TOKEN and SEGMENT_WORKSPACE environment variables or a
+// segmentctl profile. It exits with status 1 when the workspace drifted, and 2 on errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment/drift"
+	"github.com/fenderdigital/segment-apis-go/segment/snapshot"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+)
+
+func main() {
+	var flags cli.Flags
+	flags.Register(flag.CommandLine)
+	var (
+		specFile = flag.String("spec", "", "workspace spec file")
+		snap     = flag.String("snapshot", "", "snapshot directory or tarball")
+		output   = flag.String("format", "text", "output format, text or json")
+	)
+	flag.Parse()
+
+	r, err := run(flags, *specFile, *snap, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "segment-drift: %v\n", err)
+		os.Exit(2)
+	}
+	os.Exit(r.ExitCode())
+}
+
+func run(flags cli.Flags, specFile, snap, output string) (drift.Report, error) {
+	var r drift.Report
+	if output != "text" && output != "json" {
+		return r, fmt.Errorf("unknown format %q", output)
+	}
+	if specFile != "" && snap != "" {
+		return r, fmt.Errorf("-spec and -snapshot are mutually exclusive")
+	}
+	if specFile == "" && snap == "" {
+		return r, fmt.Errorf("one of -spec or -snapshot is required")
+	}
+
+	var baseline snapshot.Snapshot
+	var sp spec.Spec
+	var err error
+	if specFile != "" {
+		sp, err = spec.Load(specFile)
+	} else {
+		baseline, err = snapshot.Load(snap)
+	}
+	if err != nil {
+		return r, err
+	}
+
+	c, err := cli.Client(flags)
+	if err != nil {
+		return r, err
+	}
+	live, err := spec.ReadState(c)
+	if err != nil {
+		return r, err
+	}
+	if specFile != "" {
+		r = drift.Check(sp, live)
+	} else {
+		r = drift.CheckSnapshot(baseline, live)
+	}
+
+	if output == "json" {
+		data, err := r.JSON()
+		if err != nil {
+			return r, err
+		}
+		fmt.Println(string(data))
+		return r, nil
+	}
+	fmt.Print(r.String())
+	return r, nil
+}
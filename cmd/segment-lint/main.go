@@ -0,0 +1,102 @@
+// Command segment-lint checks a tracking plan against naming and quality conventions.
+//
+// The plan is read from a file or directory exported with the planfile package, or fetched from
+// the Segment Config API with the access token and workspace of the -token and -workspace flags,
+// the ACCESS_TOKEN and SEGMENT_WORKSPACE environment variables or a segmentctl profile.
+// It exits with status 1 when a finding is at least as serious as -fail-on, and 2 on errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment/lint"
+)
+
+func main() {
+	var pf cli.PlanFlags
+	pf.Register(flag.CommandLine)
+	var (
+		output        = flag.String("format", "text", "output format, text or json")
+		failOn        = flag.String("fail-on", string(lint.Error), "lowest severity that fails the run: error, warning or info")
+		severities    = flag.String("severity", "", "comma separated rule=severity overrides, e.g. event-description=error,categorical-enum=off")
+		maxProperties = flag.Int("max-properties", lint.DefaultMaxProperties, "maximum number of properties of an event")
+	)
+	flag.Parse()
+
+	failed, err := run(pf, *output, lint.Severity(*failOn), *severities, *maxProperties)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "segment-lint: %v\n", err)
+		os.Exit(2)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(pf cli.PlanFlags, output string, failOn lint.Severity, severities string, maxProperties int) (bool, error) {
+	switch failOn {
+	case lint.Error, lint.Warning, lint.Info:
+	default:
+		return false, fmt.Errorf("invalid -fail-on severity %q", failOn)
+	}
+	cfg := lint.DefaultConfig()
+	for i, r := range cfg.Rules {
+		if r.Name() == lint.RuleMaxProperties {
+			cfg.Rules[i] = lint.MaxProperties(maxProperties)
+		}
+	}
+	overrides, err := parseSeverities(severities)
+	if err != nil {
+		return false, err
+	}
+	cfg.Severities = overrides
+
+	tp, err := pf.LoadPlan()
+	if err != nil {
+		return false, err
+	}
+
+	r := lint.LintTrackingPlan(tp, cfg)
+	switch output {
+	case "text":
+		for _, f := range r.Findings {
+			fmt.Println(f)
+		}
+		fmt.Printf("%d errors, %d warnings, %d infos, %d suppressed\n", r.Errors, r.Warnings, r.Infos, r.Suppressed)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("unknown format %q", output)
+	}
+	return r.Failed(failOn), nil
+}
+
+func parseSeverities(s string) (map[string]lint.Severity, error) {
+	severities := map[string]lint.Severity{}
+	if s == "" {
+		return severities, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid severity override %q", pair)
+		}
+		sev := lint.Severity(strings.TrimSpace(kv[1]))
+		switch sev {
+		case lint.Error, lint.Warning, lint.Info, lint.Off:
+		default:
+			return nil, fmt.Errorf("invalid severity %q", sev)
+		}
+		severities[strings.TrimSpace(kv[0])] = sev
+	}
+	return severities, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+var connectionCommands = map[string]command{
+	"list":   listConnections,
+	"get":    getConnection,
+	"create": createConnection,
+	"update": updateConnection,
+	"delete": deleteConnection,
+}
+
+func connectionTable(connections ...segment.TrackingPlanSourceConnection) table {
+	t := table{headers: []string{"SOURCE", "TRACKING PLAN"}}
+	for _, conn := range connections {
+		t.add(segment.Slug(conn.SourceName), conn.TrackingPlanID)
+	}
+	return t
+}
+
+func listConnections(e *env, args []string) error {
+	fs := e.flagSet("connections list", "[-plan <plan>]")
+	plan := fs.String("plan", "", "only list the sources connected to this tracking plan")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	var connections segment.TrackingPlanSourceConnections
+	if *plan != "" {
+		connections, err = c.ListTrackingPlanSourceConnections(*plan)
+	} else {
+		connections, err = c.ListAllTrackingPlanSourceConnections()
+	}
+	if err != nil {
+		return err
+	}
+	if connections.Connections == nil {
+		connections.Connections = []segment.TrackingPlanSourceConnection{}
+	}
+	return e.print(connections.Connections, connectionTable(connections.Connections...))
+}
+
+func getConnection(e *env, args []string) error {
+	fs := e.flagSet("connections get", "<source>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	conn, ok, err := c.FindTrackingPlanSourceConnection(args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("source %s is not connected to a tracking plan", args[0])
+	}
+	return e.print(conn, connectionTable(conn))
+}
+
+func createConnection(e *env, args []string) error {
+	fs := e.flagSet("connections create", "<source> <plan>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	conn, err := c.CreateTrackingPlanSourceConnection(args[1], args[0])
+	if err != nil {
+		return err
+	}
+	return e.print(conn, connectionTable(conn))
+}
+
+// updateConnection moves a source to another tracking plan
+func updateConnection(e *env, args []string) error {
+	fs := e.flagSet("connections update", "<source> <plan>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	current, ok, err := c.FindTrackingPlanSourceConnection(args[0])
+	if err != nil {
+		return err
+	}
+	conn := current
+	switch {
+	case ok && segment.Slug(current.TrackingPlanID) == args[1]:
+	case ok:
+		conn, err = c.MoveTrackingPlanSourceConnection(current.TrackingPlanID, args[1], args[0])
+	default:
+		conn, err = c.CreateTrackingPlanSourceConnection(args[1], args[0])
+	}
+	if err != nil {
+		return err
+	}
+	return e.print(conn, connectionTable(conn))
+}
+
+func deleteConnection(e *env, args []string) error {
+	fs := e.flagSet("connections delete", "<source> <plan>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := e.confirm("Disconnect source %s from tracking plan %s?", args[0], args[1]); err != nil {
+		return err
+	}
+	return c.DeleteTrackingPlanSourceConnection(args[1], args[0])
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+var destinationCommands = map[string]command{
+	"list":    listDestinations,
+	"get":     getDestination,
+	"create":  createDestination,
+	"update":  updateDestination,
+	"delete":  deleteDestination,
+	"enable":  enableDestination,
+	"disable": disableDestination,
+}
+
+// settings collects destination settings from repeated -set name=value flags. Values are parsed as
+// JSON when they are valid JSON, and kept as strings otherwise.
+type settings map[string]interface{}
+
+func (s settings) String() string {
+	return ""
+}
+
+func (s settings) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected name=value, got %q", v)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(v[i+1:]), &value); err != nil {
+		value = v[i+1:]
+	}
+	s[v[:i]] = value
+	return nil
+}
+
+// resolve returns the settings with the secret references in their values, such as ${env:GA_KEY},
+// replaced by the secrets
+func (s settings) resolve() (settings, error) {
+	configs, err := segment.ResolveSecrets(segment.DefaultSecretResolver, s.merge("", nil))
+	if err != nil {
+		return nil, err
+	}
+	resolved := settings{}
+	for _, c := range configs {
+		resolved[segment.Slug(c.Name)] = c.Value
+	}
+	return resolved, nil
+}
+
+// merge sets the values of settings in configs, and adds the settings that are not there yet
+func (s settings) merge(destName string, configs []segment.DestinationConfig) []segment.DestinationConfig {
+	var merged []segment.DestinationConfig
+	seen := map[string]bool{}
+	for _, c := range configs {
+		if v, ok := s[segment.Slug(c.Name)]; ok {
+			c.Value = v
+			seen[segment.Slug(c.Name)] = true
+		}
+		merged = append(merged, c)
+	}
+	var names []string
+	for name := range s {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		merged = append(merged, segment.DestinationConfig{
+			Name:  fmt.Sprintf("%s/config/%s", destName, name),
+			Type:  configType(s[name]),
+			Value: s[name],
+		})
+	}
+	return merged
+}
+
+// configType returns the Config API type of a setting value
+func configType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return "string"
+}
+
+func destinationTable(destinations ...segment.Destination) table {
+	t := table{headers: []string{"NAME", "ENABLED", "CONNECTION MODE", "SETTINGS", "UPDATED"}}
+	for _, d := range destinations {
+		t.add(segment.Slug(d.Name), fmt.Sprint(d.Enabled), d.ConnectionMode, fmt.Sprint(len(d.Configs)), formatTime(d.UpdateTime))
+	}
+	return t
+}
+
+// destinationDetails lists the settings of a destination along with its fields
+func destinationDetails(d segment.Destination) table {
+	t := table{headers: []string{"FIELD", "VALUE"}}
+	t.add("name", segment.Slug(d.Name))
+	t.add("display_name", d.DisplayName)
+	t.add("enabled", fmt.Sprint(d.Enabled))
+	t.add("connection_mode", d.ConnectionMode)
+	t.add("updated", formatTime(d.UpdateTime))
+	for _, c := range d.Configs {
+		value, err := json.Marshal(c.Value)
+		if err != nil {
+			value = []byte(fmt.Sprint(c.Value))
+		}
+		t.add("config."+segment.Slug(c.Name), string(value))
+	}
+	return t
+}
+
+func listDestinations(e *env, args []string) error {
+	fs := e.flagSet("destinations list", "<source>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	destinations, err := c.ListDestinations(args[0])
+	if err != nil {
+		return err
+	}
+	// Secret settings are redacted from the output
+	redacted := make([]segment.Destination, len(destinations.Destinations))
+	for i, d := range destinations.Destinations {
+		redacted[i] = d.Redacted()
+	}
+	return e.print(redacted, destinationTable(redacted...))
+}
+
+func getDestination(e *env, args []string) error {
+	fs := e.flagSet("destinations get", "<source> <destination>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	d, err := c.GetDestination(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return e.print(d.Redacted(), destinationDetails(d.Redacted()))
+}
+
+func createDestination(e *env, args []string) error {
+	fs := e.flagSet("destinations create", "[-mode <mode>] [-disabled] [-set name=value]... <source> <destination>")
+	mode := fs.String("mode", "CLOUD", "connection mode, CLOUD or DEVICE")
+	disabled := fs.Bool("disabled", false, "create the destination disabled")
+	values := settings{}
+	fs.Var(values, "set", "setting as name=value, may be repeated. References such as ${env:GA_KEY} or ${file:/secrets/ga} are resolved")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	workspace, err := e.workspace()
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	destName := fmt.Sprintf("%s/%s/%s/%s/%s/%s",
+		segment.WorkspacesEndpoint, workspace, segment.SourceEndpoint, args[0], segment.DestinationEndpoint, args[1])
+	resolved, err := values.resolve()
+	if err != nil {
+		return err
+	}
+	d, err := c.WithSecretResolver(nil).CreateDestination(args[0], args[1], *mode, !*disabled, resolved.merge(destName, nil))
+	if err != nil {
+		return err
+	}
+	return e.print(d.Redacted(), destinationDetails(d.Redacted()))
+}
+
+func updateDestination(e *env, args []string) error {
+	fs := e.flagSet("destinations update", "-set name=value... <source> <destination>")
+	values := settings{}
+	fs.Var(values, "set", "setting as name=value, may be repeated. References such as ${env:GA_KEY} or ${file:/secrets/ga} are resolved")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return usagef("at least one -set is required")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	resolved, err := values.resolve()
+	if err != nil {
+		return err
+	}
+	d, err := c.GetDestination(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	// Only the -set values are resolved, the settings read back from the API are sent as is
+	d, err = c.WithSecretResolver(nil).UpdateDestination(args[0], args[1], d.Enabled, resolved.merge(d.Name, d.Configs))
+	if err != nil {
+		return err
+	}
+	return e.print(d.Redacted(), destinationDetails(d.Redacted()))
+}
+
+func deleteDestination(e *env, args []string) error {
+	fs := e.flagSet("destinations delete", "<source> <destination>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := e.confirm("Delete destination %s of source %s?", args[1], args[0]); err != nil {
+		return err
+	}
+	return c.DeleteDestination(args[0], args[1])
+}
+
+func enableDestination(e *env, args []string) error {
+	return setDestinationEnabled(e, "destinations enable", args, true)
+}
+
+func disableDestination(e *env, args []string) error {
+	return setDestinationEnabled(e, "destinations disable", args, false)
+}
+
+func setDestinationEnabled(e *env, name string, args []string, enabled bool) error {
+	fs := e.flagSet(name, "<source> <destination>")
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	d, err := c.GetDestination(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		// Disabling a destination stops the delivery of events to it
+		if err := e.confirm("Disable destination %s of source %s?", args[1], args[0]); err != nil {
+			return err
+		}
+	}
+	d, err = c.WithSecretResolver(nil).UpdateDestination(args[0], args[1], enabled, d.Configs)
+	if err != nil {
+		return err
+	}
+	return e.print(d.Redacted(), destinationTable(d.Redacted()))
+}
@@ -0,0 +1,292 @@
+// Command segmentctl manages the sources, destinations, tracking plans and source connections of
+// a Segment workspace from the command line.
+//
+// Usage:
+//
+//	segmentctl [flags] <resource> <command> [flags] [args]
+//
+// The access token and workspace are read from the -token and -workspace flags, then from the
+// ACCESS_TOKEN and SEGMENT_WORKSPACE environment variables, then from a profile of the config file,
+// which defaults to ~/.segmentctl.yaml:
+//
+//	current_profile: prod
+//	profiles:
+//	  prod:
+//	    access_token: ...
+//	    workspace: kicks
+//	  staging:
+//	    access_token: ...
+//	    workspace: kicks-staging
+//
+// Resources are printed as a table, or as JSON or YAML with -o. Commands that delete resources ask
+// for confirmation unless -yes is set. segmentctl exits with status 1 when a command fails, 2 on
+// usage errors and 3 when a confirmation is declined.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Exit statuses
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitAborted = 3
+)
+
+// command runs a command of a resource with the arguments that follow it
+type command func(e *env, args []string) error
+
+// resources are the commands of each resource
+var resources = map[string]map[string]command{
+	"workspaces":     workspaceCommands,
+	"sources":        sourceCommands,
+	"destinations":   destinationCommands,
+	"tracking-plans": trackingPlanCommands,
+	"connections":    connectionCommands,
+}
+
+// usageError is returned for invalid command lines
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// errAborted is returned when a confirmation is declined
+var errAborted = fmt.Errorf("aborted")
+
+func main() {
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(run(e, os.Args[1:]))
+}
+
+func run(e *env, args []string) int {
+	err := dispatch(e, args)
+	if err == nil || err == flag.ErrHelp {
+		return exitOK
+	}
+	fmt.Fprintf(e.stderr, "segmentctl: %v\n", err)
+	if _, ok := err.(usageError); ok {
+		return exitUsage
+	}
+	if err == errAborted {
+		return exitAborted
+	}
+	return exitFailed
+}
+
+func dispatch(e *env, args []string) error {
+	fs := e.flagSet("segmentctl", "<resource> <command> [flags] [args]")
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: segmentctl [flags] <resource> <command> [flags] [args]\n\nresources and commands:\n")
+		for _, name := range resourceNames() {
+			fmt.Fprintf(e.stderr, "  %-15s %s\n", name, strings.Join(commandNames(resources[name]), ", "))
+		}
+		fmt.Fprintf(e.stderr, "\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return usagef("a resource is required")
+	}
+	commands, ok := resources[args[0]]
+	if !ok {
+		return usagef("unknown resource %q", args[0])
+	}
+	if len(args) == 1 {
+		return usagef("a command is required: %s", strings.Join(commandNames(commands), ", "))
+	}
+	cmd, ok := commands[args[1]]
+	if !ok {
+		return usagef("unknown %s command %q", args[0], args[1])
+	}
+	return cmd(e, args[2:])
+}
+
+// globalFlags are the flags accepted before the resource and after the command
+type globalFlags struct {
+	cli.Flags
+	output string
+	yes    bool
+}
+
+// env is the environment commands run in
+type env struct {
+	global globalFlags
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// sharedString is a string flag of every flag set. Its value is not printed as a default, as it may
+// have been set by a previous flag set and hold an access token.
+type sharedString struct {
+	p *string
+}
+
+func (s sharedString) String() string {
+	return ""
+}
+
+func (s sharedString) Set(v string) error {
+	*s.p = v
+	return nil
+}
+
+// sharedBool is a bool flag of every flag set
+type sharedBool struct {
+	p *bool
+}
+
+func (b sharedBool) String() string {
+	return ""
+}
+
+func (b sharedBool) Set(v string) error {
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*b.p = value
+	return nil
+}
+
+func (b sharedBool) IsBoolFlag() bool {
+	return true
+}
+
+// flagSet returns a flag set with the global flags
+func (e *env) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: segmentctl %s %s\n\nflags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	g := &e.global
+	fs.Var(sharedString{&g.ConfigFile}, "config", "`path` of the config file, defaults to $SEGMENTCTL_CONFIG or ~/.segmentctl.yaml")
+	fs.Var(sharedString{&g.Profile}, "profile", "config file `profile`, defaults to $SEGMENTCTL_PROFILE or the current profile")
+	fs.Var(sharedString{&g.Token}, "token", "access `token`, defaults to $ACCESS_TOKEN")
+	fs.Var(sharedString{&g.Workspace}, "workspace", "workspace `slug`, defaults to $SEGMENT_WORKSPACE")
+	fs.Var(sharedString{&g.BaseURL}, "base-url", "`URL` of the Config API")
+	fs.Var(sharedString{&g.output}, "o", "output `format`, table, json or yaml")
+	fs.Var(sharedBool{&g.yes}, "yes", "do not ask for confirmation")
+	return fs
+}
+
+// parse parses the flags of a command and checks its number of arguments
+func (e *env) parse(fs *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	// Flags may follow the arguments too
+	var positional []string
+	for rest := fs.Args(); len(rest) > 0; rest = fs.Args() {
+		positional = append(positional, rest[0])
+		if err := parseFlags(fs, rest[1:]); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkOutput(e.global.output); err != nil {
+		return nil, err
+	}
+	if len(positional) != nargs {
+		fs.Usage()
+		return nil, usagef("%s expects %d arguments, got %d", fs.Name(), nargs, len(positional))
+	}
+	return positional, nil
+}
+
+// parseFlags parses flags, and reports invalid flags as usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && err != flag.ErrHelp {
+		return usagef("%v", err)
+	}
+	return err
+}
+
+// client returns a client for the configured workspace
+func (e *env) client() (*segment.Client, error) {
+	s, err := e.settings()
+	if err != nil {
+		return nil, err
+	}
+	return s.Client(), nil
+}
+
+// workspace returns the slug of the configured workspace
+func (e *env) workspace() (string, error) {
+	s, err := e.settings()
+	return s.Workspace, err
+}
+
+// settings resolves the settings of the command, and reports missing settings as usage errors
+func (e *env) settings() (cli.Profile, error) {
+	s, err := cli.LoadSettings(e.global.Flags)
+	if _, ok := err.(cli.MissingError); ok {
+		return s, usagef("%v", err)
+	}
+	return s, err
+}
+
+// confirm asks the user to confirm a destructive command, and returns errAborted if they do not
+func (e *env) confirm(format string, args ...interface{}) error {
+	if e.global.yes {
+		return nil
+	}
+	fmt.Fprintf(e.stderr, format+" [y/N] ", args...)
+	answer, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "failed to read confirmation")
+	}
+	if err == io.EOF {
+		fmt.Fprintln(e.stderr)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errAborted
+}
+
+func resourceNames() []string {
+	var names []string
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func commandNames(commands map[string]command) []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
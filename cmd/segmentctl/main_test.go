@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAPI is a Config API serving the sources of workspace ws and the destinations of source js. It records the requests that change something with their bodies, and the access token
+// of the last request.
+type testAPI struct {
+	*httptest.Server
+	calls  []string
+	bodies []string
+	token  string
+}
+
+func newTestAPI() *testAPI {
+	api := &testAPI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1beta/workspaces/ws/sources", func(w http.ResponseWriter, r *http.Request) {
+		api.token = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"sources": [{"name": "workspaces/ws/sources/js", "catalog_name": "catalog/sources/javascript", "write_keys": ["wk"]}]}`)
+	})
+	mux.HandleFunc("/v1beta/workspaces/ws/sources/js", func(w http.ResponseWriter, r *http.Request) {
+		api.token = r.Header.Get("Authorization")
+		if r.Method != http.MethodGet {
+			api.calls = append(api.calls, r.Method+" "+r.URL.Path)
+		}
+		fmt.Fprint(w, `{"name": "workspaces/ws/sources/js", "catalog_name": "catalog/sources/javascript"}`)
+	})
+	mux.HandleFunc("/v1beta/workspaces/ws/sources/js/destinations", func(w http.ResponseWriter, r *http.Request) {
+		api.calls = append(api.calls, r.Method+" "+r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		api.bodies = append(api.bodies, string(body))
+		fmt.Fprint(w, `{"name": "workspaces/ws/sources/js/destinations/amplitude", "enabled": true}`)
+	})
+	mux.HandleFunc("/v1beta/workspaces/ws/sources/js/destinations/mixpanel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			api.calls = append(api.calls, r.Method+" "+r.URL.Path)
+			body, _ := ioutil.ReadAll(r.Body)
+			api.bodies = append(api.bodies, string(body))
+		}
+		fmt.Fprint(w, `{"name": "workspaces/ws/sources/js/destinations/mixpanel", "enabled": true,
+			"config": [{"name": "workspaces/ws/sources/js/destinations/mixpanel/config/token", "type": "string", "value": "${env:SEGMENTCTL_TEST_TOKEN}"}]}`)
+	})
+	api.Server = httptest.NewServer(mux)
+	return api
+}
+
+// setupRun serves the test API and writes a config file whose current profile points at it. The
+// settings environment variables are cleared until the returned function is called.
+func setupRun(t *testing.T) (*testAPI, func()) {
+	api := newTestAPI()
+	home, err := ioutil.TempDir("", "segmentctl")
+	assert.NoError(t, err)
+	config := fmt.Sprintf("current_profile: test\nprofiles:\n  test:\n    access_token: profile-token\n    workspace: ws\n    base_url: %s\n", api.URL)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(home, ".segmentctl.yaml"), []byte(config), 0600))
+
+	vars := []string{"HOME", "ACCESS_TOKEN", "SEGMENT_WORKSPACE", "SEGMENTCTL_CONFIG", "SEGMENTCTL_PROFILE"}
+	prev := map[string]string{}
+	for _, k := range vars {
+		prev[k] = os.Getenv(k)
+		os.Unsetenv(k)
+	}
+	os.Setenv("HOME", home)
+	return api, func() {
+		for k, v := range prev {
+			os.Setenv(k, v)
+		}
+		os.RemoveAll(home)
+		api.Close()
+	}
+}
+
+// runCommand runs segmentctl with args and stdin, and returns its exit status and output
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}
+	code := run(e, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	api, teardown := setupRun(t)
+	defer teardown()
+
+	code, stdout, _ := runCommand("", "sources", "list")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "NAME  CATALOG                     WRITE KEYS  CREATED\njs    catalog/sources/javascript  wk          \n", stdout)
+
+	code, _, stderr := runCommand("", "sources", "get", "ios")
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr, "segmentctl: ")
+
+	code, _, stderr = runCommand("", "sources", "list", "extra")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "segmentctl: sources list expects 0 arguments, got 1\n")
+	code, _, _ = runCommand("", "widgets", "list")
+	assert.Equal(t, exitUsage, code)
+	code, _, stderr = runCommand("", "-o", "xml", "sources", "list")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown output format "xml"`)
+
+	code, _, stderr = runCommand("n\n", "sources", "delete", "js")
+	assert.Equal(t, exitAborted, code)
+	assert.Equal(t, "Delete source js and its destinations? [y/N] segmentctl: aborted\n", stderr)
+	code, _, _ = runCommand("", "sources", "delete", "js")
+	assert.Equal(t, exitAborted, code, "no answer declines")
+	assert.Empty(t, api.calls, "nothing is deleted when the confirmation is declined")
+
+	code, _, _ = runCommand("y\n", "sources", "delete", "js")
+	assert.Equal(t, exitOK, code)
+	code, _, _ = runCommand("", "sources", "delete", "js", "-yes")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, []string{"DELETE /v1beta/workspaces/ws/sources/js", "DELETE /v1beta/workspaces/ws/sources/js"}, api.calls)
+}
+
+func TestRun_SettingsPrecedence(t *testing.T) {
+	api, teardown := setupRun(t)
+	defer teardown()
+
+	code, _, _ := runCommand("", "sources", "list")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer profile-token", api.token, "the current profile is used")
+
+	os.Setenv("ACCESS_TOKEN", "env-token")
+	code, _, _ = runCommand("", "sources", "list")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer env-token", api.token, "the environment takes precedence over the profile")
+
+	code, _, _ = runCommand("", "sources", "list", "-token", "flag-token")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer flag-token", api.token, "flags take precedence over the environment")
+
+	os.Setenv("SEGMENT_WORKSPACE", "other")
+	code, _, _ = runCommand("", "sources", "list")
+	assert.Equal(t, exitFailed, code, "the workspace of the environment is used")
+	code, _, _ = runCommand("", "-workspace", "ws", "sources", "list")
+	assert.Equal(t, exitOK, code)
+
+	code, _, stderr := runCommand("", "-profile", "prod", "sources", "list")
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr, `profile "prod" is not in`)
+
+	os.Unsetenv("ACCESS_TOKEN")
+	os.Setenv("HOME", filepath.Join(os.TempDir(), "segmentctl-missing"))
+	code, _, stderr = runCommand("", "sources", "list")
+	assert.Equal(t, exitUsage, code)
+	assert.Equal(t, "segmentctl: no access token, set -token, ACCESS_TOKEN or a profile\n", stderr)
+}
+
+func TestRun_Output(t *testing.T) {
+	_, teardown := setupRun(t)
+	defer teardown()
+
+	code, stdout, _ := runCommand("", "sources", "get", "js", "-o", "yaml")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "catalog_name: catalog/sources/javascript\nlibrary_config: {}\nname: workspaces/ws/sources/js\n", stdout)
+
+	code, stdout, _ = runCommand("", "-o", "json", "sources", "get", "js")
+	assert.Equal(t, exitOK, code)
+	assert.JSONEq(t, `{"name": "workspaces/ws/sources/js", "catalog_name": "catalog/sources/javascript", "library_config": {}}`, stdout)
+}
+
+func TestRun_DestinationsKeepSettings(t *testing.T) {
+	api, teardown := setupRun(t)
+	defer teardown()
+	os.Setenv("SEGMENTCTL_TEST_TOKEN", "resolved")
+	defer os.Unsetenv("SEGMENTCTL_TEST_TOKEN")
+
+	// the settings read back from the API are sent as is, even when they look like secret references
+	code, _, _ := runCommand("", "destinations", "disable", "js", "mixpanel", "-yes")
+	assert.Equal(t, exitOK, code)
+	code, _, _ = runCommand("", "destinations", "update", "js", "mixpanel", "-set", "people=true")
+	assert.Equal(t, exitOK, code)
+	if !assert.Len(t, api.bodies, 2) {
+		return
+	}
+	for _, body := range api.bodies {
+		assert.Contains(t, body, "${env:SEGMENTCTL_TEST_TOKEN}")
+		assert.NotContains(t, body, "resolved")
+	}
+}
+
+func TestRun_DestinationsResolveSecrets(t *testing.T) {
+	api, teardown := setupRun(t)
+	defer teardown()
+	os.Setenv("SEGMENTCTL_TEST_TOKEN", "resolved")
+	defer os.Unsetenv("SEGMENTCTL_TEST_TOKEN")
+	os.Setenv("SEGMENTCTL_TEST_SECRET", "s3cr3t")
+	defer os.Unsetenv("SEGMENTCTL_TEST_SECRET")
+
+	code, _, _ := runCommand("", "destinations", "create", "js", "amplitude", "-set", "apiKey=${env:SEGMENTCTL_TEST_SECRET}")
+	assert.Equal(t, exitOK, code)
+	code, _, _ = runCommand("", "destinations", "update", "js", "mixpanel", "-set", "apiSecret=${env:SEGMENTCTL_TEST_SECRET}")
+	assert.Equal(t, exitOK, code)
+	if !assert.Len(t, api.bodies, 2) {
+		return
+	}
+	for _, body := range api.bodies {
+		assert.Contains(t, body, `"value":"s3cr3t"`)
+		assert.NotContains(t, body, "${env:SEGMENTCTL_TEST_SECRET}")
+	}
+	assert.Contains(t, api.bodies[1], "${env:SEGMENTCTL_TEST_TOKEN}", "the settings read back are not resolved")
+
+	code, _, stderr := runCommand("", "destinations", "create", "js", "amplitude", "-set", "apiKey=${env:SEGMENTCTL_TEST_MISSING}")
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr, "SEGMENTCTL_TEST_MISSING")
+	assert.Len(t, api.bodies, 2, "nothing is sent when a reference cannot be resolved")
+}
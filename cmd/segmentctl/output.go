@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// checkOutput checks the output format before a command changes anything
+func checkOutput(output string) error {
+	switch output {
+	case "", outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return usagef("unknown output format %q, expected table, json or yaml", output)
+}
+
+// table is the table output of a command
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// print writes v as JSON or YAML, or t as a table
+func (e *env) print(v interface{}, t table) error {
+	switch e.global.output {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal output")
+		}
+		_, err = fmt.Fprintln(e.stdout, string(data))
+		return err
+	case outputYAML:
+		// Round trip through JSON so that YAML uses the same field names as the Config API
+		data, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal output")
+		}
+		var generic interface{}
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return errors.Wrap(err, "failed to marshal output")
+		}
+		data, err = yaml.Marshal(generic)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal output")
+		}
+		_, err = e.stdout.Write(data)
+		return err
+	}
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
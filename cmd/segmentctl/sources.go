@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+var sourceCommands = map[string]command{
+	"list":   listSources,
+	"get":    getSource,
+	"create": createSource,
+	"delete": deleteSource,
+}
+
+func sourceTable(sources ...segment.Source) table {
+	t := table{headers: []string{"NAME", "CATALOG", "WRITE KEYS", "CREATED"}}
+	for _, src := range sources {
+		t.add(segment.Slug(src.Name), src.CatalogName, strings.Join(src.WriteKeys, ","), formatTime(src.CreateTime))
+	}
+	return t
+}
+
+func listSources(e *env, args []string) error {
+	fs := e.flagSet("sources list", "")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	sources, err := c.ListSources()
+	if err != nil {
+		return err
+	}
+	if sources.Sources == nil {
+		sources.Sources = []segment.Source{}
+	}
+	return e.print(sources.Sources, sourceTable(sources.Sources...))
+}
+
+func getSource(e *env, args []string) error {
+	fs := e.flagSet("sources get", "<source>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	src, err := c.GetSource(args[0])
+	if err != nil {
+		return err
+	}
+	return e.print(src, sourceTable(src))
+}
+
+func createSource(e *env, args []string) error {
+	fs := e.flagSet("sources create", "-catalog <catalog> <source>")
+	catalog := fs.String("catalog", "", "catalog name of the source, e.g. catalog/sources/javascript")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *catalog == "" {
+		return usagef("-catalog is required")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	src, err := c.CreateSource(args[0], *catalog)
+	if err != nil {
+		return err
+	}
+	return e.print(src, sourceTable(src))
+}
+
+func deleteSource(e *env, args []string) error {
+	fs := e.flagSet("sources delete", "<source>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := e.confirm("Delete source %s and its destinations?", args[0]); err != nil {
+		return err
+	}
+	return c.DeleteSource(args[0])
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/planfile"
+)
+
+var trackingPlanCommands = map[string]command{
+	"list":   listTrackingPlans,
+	"get":    getTrackingPlan,
+	"create": createTrackingPlan,
+	"update": updateTrackingPlan,
+	"delete": deleteTrackingPlan,
+}
+
+func trackingPlanTable(plans ...segment.TrackingPlan) table {
+	t := table{headers: []string{"NAME", "DISPLAY NAME", "EVENTS", "UPDATED"}}
+	for _, p := range plans {
+		t.add(segment.Slug(p.Name), p.DisplayName, fmt.Sprint(len(p.Rules.Events)), formatTime(p.UpdateTime))
+	}
+	return t
+}
+
+func listTrackingPlans(e *env, args []string) error {
+	fs := e.flagSet("tracking-plans list", "")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return err
+	}
+	if plans.TrackingPlans == nil {
+		plans.TrackingPlans = []segment.TrackingPlan{}
+	}
+	return e.print(plans.TrackingPlans, trackingPlanTable(plans.TrackingPlans...))
+}
+
+func getTrackingPlan(e *env, args []string) error {
+	fs := e.flagSet("tracking-plans get", "<plan>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	p, err := c.GetTrackingPlan(args[0])
+	if err != nil {
+		return err
+	}
+	return e.print(p, trackingPlanTable(p))
+}
+
+func createTrackingPlan(e *env, args []string) error {
+	fs := e.flagSet("tracking-plans create", "-display-name <name> [-file <path>]")
+	displayName := fs.String("display-name", "", "display name of the tracking plan")
+	file := fs.String("file", "", "tracking plan file or directory written by the planfile package, for the rules")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *displayName == "" {
+		return usagef("-display-name is required")
+	}
+	var rules segment.Rules
+	if *file != "" {
+		tp, err := planfile.Load(*file)
+		if err != nil {
+			return err
+		}
+		rules = tp.Rules
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	p, err := c.CreateTrackingPlan(*displayName, rules)
+	if err != nil {
+		return err
+	}
+	return e.print(p, trackingPlanTable(p))
+}
+
+func updateTrackingPlan(e *env, args []string) error {
+	fs := e.flagSet("tracking-plans update", "[-display-name <name>] [-file <path>] <plan>")
+	displayName := fs.String("display-name", "", "new display name of the tracking plan")
+	file := fs.String("file", "", "tracking plan file or directory written by the planfile package, for the new rules")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *displayName == "" && *file == "" {
+		return usagef("one of -display-name or -file is required")
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	updated, err := c.GetTrackingPlan(args[0])
+	if err != nil {
+		return err
+	}
+	if *file != "" {
+		tp, err := planfile.Load(*file)
+		if err != nil {
+			return err
+		}
+		updated.Rules = tp.Rules
+	}
+	if *displayName != "" {
+		updated.DisplayName = *displayName
+	}
+	p, _, err := c.ApplyTrackingPlanChanges(args[0], updated)
+	if err != nil {
+		return err
+	}
+	return e.print(p, trackingPlanTable(p))
+}
+
+func deleteTrackingPlan(e *env, args []string) error {
+	fs := e.flagSet("tracking-plans delete", "<plan>")
+	args, err := e.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	if err := e.confirm("Delete tracking plan %s?", args[0]); err != nil {
+		return err
+	}
+	return c.DeleteTrackingPlan(args[0])
+}
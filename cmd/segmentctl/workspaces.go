@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/internal/cli"
+	"github.com/fenderdigital/segment-apis-go/segment/inventory"
+	"github.com/fenderdigital/segment-apis-go/segment/topology"
+	"github.com/fenderdigital/segment-apis-go/segment/watch"
+)
+
+var workspaceCommands = map[string]command{
+	"get":       getWorkspace,
+	"graph":     graphWorkspace,
+	"inventory": inventoryWorkspace,
+	"list":      listWorkspaces,
+	"watch":     watchWorkspace,
+}
+
+// workspaceProfile is a profile of the config file
+type workspaceProfile struct {
+	Profile   string `json:"profile"`
+	Workspace string `json:"workspace"`
+	Current   bool   `json:"current,omitempty"`
+}
+
+func getWorkspace(e *env, args []string) error {
+	fs := e.flagSet("workspaces get", "")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	w, err := c.GetWorkspace()
+	if err != nil {
+		return err
+	}
+	t := table{headers: []string{"NAME", "DISPLAY NAME", "ID", "CREATED"}}
+	t.add(w.Name, w.DisplayName, w.ID, formatTime(w.CreateTime))
+	return e.print(w, t)
+}
+
+// listWorkspaces lists the workspaces of the profiles of the config file, as the Config API cannot
+// list the workspaces an access token has access to
+func listWorkspaces(e *env, args []string) error {
+	fs := e.flagSet("workspaces list", "")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	path, _ := e.global.ConfigPath()
+	cfg, err := cli.ReadConfig(path)
+	if err != nil {
+		return err
+	}
+	current := e.global.ProfileName()
+	if current == "" {
+		current = cfg.CurrentProfile
+	}
+
+	profiles := []workspaceProfile{}
+	for name, p := range cfg.Profiles {
+		profiles = append(profiles, workspaceProfile{Profile: name, Workspace: p.Workspace, Current: name == current})
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Profile < profiles[j].Profile })
+	t := table{headers: []string{"CURRENT", "PROFILE", "WORKSPACE"}}
+	for _, p := range profiles {
+		marker := ""
+		if p.Current {
+			marker = "*"
+		}
+		t.add(marker, p.Profile, p.Workspace)
+	}
+	return e.print(profiles, t)
+}
+
+// stringList collects the values of a repeated flag, which may also be separated by commas
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, strings.Split(v, ",")...)
+	return nil
+}
+
+// graphWorkspace prints the topology of the workspace as a Graphviz DOT or Mermaid diagram, or as
+// JSON
+func graphWorkspace(e *env, args []string) error {
+	fs := e.flagSet("workspaces graph", "")
+	var f topology.Filter
+	fs.Var((*stringList)(&f.Sources), "source", "only graph the source with this `slug`, may be repeated")
+	fs.Var((*stringList)(&f.Catalogs), "catalog", "only graph sources and destinations of this catalog `type`, may be repeated")
+	enabled := fs.String("enabled", "", "only graph enabled destinations when true, or disabled ones when false")
+	format := fs.String("format", "dot", "graph `format`, dot, mermaid or json")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *enabled != "" {
+		v, err := strconv.ParseBool(*enabled)
+		if err != nil {
+			return usagef("invalid -enabled value %q", *enabled)
+		}
+		f.Enabled = &v
+	}
+	if *format != "dot" && *format != "mermaid" && *format != "json" {
+		return usagef("unknown graph format %q", *format)
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	g, err := topology.Build(c, f)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "mermaid":
+		_, err = fmt.Fprint(e.stdout, g.Mermaid())
+	case "json":
+		var data []byte
+		if data, err = g.JSON(); err == nil {
+			_, err = fmt.Fprintln(e.stdout, string(data))
+		}
+	default:
+		_, err = fmt.Fprint(e.stdout, g.DOT())
+	}
+	return err
+}
+
+// inventoryWorkspace prints the inventory of the destinations of the workspace as a CSV, JSON or
+// Markdown report
+func inventoryWorkspace(e *env, args []string) error {
+	fs := e.flagSet("workspaces inventory", "")
+	format := fs.String("format", "csv", "report `format`, csv, json or markdown")
+	var opts inventory.Options
+	fs.IntVar(&opts.Parallelism, "parallelism", inventory.DefaultParallelism, "maximum `number` of concurrent requests")
+	fs.IntVar(&opts.DisabledDays, "disabled-days", inventory.DefaultDisabledDays, "flag destinations disabled for more than this number of `days`")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" && *format != "markdown" {
+		return usagef("unknown report format %q", *format)
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	inv, err := inventory.Crawl(c, opts)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		return inv.WriteJSON(e.stdout)
+	case "markdown":
+		return inv.WriteMarkdown(e.stdout)
+	}
+	return inv.WriteCSV(e.stdout)
+}
+
+// watchWorkspace prints the changes made to the workspace until interrupted, one per line as text
+// or as JSON
+func watchWorkspace(e *env, args []string) error {
+	fs := e.flagSet("workspaces watch", "")
+	var opts watch.Options
+	fs.DurationVar(&opts.Interval, "interval", watch.DefaultInterval, "`time` between polls")
+	fs.DurationVar(&opts.Jitter, "jitter", 0, "maximum random `time` added to each interval")
+	checkpoint := fs.String("checkpoint", "", "`path` of a file to resume from and save the checkpoint of each poll to")
+	format := fs.String("format", "text", "event `format`, text or json")
+	if _, err := e.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown event format %q", *format)
+	}
+	if *checkpoint != "" {
+		c, err := watch.LoadCheckpoint(*checkpoint)
+		if err != nil {
+			return err
+		}
+		opts.Checkpoint = c
+		opts.OnCheckpoint = func(c watch.Checkpoint) error { return c.Save(*checkpoint) }
+	}
+	opts.OnError = func(err error) {
+		fmt.Fprintf(e.stderr, "segmentctl: %s: %v\n", time.Now().Format(time.RFC3339), err)
+	}
+
+	c, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err = watch.NewWatcher(c, opts).Run(ctx, func(ev watch.Event) error {
+		if *format == "json" {
+			if ev.Destination != nil {
+				// Secret settings are redacted from the output
+				d := ev.Destination.Redacted()
+				ev.Destination = &d
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(e.stdout, string(data))
+			return err
+		}
+		_, err := fmt.Fprintln(e.stdout, ev)
+		return err
+	})
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/pkg/errors v0.8.1
 	github.com/stretchr/testify v1.4.0
+	gopkg.in/yaml.v2 v2.2.2
 )
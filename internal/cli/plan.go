@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/planfile"
+)
+
+// PlanFlags choose the tracking plan a command reads, either from a file or from the Config API
+type PlanFlags struct {
+	Flags
+	File string
+	Plan string
+}
+
+// Register adds the -file and -plan flags and the settings flags to a flag set
+func (f *PlanFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.File, "file", "", "tracking plan file or directory written by the planfile package")
+	fs.StringVar(&f.Plan, "plan", "", "name of a tracking plan to fetch from the Segment Config API, e.g. rs_123")
+	f.Flags.Register(fs)
+}
+
+// LoadPlan reads the tracking plan chosen by the flags
+func (f PlanFlags) LoadPlan() (segment.TrackingPlan, error) {
+	switch {
+	case f.File != "" && f.Plan != "":
+		return segment.TrackingPlan{}, fmt.Errorf("-file and -plan are mutually exclusive")
+	case f.File != "":
+		return planfile.Load(f.File)
+	case f.Plan != "":
+		c, err := Client(f.Flags)
+		if err != nil {
+			return segment.TrackingPlan{}, err
+		}
+		return c.GetTrackingPlan(f.Plan)
+	}
+	return segment.TrackingPlan{}, fmt.Errorf("one of -file or -plan is required")
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanFlags_LoadPlan(t *testing.T) {
+	path, teardown := setupConfig(t)
+	defer teardown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/workspaces/ws/tracking-plans/rs_1", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"name": "workspaces/ws/tracking-plans/rs_1", "display_name": "Kicks"}`)
+	}))
+	defer server.Close()
+
+	f := PlanFlags{Flags: Flags{Token: "token", Workspace: "ws", BaseURL: server.URL}, Plan: "rs_1"}
+	tp, err := f.LoadPlan()
+	assert.NoError(t, err)
+	assert.Equal(t, "Kicks", tp.DisplayName)
+
+	file := filepath.Join(filepath.Dir(path), "plan.yaml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("display_name: Kicks Web\n"), 0600))
+	defer os.Remove(file)
+	tp, err = PlanFlags{File: file}.LoadPlan()
+	assert.NoError(t, err)
+	assert.Equal(t, "Kicks Web", tp.DisplayName)
+
+	_, err = PlanFlags{File: file, Plan: "rs_1"}.LoadPlan()
+	assert.EqualError(t, err, "-file and -plan are mutually exclusive")
+	_, err = PlanFlags{}.LoadPlan()
+	assert.EqualError(t, err, "one of -file or -plan is required")
+	_, err = PlanFlags{Plan: "rs_1", Flags: Flags{Profile: "dev"}}.LoadPlan()
+	assert.EqualError(t, err, `profile "dev" is not in `+path)
+}
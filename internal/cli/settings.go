@@ -0,0 +1,168 @@
+// Package cli holds what the commands of this module share: how they find the access token,
+// workspace and base URL of the Segment Config API, and how they load tracking plans.
+//
+// Settings are read from flags, then from the ACCESS_TOKEN and SEGMENT_WORKSPACE environment
+// variables, then from a profile of the config file, which defaults to ~/.segmentctl.yaml:
+//
+//	current_profile: prod
+//	profiles:
+//	  prod:
+//	    access_token: ...
+//	    workspace: kicks
+//	  staging:
+//	    access_token: ...
+//	    workspace: kicks-staging
+//	    base_url: https://platform.segmentapis.com
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigFileName is the name of the config file in the home directory
+const ConfigFileName = ".segmentctl.yaml"
+
+// Config is the content of the config file
+type Config struct {
+	CurrentProfile string             `yaml:"current_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of a workspace
+type Profile struct {
+	AccessToken string `yaml:"access_token"`
+	Workspace   string `yaml:"workspace"`
+	BaseURL     string `yaml:"base_url,omitempty"`
+}
+
+// Client returns a client for the workspace of the profile
+func (p Profile) Client() *segment.Client {
+	c := segment.NewClient(p.AccessToken, p.Workspace)
+	if p.BaseURL != "" {
+		c.SetBaseURL(p.BaseURL)
+	}
+	return c
+}
+
+// Flags are the settings given on the command line, which take precedence over the environment
+// and the config file
+type Flags struct {
+	ConfigFile string
+	Profile    string
+	Token      string
+	Workspace  string
+	BaseURL    string
+}
+
+// Register adds the -config, -profile, -token, -workspace and -base-url flags to a flag set
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.ConfigFile, "config", "", "`path` of the config file, defaults to $SEGMENTCTL_CONFIG or ~/.segmentctl.yaml")
+	fs.StringVar(&f.Profile, "profile", "", "config file `profile`, defaults to $SEGMENTCTL_PROFILE or the current profile")
+	fs.StringVar(&f.Token, "token", "", "access `token`, defaults to $ACCESS_TOKEN")
+	fs.StringVar(&f.Workspace, "workspace", "", "workspace `slug`, defaults to $SEGMENT_WORKSPACE")
+	fs.StringVar(&f.BaseURL, "base-url", "", "`URL` of the Config API")
+}
+
+// MissingError is returned when a required setting is set neither by a flag, the environment nor
+// a profile
+type MissingError struct {
+	Setting string
+	Flag    string
+	Env     string
+}
+
+func (e MissingError) Error() string {
+	return fmt.Sprintf("no %s, set -%s, %s or a profile", e.Setting, e.Flag, e.Env)
+}
+
+// ReadConfig reads a config file
+func ReadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return cfg, nil
+}
+
+// LoadSettings resolves the settings of a command. Flags take precedence over environment
+// variables, which take precedence over the selected profile of the config file. The config file
+// may only be missing when neither it nor a profile were chosen explicitly.
+func LoadSettings(f Flags) (Profile, error) {
+	path, explicit := f.ConfigPath()
+	name := f.ProfileName()
+
+	var s Profile
+	cfg, err := ReadConfig(path)
+	switch {
+	case err == nil:
+		if name == "" {
+			name = cfg.CurrentProfile
+		}
+		if name != "" {
+			p, ok := cfg.Profiles[name]
+			if !ok {
+				return s, fmt.Errorf("profile %q is not in %s", name, path)
+			}
+			s = p
+		}
+	case explicit || name != "" || !os.IsNotExist(errors.Cause(err)):
+		return s, err
+	}
+
+	s.AccessToken = firstNonEmpty(f.Token, os.Getenv("ACCESS_TOKEN"), s.AccessToken)
+	s.Workspace = firstNonEmpty(f.Workspace, os.Getenv("SEGMENT_WORKSPACE"), s.Workspace)
+	s.BaseURL = firstNonEmpty(f.BaseURL, s.BaseURL)
+	if s.AccessToken == "" {
+		return s, MissingError{Setting: "access token", Flag: "token", Env: "ACCESS_TOKEN"}
+	}
+	if s.Workspace == "" {
+		return s, MissingError{Setting: "workspace", Flag: "workspace", Env: "SEGMENT_WORKSPACE"}
+	}
+	return s, nil
+}
+
+// Client returns a client for the workspace the flags, environment or config file select
+func Client(f Flags) (*segment.Client, error) {
+	s, err := LoadSettings(f)
+	if err != nil {
+		return nil, err
+	}
+	return s.Client(), nil
+}
+
+// ConfigPath returns the path of the config file, and whether it was chosen explicitly
+func (f Flags) ConfigPath() (string, bool) {
+	if f.ConfigFile != "" {
+		return f.ConfigFile, true
+	}
+	if path := os.Getenv("SEGMENTCTL_CONFIG"); path != "" {
+		return path, true
+	}
+	return filepath.Join(os.Getenv("HOME"), ConfigFileName), false
+}
+
+// ProfileName returns the profile chosen with a flag or environment variable, if any
+func (f Flags) ProfileName() string {
+	return firstNonEmpty(f.Profile, os.Getenv("SEGMENTCTL_PROFILE"))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `current_profile: prod
+profiles:
+  prod:
+    access_token: prod-token
+    workspace: kicks
+  staging:
+    access_token: staging-token
+    workspace: kicks-staging
+    base_url: http://localhost:8080
+`
+
+// setenv sets environment variables for a test, and returns a function that restores them
+func setenv(vars map[string]string) func() {
+	prev := map[string]*string{}
+	for k, v := range vars {
+		if old, ok := os.LookupEnv(k); ok {
+			prev[k] = &old
+		} else {
+			prev[k] = nil
+		}
+		if v == "" {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, v)
+		}
+	}
+	return func() {
+		for k, v := range prev {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+// setupConfig writes the test config file to a temporary home directory and clears the settings
+// environment variables
+func setupConfig(t *testing.T) (string, func()) {
+	home, err := ioutil.TempDir("", "cli")
+	assert.NoError(t, err)
+	path := filepath.Join(home, ConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testConfig), 0600))
+	restore := setenv(map[string]string{"HOME": home, "ACCESS_TOKEN": "", "SEGMENT_WORKSPACE": "",
+		"SEGMENTCTL_CONFIG": "", "SEGMENTCTL_PROFILE": ""})
+	return path, func() {
+		restore()
+		os.RemoveAll(home)
+	}
+}
+
+func TestLoadSettings_Precedence(t *testing.T) {
+	_, teardown := setupConfig(t)
+	defer teardown()
+
+	s, err := LoadSettings(Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{AccessToken: "prod-token", Workspace: "kicks"}, s, "the current profile is used")
+
+	s, err = LoadSettings(Flags{Profile: "staging"})
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{AccessToken: "staging-token", Workspace: "kicks-staging", BaseURL: "http://localhost:8080"}, s)
+
+	defer setenv(map[string]string{"ACCESS_TOKEN": "env-token", "SEGMENT_WORKSPACE": "env-ws", "SEGMENTCTL_PROFILE": "staging"})()
+	s, err = LoadSettings(Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{AccessToken: "env-token", Workspace: "env-ws", BaseURL: "http://localhost:8080"}, s,
+		"the environment takes precedence over the profile")
+
+	s, err = LoadSettings(Flags{Token: "flag-token", Workspace: "flag-ws", BaseURL: "http://segment.test"})
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{AccessToken: "flag-token", Workspace: "flag-ws", BaseURL: "http://segment.test"}, s,
+		"flags take precedence over the environment")
+}
+
+func TestLoadSettings_ConfigFile(t *testing.T) {
+	path, teardown := setupConfig(t)
+	defer teardown()
+
+	_, err := LoadSettings(Flags{Profile: "dev"})
+	assert.EqualError(t, err, `profile "dev" is not in `+path)
+
+	os.Remove(path)
+	s, err := LoadSettings(Flags{Token: "token", Workspace: "ws"})
+	assert.NoError(t, err, "the default config file may be missing")
+	assert.Equal(t, Profile{AccessToken: "token", Workspace: "ws"}, s)
+
+	_, err = LoadSettings(Flags{ConfigFile: path, Token: "token", Workspace: "ws"})
+	assert.Error(t, err, "a chosen config file must exist")
+	_, err = LoadSettings(Flags{Profile: "prod", Token: "token", Workspace: "ws"})
+	assert.Error(t, err, "the config file of a chosen profile must exist")
+}
+
+func TestLoadSettings_Missing(t *testing.T) {
+	path, teardown := setupConfig(t)
+	defer teardown()
+	os.Remove(path)
+
+	_, err := LoadSettings(Flags{Workspace: "ws"})
+	assert.Equal(t, MissingError{Setting: "access token", Flag: "token", Env: "ACCESS_TOKEN"}, err)
+	assert.EqualError(t, err, "no access token, set -token, ACCESS_TOKEN or a profile")
+
+	_, err = LoadSettings(Flags{Token: "token"})
+	assert.EqualError(t, err, "no workspace, set -workspace, SEGMENT_WORKSPACE or a profile")
+}
@@ -0,0 +1,403 @@
+package segment
+
+// Envelope properties every tracking plan rule is nested in
+const (
+	envelopeContext    = "context"
+	envelopeTraits     = "traits"
+	envelopeProperties = "properties"
+)
+
+// RulesBuilder builds the Rules of a tracking plan without hand-nesting the
+// context/traits/properties envelope of every event.
+type RulesBuilder struct {
+	events   []*EventBuilder
+	global   *EnvelopeBuilder
+	identify *EnvelopeBuilder
+	group    *EnvelopeBuilder
+}
+
+// NewRulesBuilder creates an empty tracking plan rules builder
+func NewRulesBuilder() *RulesBuilder {
+	return &RulesBuilder{}
+}
+
+// Event returns the builder for the named event, adding the event if it does not exist yet
+func (b *RulesBuilder) Event(name string) *EventBuilder {
+	for _, e := range b.events {
+		if e.name == name {
+			return e
+		}
+	}
+	e := &EventBuilder{name: name}
+	b.events = append(b.events, e)
+	return e
+}
+
+// Global returns the builder for the rules applied to every call
+func (b *RulesBuilder) Global() *EnvelopeBuilder {
+	if b.global == nil {
+		b.global = &EnvelopeBuilder{}
+	}
+	return b.global
+}
+
+// Identify returns the builder for the rules applied to identify calls
+func (b *RulesBuilder) Identify() *EnvelopeBuilder {
+	if b.identify == nil {
+		b.identify = &EnvelopeBuilder{}
+	}
+	return b.identify
+}
+
+// Group returns the builder for the rules applied to group calls
+func (b *RulesBuilder) Group() *EnvelopeBuilder {
+	if b.group == nil {
+		b.group = &EnvelopeBuilder{}
+	}
+	return b.group
+}
+
+// IdentifyTrait adds a trait rule to identify calls
+func (b *RulesBuilder) IdentifyTrait(name string, p *PropertyBuilder) *RulesBuilder {
+	b.Identify().Trait(name, p)
+	return b
+}
+
+// GroupTrait adds a trait rule to group calls
+func (b *RulesBuilder) GroupTrait(name string, p *PropertyBuilder) *RulesBuilder {
+	b.Group().Trait(name, p)
+	return b
+}
+
+// Rules returns the built rules, ready for CreateTrackingPlan
+func (b *RulesBuilder) Rules() Rules {
+	r := Rules{
+		IdentifyTraits: []Rule{},
+		GroupTraits:    []Rule{},
+		Events:         []Event{},
+	}
+	for _, e := range b.events {
+		r.Events = append(r.Events, e.Event())
+	}
+	if b.global != nil {
+		r.Global = b.global.Rule()
+	}
+	if b.identify != nil {
+		r.Identify = b.identify.Rule()
+	}
+	if b.group != nil {
+		r.Group = b.group.Rule()
+	}
+	return r
+}
+
+// TrackingPlan returns a tracking plan with the built rules, ready for UpdateTrackingPlan
+func (b *RulesBuilder) TrackingPlan(displayName string) TrackingPlan {
+	return TrackingPlan{DisplayName: displayName, Rules: b.Rules()}
+}
+
+// EnvelopeBuilder builds a rule made of context, traits and properties sections
+type EnvelopeBuilder struct {
+	context    *PropertyBuilder
+	traits     *PropertyBuilder
+	properties *PropertyBuilder
+}
+
+// Context adds a rule for a context field
+func (b *EnvelopeBuilder) Context(name string, p *PropertyBuilder) *EnvelopeBuilder {
+	if b.context == nil {
+		b.context = Object()
+	}
+	b.context.Prop(name, p)
+	return b
+}
+
+// Trait adds a rule for a trait
+func (b *EnvelopeBuilder) Trait(name string, p *PropertyBuilder) *EnvelopeBuilder {
+	if b.traits == nil {
+		b.traits = Object()
+	}
+	b.traits.Prop(name, p)
+	return b
+}
+
+// Prop adds a rule for a property
+func (b *EnvelopeBuilder) Prop(name string, p *PropertyBuilder) *EnvelopeBuilder {
+	if b.properties == nil {
+		b.properties = Object()
+	}
+	b.properties.Prop(name, p)
+	return b
+}
+
+// Rule returns the envelope rule including the $schema header
+func (b *EnvelopeBuilder) Rule() Rule {
+	r := Rule{
+		Schema: SchemaDraft07,
+		Type:   SingleType(TypeObject),
+		Properties: map[string]Rule{
+			envelopeContext:    {},
+			envelopeTraits:     {},
+			envelopeProperties: {},
+		},
+	}
+	sections := []struct {
+		name string
+		p    *PropertyBuilder
+	}{
+		{envelopeContext, b.context},
+		{envelopeTraits, b.traits},
+		{envelopeProperties, b.properties},
+	}
+	for _, s := range sections {
+		if s.p == nil {
+			continue
+		}
+		sr := s.p.Rule()
+		r.Properties[s.name] = sr
+		if len(sr.Required) > 0 {
+			r.Required = append(r.Required, s.name)
+		}
+	}
+	return r
+}
+
+// EventBuilder builds the rules of a single track event
+type EventBuilder struct {
+	envelope    EnvelopeBuilder
+	name        string
+	version     int
+	description string
+	labels      map[string]interface{}
+}
+
+// Version sets the event version
+func (e *EventBuilder) Version(v int) *EventBuilder {
+	e.version = v
+	return e
+}
+
+// Description sets the event description
+func (e *EventBuilder) Description(d string) *EventBuilder {
+	e.description = d
+	return e
+}
+
+// Label adds a label to the event rules
+func (e *EventBuilder) Label(key string, value interface{}) *EventBuilder {
+	if e.labels == nil {
+		e.labels = map[string]interface{}{}
+	}
+	e.labels[key] = value
+	return e
+}
+
+// Prop adds a rule for an event property
+func (e *EventBuilder) Prop(name string, p *PropertyBuilder) *EventBuilder {
+	e.envelope.Prop(name, p)
+	return e
+}
+
+// Context adds a rule for an event context field
+func (e *EventBuilder) Context(name string, p *PropertyBuilder) *EventBuilder {
+	e.envelope.Context(name, p)
+	return e
+}
+
+// Trait adds a rule for an event trait
+func (e *EventBuilder) Trait(name string, p *PropertyBuilder) *EventBuilder {
+	e.envelope.Trait(name, p)
+	return e
+}
+
+// Event returns the built event
+func (e *EventBuilder) Event() Event {
+	r := e.envelope.Rule()
+	r.Labels = copyLabels(e.labels)
+	return Event{
+		Name:        e.name,
+		Version:     e.version,
+		Description: e.description,
+		Rules:       r,
+	}
+}
+
+// PropertyBuilder builds the rule of a single property
+type PropertyBuilder struct {
+	rule     Rule
+	required bool
+	nullable bool
+	props    []string
+	children map[string]*PropertyBuilder
+}
+
+func newProperty(types ...string) *PropertyBuilder {
+	p := &PropertyBuilder{}
+	if len(types) > 0 {
+		p.rule.Type = TypeList(types...)
+	}
+	return p
+}
+
+// String returns a builder for a string property
+func String() *PropertyBuilder { return newProperty(TypeString) }
+
+// Number returns a builder for a number property
+func Number() *PropertyBuilder { return newProperty(TypeNumber) }
+
+// Integer returns a builder for an integer property
+func Integer() *PropertyBuilder { return newProperty(TypeInteger) }
+
+// Boolean returns a builder for a boolean property
+func Boolean() *PropertyBuilder { return newProperty(TypeBoolean) }
+
+// Any returns a builder for a property of any type
+func Any() *PropertyBuilder { return newProperty() }
+
+// Object returns a builder for an object property
+func Object() *PropertyBuilder {
+	p := newProperty()
+	p.rule.Type = SingleType(TypeObject)
+	return p
+}
+
+// Array returns a builder for an array property whose items match items. A nil items allows any item.
+func Array(items *PropertyBuilder) *PropertyBuilder {
+	p := newProperty(TypeArray)
+	if items != nil {
+		ir := items.Rule()
+		p.rule.Items = &ir
+	}
+	return p
+}
+
+// Required marks the property as required by its parent
+func (p *PropertyBuilder) Required() *PropertyBuilder {
+	p.required = true
+	return p
+}
+
+// Nullable allows the property to be null, by adding null to its type and to its enum if it has
+// them. An untyped property, such as one built with Any, allows null unless its enum rejects it.
+func (p *PropertyBuilder) Nullable() *PropertyBuilder {
+	p.nullable = true
+	return p
+}
+
+// Description sets the property description
+func (p *PropertyBuilder) Description(d string) *PropertyBuilder {
+	p.rule.Description = d
+	return p
+}
+
+// Enum restricts the property to the given values
+func (p *PropertyBuilder) Enum(values ...interface{}) *PropertyBuilder {
+	p.rule.Enum = Enum(values)
+	return p
+}
+
+// Pattern restricts a string property to values matching the regular expression
+func (p *PropertyBuilder) Pattern(pattern string) *PropertyBuilder {
+	p.rule.Pattern = pattern
+	return p
+}
+
+// Format sets the format of a string property, such as "email" or "date-time"
+func (p *PropertyBuilder) Format(format string) *PropertyBuilder {
+	p.rule.Format = format
+	return p
+}
+
+// Min sets the minimum of a numeric property
+func (p *PropertyBuilder) Min(min float64) *PropertyBuilder {
+	p.rule.Minimum = &min
+	return p
+}
+
+// Max sets the maximum of a numeric property
+func (p *PropertyBuilder) Max(max float64) *PropertyBuilder {
+	p.rule.Maximum = &max
+	return p
+}
+
+// MinLength sets the minimum length of a string property
+func (p *PropertyBuilder) MinLength(n int) *PropertyBuilder {
+	p.rule.MinLength = &n
+	return p
+}
+
+// MaxLength sets the maximum length of a string property
+func (p *PropertyBuilder) MaxLength(n int) *PropertyBuilder {
+	p.rule.MaxLength = &n
+	return p
+}
+
+// Label adds a label to the property
+func (p *PropertyBuilder) Label(key string, value interface{}) *PropertyBuilder {
+	if p.rule.Labels == nil {
+		p.rule.Labels = map[string]interface{}{}
+	}
+	p.rule.Labels[key] = value
+	return p
+}
+
+// Prop adds a nested property to an object property
+func (p *PropertyBuilder) Prop(name string, child *PropertyBuilder) *PropertyBuilder {
+	if p.children == nil {
+		p.children = map[string]*PropertyBuilder{}
+	}
+	if _, ok := p.children[name]; !ok {
+		p.props = append(p.props, name)
+	}
+	p.children[name] = child
+	return p
+}
+
+// Rule returns the built property rule
+func (p *PropertyBuilder) Rule() Rule {
+	r := p.rule
+	r.Labels = copyLabels(p.rule.Labels)
+	if p.nullable {
+		if !r.Type.IsZero() && !r.Type.Nullable() {
+			r.Type = TypeList(append(append([]string{}, r.Type.Names...), TypeNull)...)
+		}
+		if len(r.Enum) > 0 && !enumHasNull(r.Enum) {
+			r.Enum = append(append(Enum{}, r.Enum...), nil)
+		}
+	}
+	if len(p.props) == 0 {
+		return r
+	}
+	r.Properties = map[string]Rule{}
+	r.Required = nil
+	for _, name := range p.props {
+		child := p.children[name]
+		r.Properties[name] = child.Rule()
+		if child.required {
+			r.Required = append(r.Required, name)
+		}
+	}
+	return r
+}
+
+// enumHasNull reports whether null is one of the values of an enum
+func enumHasNull(e Enum) bool {
+	for _, v := range e {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// copyLabels returns a copy of labels, so that built rules do not change with their builder
+func copyLabels(labels map[string]interface{}) map[string]interface{} {
+	if labels == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,172 @@
+package segment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Rules(t *testing.T) {
+	tp := NewRulesBuilder()
+	tp.Event("Order Completed").Version(1).Description("Who bought what").
+		Prop("product", String().Required()).
+		Prop("price", Number().Required()).
+		Prop("amount", Number().Required())
+	tp.Global().Context("library", Object().Required())
+	tp.IdentifyTrait("occupation", String()).
+		IdentifyTrait("age", Number()).
+		IdentifyTrait("name", String().Required())
+	tp.GroupTrait("company", Object().Required())
+
+	expected := Rules{
+		IdentifyTraits: []Rule{},
+		GroupTraits:    []Rule{},
+		Events: []Event{
+			{
+				Name:        "Order Completed",
+				Description: "Who bought what",
+				Version:     1,
+				Rules: Rule{
+					Schema: SchemaDraft07,
+					Type:   SingleType(TypeObject),
+					Properties: map[string]Rule{
+						"context": {},
+						"traits":  {},
+						"properties": {
+							Required: []string{"product", "price", "amount"},
+							Type:     SingleType(TypeObject),
+							Properties: map[string]Rule{
+								"product": {Type: TypeList(TypeString)},
+								"amount":  {Type: TypeList(TypeNumber)},
+								"price":   {Type: TypeList(TypeNumber)},
+							},
+						},
+					},
+					Required: []string{"properties"},
+				},
+			},
+		},
+		Global: Rule{
+			Schema: SchemaDraft07,
+			Type:   SingleType(TypeObject),
+			Properties: map[string]Rule{
+				"context": {
+					Required: []string{"library"},
+					Type:     SingleType(TypeObject),
+					Properties: map[string]Rule{
+						"library": {Type: SingleType(TypeObject)},
+					},
+				},
+				"traits":     {},
+				"properties": {},
+			},
+			Required: []string{"context"},
+		},
+		Identify: Rule{
+			Schema: SchemaDraft07,
+			Type:   SingleType(TypeObject),
+			Properties: map[string]Rule{
+				"traits": {
+					Type: SingleType(TypeObject),
+					Properties: map[string]Rule{
+						"occupation": {Type: TypeList(TypeString)},
+						"age":        {Type: TypeList(TypeNumber)},
+						"name":       {Type: TypeList(TypeString)},
+					},
+					Required: []string{"name"},
+				},
+				"properties": {},
+				"context":    {},
+			},
+			Required: []string{"traits"},
+		},
+		Group: Rule{
+			Schema: SchemaDraft07,
+			Type:   SingleType(TypeObject),
+			Properties: map[string]Rule{
+				"properties": {},
+				"context":    {},
+				"traits": {
+					Properties: map[string]Rule{
+						"company": {Type: SingleType(TypeObject)},
+					},
+					Required: []string{"company"},
+					Type:     SingleType(TypeObject),
+				},
+			},
+			Required: []string{"traits"},
+		},
+	}
+
+	assert.Equal(t, expected, tp.Rules())
+}
+
+func TestBuilder_EventIsReused(t *testing.T) {
+	tp := NewRulesBuilder()
+	tp.Event("Signed Up").Prop("plan", String())
+	tp.Event("Signed Up").Version(2).Prop("trial", Boolean())
+
+	rules := tp.Rules()
+	assert.Len(t, rules.Events, 1)
+	assert.Equal(t, 2, rules.Events[0].Version)
+	assert.Len(t, rules.Events[0].Rules.Properties["properties"].Properties, 2)
+	assert.Nil(t, rules.Events[0].Rules.Required)
+}
+
+func TestBuilder_PropertyKeywords(t *testing.T) {
+	p := Object().
+		Prop("currency", String().Required().Enum("USD", "EUR").Pattern("^[A-Z]{3}$").Description("ISO code")).
+		Prop("email", String().Nullable().Format("email").MinLength(3).MaxLength(254)).
+		Prop("quantity", Integer().Min(1).Max(100).Label("pii", false)).
+		Prop("tags", Array(String())).
+		Prop("extra", Any())
+
+	actual, err := json.Marshal(p.Rule())
+	assert.NoError(t, err)
+
+	expected := `{"properties":{` +
+		`"currency":{"description":"ISO code","enum":["USD","EUR"],"pattern":"^[A-Z]{3}$","type":["string"]},` +
+		`"email":{"type":["string","null"],"format":"email","minLength":3,"maxLength":254},` +
+		`"extra":{},` +
+		`"quantity":{"labels":{"pii":false},"type":["integer"],"minimum":1,"maximum":100},` +
+		`"tags":{"type":["array"],"items":{"type":["string"]}}},` +
+		`"required":["currency"],"type":"object"}`
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestBuilder_Nullable(t *testing.T) {
+	assert.Equal(t, TypeList(TypeString, TypeNull), String().Nullable().Rule().Type)
+	assert.Equal(t, SingleType(TypeObject), Object().Rule().Type)
+	assert.True(t, Object().Nullable().Rule().Type.Nullable())
+
+	// untyped properties allow null, and null is added to enums whatever the order of the calls
+	any := Any().Nullable().Rule()
+	assert.True(t, any.Type.IsZero())
+	assert.Equal(t, Enum{"a", "b", nil}, Any().Nullable().Enum("a", "b").Rule().Enum)
+	assert.Equal(t, Enum{"a", nil}, String().Enum("a").Nullable().Rule().Enum)
+	assert.Equal(t, Enum{"a", nil}, String().Enum("a", nil).Nullable().Rule().Enum)
+	assert.Equal(t, Enum{"a"}, String().Enum("a").Rule().Enum)
+}
+
+func TestBuilder_RuleCopiesLabels(t *testing.T) {
+	p := String().Label("pii", true)
+	r := p.Rule()
+	p.Label("owner", "growth")
+	assert.Equal(t, map[string]interface{}{"pii": true}, r.Labels)
+	assert.Nil(t, String().Rule().Labels)
+
+	e := NewRulesBuilder().Event("Signed Up").Label("team", "growth")
+	event := e.Event()
+	e.Label("deprecated", true)
+	assert.Equal(t, map[string]interface{}{"team": "growth"}, event.Rules.Labels)
+}
+
+func TestBuilder_TrackingPlan(t *testing.T) {
+	tp := NewRulesBuilder()
+	tp.Event("Signed Up").Prop("plan", String())
+
+	plan := tp.TrackingPlan("Kicks App")
+	assert.Equal(t, "Kicks App", plan.DisplayName)
+	assert.Len(t, plan.Rules.Events, 1)
+}
@@ -24,6 +24,7 @@ type Client struct {
 	accessToken string
 	workspace   string
 	client      *http.Client
+	secrets     SecretResolver
 }
 
 // NewClient creates a new Segment Config API client.
@@ -37,6 +38,26 @@ func NewClient(accessToken string, workspace string) *Client {
 	}
 }
 
+// SetBaseURL sets the URL the client sends requests to, e.g. a proxy or a test server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetSecretResolver sets the resolver of the secret references in the destination settings the
+// client sends, e.g. DefaultSecretResolver. References are sent as is when it is nil, which is the
+// default.
+func (c *Client) SetSecretResolver(r SecretResolver) {
+	c.secrets = r
+}
+
+// WithSecretResolver returns a copy of the client that resolves secret references with r. A nil
+// resolver sends settings as is, e.g. the values read back from the API.
+func (c *Client) WithSecretResolver(r SecretResolver) *Client {
+	copy := *c
+	copy.secrets = r
+	return &copy
+}
+
 func (c *Client) doRequest(method, endpoint string, data interface{}) ([]byte, error) {
 
 	// Encode data if we are passed an object.
@@ -90,3 +111,9 @@ func (c *Client) doRequest(method, endpoint string, data interface{}) ([]byte, e
 
 	return body, nil
 }
+
+// Slug returns the last segment of a resource name such as
+// "workspaces/myworkspace/sources/js", which is how the API addresses it.
+func Slug(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
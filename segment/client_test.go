@@ -42,6 +42,17 @@ func Test_NewClient(t *testing.T) {
 	testClientDefaultBaseURL(t, c)
 }
 
+func Test_SetBaseURL(t *testing.T) {
+	c := NewClient(testToken, testWorkspace)
+	c.SetBaseURL("http://localhost:8080/")
+	assert.Equal(t, "http://localhost:8080", c.baseURL)
+}
+
+func Test_Slug(t *testing.T) {
+	assert.Equal(t, "js", Slug("workspaces/ws/sources/js"))
+	assert.Equal(t, "js", Slug("js"))
+}
+
 func Test_doRequest(t *testing.T) {
 	setup()
 	defer teardown()
@@ -0,0 +1,504 @@
+// Package codegen generates typed Go helpers from a tracking plan. Each event becomes a struct of
+// its properties with a Validate method checking the plan rules and a Track method building the
+// payload of a track call, so services no longer hand-build property maps that drift from the plan.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Go type kinds of generated values
+const (
+	kindString  = "string"
+	kindInteger = "integer"
+	kindNumber  = "number"
+	kindBoolean = "boolean"
+	kindStruct  = "struct"
+	kindSlice   = "slice"
+	kindMap     = "map"
+	kindAny     = "any"
+)
+
+// Options controls the generated code
+type Options struct {
+	// Package is the name of the generated package
+	Package string
+	// Generator names the tool in the generated code header, defaults to segment-codegen
+	Generator string
+}
+
+// value is the Go type of a property or array item with the rules to validate it against
+type value struct {
+	Type      string
+	Kind      string
+	Pointer   bool
+	Enum      []enumConst
+	Pattern   string
+	Minimum   *float64
+	Maximum   *float64
+	MinLength *int
+	MaxLength *int
+	Item      *value
+}
+
+type enumConst struct {
+	Name    string
+	Literal string
+}
+
+type field struct {
+	Name     string
+	JSON     string
+	Doc      string
+	Required bool
+	value
+}
+
+type structType struct {
+	Name   string
+	Doc    []string
+	Fields []field
+	Event  *segment.Event
+}
+
+type pattern struct {
+	Var    string
+	Regexp string
+}
+
+type enumBlock struct {
+	Doc    string
+	Consts []enumConst
+}
+
+type generator struct {
+	names    namer
+	structs  []*structType
+	enums    []enumBlock
+	patterns []pattern
+	imports  map[string]bool
+}
+
+// Generate returns the Go source of the helpers for every event of a tracking plan. The latest
+// version of an event is named after the event, older versions get a V<n> suffix.
+func Generate(plan segment.TrackingPlan, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	if opts.Generator == "" {
+		opts.Generator = "segment-codegen"
+	}
+
+	g := &generator{names: namer{"Track": true}, imports: map[string]bool{}}
+	events := append([]segment.Event(nil), plan.Rules.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		return events[i].Version > events[j].Version
+	})
+	for i := range events {
+		e := &events[i]
+		name := exportedName(e.Name)
+		if i > 0 && events[i-1].Name == e.Name {
+			name += fmt.Sprintf("V%d", e.Version)
+		}
+		doc := []string{fmt.Sprintf("%s holds the properties of the %q event", name, e.Name)}
+		if e.Version > 0 {
+			doc[0] += fmt.Sprintf(" version %d", e.Version)
+		}
+		if e.Description != "" {
+			doc = append(doc, "")
+			doc = append(doc, strings.Split(e.Description, "\n")...)
+		}
+		s := g.structType(name, e.Rules.Properties["properties"], doc)
+		s.Event = e
+	}
+
+	var body bytes.Buffer
+	g.writeBody(&body)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by %s. DO NOT EDIT.\n", opts.Generator)
+	if plan.DisplayName != "" {
+		fmt.Fprintf(&src, "// Tracking plan: %s\n", plan.DisplayName)
+	}
+	fmt.Fprintf(&src, "\npackage %s\n\n", opts.Package)
+	if len(g.imports) > 0 {
+		var imports []string
+		for imp := range g.imports {
+			imports = append(imports, strconv.Quote(imp))
+		}
+		sort.Strings(imports)
+		fmt.Fprintf(&src, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to format generated code")
+	}
+	return out, nil
+}
+
+// structType adds the struct for an object rule and returns it
+func (g *generator) structType(name string, r segment.Rule, doc []string) *structType {
+	s := &structType{Name: g.names.unique(name), Doc: doc}
+	g.structs = append(g.structs, s)
+
+	props := make([]string, 0, len(r.Properties))
+	for p := range r.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	fields := namer{"EventName": true, "Track": true, "Validate": true}
+	for _, p := range props {
+		child := r.Properties[p]
+		f := field{
+			Name:     fields.unique(exportedName(p)),
+			JSON:     p,
+			Doc:      child.Description,
+			Required: r.IsRequired(p),
+		}
+		f.value = g.value(s.Name+f.Name, child)
+		if !f.Required && (f.Kind != kindSlice && f.Kind != kindMap && f.Kind != kindAny) {
+			f.Pointer = true
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s
+}
+
+// value returns the Go type of a rule. Nullable scalars and structs are pointers.
+func (g *generator) value(name string, r segment.Rule) value {
+	v := value{Type: "interface{}", Kind: kindAny}
+	types := r.Type.NonNull()
+	if r.Ref != "" || len(types) != 1 {
+		return v
+	}
+	switch types[0] {
+	case segment.TypeString:
+		v = value{Type: "string", Kind: kindString, Pattern: g.pattern(name, r.Pattern),
+			MinLength: r.MinLength, MaxLength: r.MaxLength}
+	case segment.TypeInteger:
+		v = value{Type: "int64", Kind: kindInteger, Minimum: r.Minimum, Maximum: r.Maximum}
+	case segment.TypeNumber:
+		v = value{Type: "float64", Kind: kindNumber, Minimum: r.Minimum, Maximum: r.Maximum}
+	case segment.TypeBoolean:
+		v = value{Type: "bool", Kind: kindBoolean}
+	case segment.TypeObject:
+		if len(r.Properties) == 0 {
+			return value{Type: "map[string]interface{}", Kind: kindMap}
+		}
+		s := g.structType(name, r, nil)
+		s.Doc = []string{fmt.Sprintf("%s is a nested object", s.Name)}
+		v = value{Type: s.Name, Kind: kindStruct}
+	case segment.TypeArray:
+		v = value{Type: "[]interface{}", Kind: kindSlice}
+		if r.Items != nil {
+			item := g.value(name+"Item", *r.Items)
+			v.Item = &item
+			v.Type = "[]" + item.goType()
+		}
+		return v
+	default:
+		return v
+	}
+	v.Pointer = r.Type.Nullable()
+	v.Enum = g.enum(name, v.Kind, r.Enum)
+	return v
+}
+
+func (v value) goType() string {
+	if v.Pointer {
+		return "*" + v.Type
+	}
+	return v.Type
+}
+
+// enum adds constants for the allowed values of a string or numeric rule. Values that repeat, such
+// as 1 and 1.0, get a single constant so that the generated switch has no duplicate cases.
+func (g *generator) enum(name, kind string, enum segment.Enum) []enumConst {
+	if len(enum) == 0 || (kind != kindString && kind != kindInteger && kind != kindNumber) {
+		return nil
+	}
+	var consts []enumConst
+	seen := map[string]bool{}
+	for _, v := range enum {
+		var lit string
+		switch kind {
+		case kindString:
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			lit = strconv.Quote(s)
+		default:
+			f, ok := number(v)
+			if !ok || (kind == kindInteger && f != math.Trunc(f)) {
+				continue
+			}
+			lit = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if seen[lit] {
+			continue
+		}
+		seen[lit] = true
+		suffix := camelCase(fmt.Sprint(v))
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		consts = append(consts, enumConst{Name: g.names.unique(name + suffix), Literal: lit})
+	}
+	if len(consts) > 0 {
+		g.enums = append(g.enums, enumBlock{Doc: fmt.Sprintf("Allowed values of %s", name), Consts: consts})
+	}
+	return consts
+}
+
+func (g *generator) pattern(name, re string) string {
+	if re == "" {
+		return ""
+	}
+	p := pattern{Var: g.names.unique("pattern" + name), Regexp: re}
+	g.patterns = append(g.patterns, p)
+	g.imports["regexp"] = true
+	return p.Var
+}
+
+func (g *generator) writeBody(w *bytes.Buffer) {
+	w.WriteString(`// Track is the payload of a track call
+type Track struct {
+	Type        string      ` + "`json:\"type\"`" + `
+	Event       string      ` + "`json:\"event\"`" + `
+	UserID      string      ` + "`json:\"userId,omitempty\"`" + `
+	AnonymousID string      ` + "`json:\"anonymousId,omitempty\"`" + `
+	Properties  interface{} ` + "`json:\"properties\"`" + `
+}
+
+`)
+	for _, b := range g.enums {
+		fmt.Fprintf(w, "// %s\nconst (\n", b.Doc)
+		for _, c := range b.Consts {
+			fmt.Fprintf(w, "%s = %s\n", c.Name, c.Literal)
+		}
+		w.WriteString(")\n\n")
+	}
+	if len(g.patterns) > 0 {
+		w.WriteString("var (\n")
+		for _, p := range g.patterns {
+			fmt.Fprintf(w, "%s = regexp.MustCompile(%s)\n", p.Var, goString(p.Regexp))
+		}
+		w.WriteString(")\n\n")
+	}
+	for _, s := range g.structs {
+		g.writeStruct(w, s)
+	}
+}
+
+func (g *generator) writeStruct(w *bytes.Buffer, s *structType) {
+	for _, line := range s.Doc {
+		writeComment(w, line)
+	}
+	if len(s.Fields) == 0 {
+		fmt.Fprintf(w, "type %s struct{}\n\n", s.Name)
+	} else {
+		fmt.Fprintf(w, "type %s struct {\n", s.Name)
+	}
+	for _, f := range s.Fields {
+		if f.Doc != "" {
+			for _, line := range strings.Split(f.Doc, "\n") {
+				writeComment(w, line)
+			}
+		}
+		tag := f.JSON
+		if !f.Required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(w, "%s %s `json:%s`\n", f.Name, f.goType(), strconv.Quote(tag))
+	}
+	if len(s.Fields) > 0 {
+		w.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(w, "// Validate checks the values against the tracking plan rules\nfunc (e %s) Validate() error {\n", s.Name)
+	for _, f := range s.Fields {
+		expr := "e." + f.Name
+		p := valuePath{format: strings.Replace(f.JSON, "%", "%%", -1)}
+		if f.Required && !f.Pointer && (f.Kind == kindSlice || f.Kind == kindMap || f.Kind == kindAny) {
+			fmt.Fprintf(w, "if %s == nil {\n", expr)
+			g.writeError(w, p, "missing required property")
+			w.WriteString("}\n")
+		}
+		g.writeChecks(w, expr, p, f.value)
+	}
+	w.WriteString("return nil\n}\n\n")
+
+	if s.Event == nil {
+		return
+	}
+	fmt.Fprintf(w, "// EventName returns the name of the event in the tracking plan\nfunc (e %s) EventName() string {\nreturn %s\n}\n\n",
+		s.Name, strconv.Quote(s.Event.Name))
+	fmt.Fprintf(w, `// Track validates the event and returns the payload of its track call
+func (e %s) Track(userID string) (Track, error) {
+if err := e.Validate(); err != nil {
+return Track{}, err
+}
+return Track{Type: "track", Event: %s, UserID: userID, Properties: e}, nil
+}
+
+`, s.Name, strconv.Quote(s.Event.Name))
+}
+
+// valuePath is the path of a value in error messages, as a format string and the names of the
+// loop indexes it is formatted with
+type valuePath struct {
+	format  string
+	indexes []string
+}
+
+func (p valuePath) item() (valuePath, string) {
+	index := string(rune('i' + len(p.indexes)))
+	return valuePath{format: p.format + "[%d]", indexes: append(append([]string(nil), p.indexes...), index)}, index
+}
+
+// writeError writes a return of an error about the value at path p
+func (g *generator) writeError(w *bytes.Buffer, p valuePath, format string, args ...string) {
+	g.imports["fmt"] = true
+	args = append(append([]string(nil), p.indexes...), args...)
+	lit := strconv.Quote(p.format + ": " + format)
+	if len(args) == 0 {
+		fmt.Fprintf(w, "return fmt.Errorf(%s)\n", lit)
+		return
+	}
+	fmt.Fprintf(w, "return fmt.Errorf(%s, %s)\n", lit, strings.Join(args, ", "))
+}
+
+// writeChecks writes the checks of the value expr, guarded by a nil check for pointers
+func (g *generator) writeChecks(w *bytes.Buffer, expr string, p valuePath, v value) {
+	var checks bytes.Buffer
+	g.writeValueChecks(&checks, expr, p, v)
+	if checks.Len() == 0 {
+		return
+	}
+	if v.Pointer {
+		fmt.Fprintf(w, "if %s != nil {\n", expr)
+		w.Write(checks.Bytes())
+		w.WriteString("}\n")
+		return
+	}
+	w.Write(checks.Bytes())
+}
+
+func (g *generator) writeValueChecks(w *bytes.Buffer, expr string, p valuePath, v value) {
+	val := expr
+	if v.Pointer {
+		val = "*" + expr
+	}
+	if len(v.Enum) > 0 {
+		var names []string
+		for _, c := range v.Enum {
+			names = append(names, c.Name)
+		}
+		fmt.Fprintf(w, "switch %s {\ncase %s:\ndefault:\n", val, strings.Join(names, ", "))
+		g.writeError(w, p, "%v is not an allowed value", val)
+		w.WriteString("}\n")
+	}
+	if v.Pattern != "" {
+		fmt.Fprintf(w, "if !%s.MatchString(%s) {\n", v.Pattern, val)
+		g.writeError(w, p, "%q does not match %s", val, v.Pattern+".String()")
+		w.WriteString("}\n")
+	}
+	for _, b := range []struct {
+		bound *float64
+		op    string
+		desc  string
+	}{{v.Minimum, "<", "less than the minimum"}, {v.Maximum, ">", "greater than the maximum"}} {
+		if b.bound == nil {
+			continue
+		}
+		lit := strconv.FormatFloat(*b.bound, 'f', -1, 64)
+		if v.Kind == kindInteger {
+			fmt.Fprintf(w, "if float64(%s) %s %s {\n", val, b.op, lit)
+		} else {
+			fmt.Fprintf(w, "if %s %s %s {\n", val, b.op, lit)
+		}
+		g.writeError(w, p, "%v is "+b.desc+" "+lit, val)
+		w.WriteString("}\n")
+	}
+	for _, b := range []struct {
+		bound *int
+		op    string
+		desc  string
+	}{{v.MinLength, "<", "shorter than"}, {v.MaxLength, ">", "longer than"}} {
+		if b.bound == nil {
+			continue
+		}
+		g.imports["unicode/utf8"] = true
+		fmt.Fprintf(w, "if utf8.RuneCountInString(%s) %s %d {\n", val, b.op, *b.bound)
+		g.writeError(w, p, fmt.Sprintf("%%q is %s %d characters", b.desc, *b.bound), val)
+		w.WriteString("}\n")
+	}
+	switch {
+	case v.Kind == kindStruct:
+		g.imports["fmt"] = true
+		args := append(append([]string(nil), p.indexes...), "err")
+		fmt.Fprintf(w, "if err := %s.Validate(); err != nil {\nreturn fmt.Errorf(%s, %s)\n}\n",
+			expr, strconv.Quote(p.format+".%v"), strings.Join(args, ", "))
+	case v.Kind == kindSlice && v.Item != nil:
+		var checks bytes.Buffer
+		ip, index := p.item()
+		item := "item"
+		if len(p.indexes) > 0 {
+			item += strconv.Itoa(len(p.indexes) + 1)
+		}
+		g.writeChecks(&checks, item, ip, *v.Item)
+		if checks.Len() > 0 {
+			fmt.Fprintf(w, "for %s, %s := range %s {\n", index, item, val)
+			w.Write(checks.Bytes())
+			w.WriteString("}\n")
+		}
+	}
+}
+
+func writeComment(w *bytes.Buffer, line string) {
+	if line == "" {
+		w.WriteString("//\n")
+		return
+	}
+	fmt.Fprintf(w, "// %s\n", line)
+}
+
+// goString returns a Go string literal, preferring a raw string for regular expressions
+func goString(s string) string {
+	if !strings.Contains(s, "`") && strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// number returns the value of a numeric enum entry
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
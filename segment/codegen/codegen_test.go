@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/planfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate_Golden(t *testing.T) {
+	plan, err := planfile.Load("testdata/plan.yaml")
+	assert.NoError(t, err)
+
+	src, err := Generate(plan, Options{Package: "analytics"})
+	assert.NoError(t, err)
+
+	golden, err := ioutil.ReadFile("internal/analytics/analytics_gen.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(src), "run go generate ./segment/codegen/...")
+}
+
+func TestGenerate_Types(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Signed Up").
+		Prop("plan", segment.String().Enum("free", "pro").Nullable()).
+		Prop("seats", segment.Integer().Enum(1, 5).Required()).
+		Prop("score", segment.Number().Max(1.5)).
+		Prop("validate", segment.Boolean()).
+		Prop("extra", segment.Any().Required()).
+		Prop("matrix", segment.Array(segment.Array(segment.String().Pattern("^a")))).
+		Prop("ref", segment.String())
+
+	src, err := Generate(tp.TrackingPlan("Test"), Options{Package: "events", Generator: "test"})
+	assert.NoError(t, err)
+
+	s := string(src)
+	assert.Contains(t, s, "// Code generated by test. DO NOT EDIT.\n// Tracking plan: Test\n\npackage events\n")
+	assert.Contains(t, s, `type SignedUp struct {
+	Extra     interface{} `+"`json:\"extra\"`"+`
+	Matrix    [][]string  `+"`json:\"matrix,omitempty\"`"+`
+	Plan      *string     `+"`json:\"plan,omitempty\"`"+`
+	Ref       *string     `+"`json:\"ref,omitempty\"`"+`
+	Score     *float64    `+"`json:\"score,omitempty\"`"+`
+	Seats     int64       `+"`json:\"seats\"`"+`
+	Validate2 *bool       `+"`json:\"validate,omitempty\"`"+`
+}`)
+	assert.Contains(t, s, "SignedUpPlanFree = \"free\"")
+	assert.Contains(t, s, "SignedUpSeats5 = 5")
+	assert.Contains(t, s, "if *e.Score > 1.5 {")
+	assert.Contains(t, s, "for j, item2 := range item {")
+	assert.Contains(t, s, `return fmt.Errorf("matrix[%d][%d]: %q does not match %s", i, j, item2, patternSignedUpMatrixItemItem.String())`)
+}
+
+func TestGenerate_DuplicateEnumValues(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Signed Up").
+		Prop("plan", segment.String().Enum("free", "free", "pro").Required()).
+		Prop("seats", segment.Integer().Enum(1, 1.0, 5).Required())
+
+	src, err := Generate(tp.TrackingPlan("Test"), Options{Package: "events"})
+	assert.NoError(t, err)
+
+	s := string(src)
+	assert.Contains(t, s, "case SignedUpPlanFree, SignedUpPlanPro:")
+	assert.Contains(t, s, "case SignedUpSeats1, SignedUpSeats5:")
+	assert.NotContains(t, s, "SignedUpPlanFree2")
+	assert.NotContains(t, s, "SignedUpSeats12")
+}
+
+func TestGenerate_PackageRequired(t *testing.T) {
+	_, err := Generate(segment.TrackingPlan{}, Options{})
+	assert.EqualError(t, err, "package name is required")
+}
@@ -0,0 +1,184 @@
+// Code generated by segment-codegen. DO NOT EDIT.
+// Tracking plan: Kicks
+
+package analytics
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Track is the payload of a track call
+type Track struct {
+	Type        string      `json:"type"`
+	Event       string      `json:"event"`
+	UserID      string      `json:"userId,omitempty"`
+	AnonymousID string      `json:"anonymousId,omitempty"`
+	Properties  interface{} `json:"properties"`
+}
+
+// Allowed values of OrderCompletedCurrency
+const (
+	OrderCompletedCurrencyUSD = "USD"
+	OrderCompletedCurrencyEUR = "EUR"
+)
+
+// Allowed values of OrderCompletedShippingMethod
+const (
+	OrderCompletedShippingMethodStandard = "standard"
+	OrderCompletedShippingMethodExpress  = "express"
+)
+
+var (
+	patternOrderCompletedCoupon = regexp.MustCompile(`^[A-Z0-9]{4,12}$`)
+)
+
+// CartViewed holds the properties of the "Cart Viewed" event version 1
+//
+// A customer viewed their cart.
+// Sent on every cart page load.
+type CartViewed struct{}
+
+// Validate checks the values against the tracking plan rules
+func (e CartViewed) Validate() error {
+	return nil
+}
+
+// EventName returns the name of the event in the tracking plan
+func (e CartViewed) EventName() string {
+	return "Cart Viewed"
+}
+
+// Track validates the event and returns the payload of its track call
+func (e CartViewed) Track(userID string) (Track, error) {
+	if err := e.Validate(); err != nil {
+		return Track{}, err
+	}
+	return Track{Type: "track", Event: "Cart Viewed", UserID: userID, Properties: e}, nil
+}
+
+// OrderCompleted holds the properties of the "Order Completed" event version 2
+//
+// A customer completed an order
+type OrderCompleted struct {
+	Coupon   *string                `json:"coupon,omitempty"`
+	Currency string                 `json:"currency"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Unique order identifier
+	OrderID  string                       `json:"order_id"`
+	Products []OrderCompletedProductsItem `json:"products"`
+	Quantity *int64                       `json:"quantity,omitempty"`
+	Referrer *string                      `json:"referrer"`
+	Shipping *OrderCompletedShipping      `json:"shipping,omitempty"`
+	Tags     []string                     `json:"tags,omitempty"`
+}
+
+// Validate checks the values against the tracking plan rules
+func (e OrderCompleted) Validate() error {
+	if e.Coupon != nil {
+		if !patternOrderCompletedCoupon.MatchString(*e.Coupon) {
+			return fmt.Errorf("coupon: %q does not match %s", *e.Coupon, patternOrderCompletedCoupon.String())
+		}
+	}
+	switch e.Currency {
+	case OrderCompletedCurrencyUSD, OrderCompletedCurrencyEUR:
+	default:
+		return fmt.Errorf("currency: %v is not an allowed value", e.Currency)
+	}
+	if utf8.RuneCountInString(e.OrderID) < 1 {
+		return fmt.Errorf("order_id: %q is shorter than 1 characters", e.OrderID)
+	}
+	if e.Products == nil {
+		return fmt.Errorf("products: missing required property")
+	}
+	for i, item := range e.Products {
+		if err := item.Validate(); err != nil {
+			return fmt.Errorf("products[%d].%v", i, err)
+		}
+	}
+	if e.Quantity != nil {
+		if float64(*e.Quantity) < 1 {
+			return fmt.Errorf("quantity: %v is less than the minimum 1", *e.Quantity)
+		}
+		if float64(*e.Quantity) > 10 {
+			return fmt.Errorf("quantity: %v is greater than the maximum 10", *e.Quantity)
+		}
+	}
+	if e.Shipping != nil {
+		if err := e.Shipping.Validate(); err != nil {
+			return fmt.Errorf("shipping.%v", err)
+		}
+	}
+	for i, item := range e.Tags {
+		if utf8.RuneCountInString(item) > 20 {
+			return fmt.Errorf("tags[%d]: %q is longer than 20 characters", i, item)
+		}
+	}
+	return nil
+}
+
+// EventName returns the name of the event in the tracking plan
+func (e OrderCompleted) EventName() string {
+	return "Order Completed"
+}
+
+// Track validates the event and returns the payload of its track call
+func (e OrderCompleted) Track(userID string) (Track, error) {
+	if err := e.Validate(); err != nil {
+		return Track{}, err
+	}
+	return Track{Type: "track", Event: "Order Completed", UserID: userID, Properties: e}, nil
+}
+
+// OrderCompletedProductsItem is a nested object
+type OrderCompletedProductsItem struct {
+	Price float64 `json:"price"`
+	SKU   string  `json:"sku"`
+}
+
+// Validate checks the values against the tracking plan rules
+func (e OrderCompletedProductsItem) Validate() error {
+	if e.Price < 0 {
+		return fmt.Errorf("price: %v is less than the minimum 0", e.Price)
+	}
+	return nil
+}
+
+// OrderCompletedShipping is a nested object
+type OrderCompletedShipping struct {
+	Method string `json:"method"`
+}
+
+// Validate checks the values against the tracking plan rules
+func (e OrderCompletedShipping) Validate() error {
+	switch e.Method {
+	case OrderCompletedShippingMethodStandard, OrderCompletedShippingMethodExpress:
+	default:
+		return fmt.Errorf("method: %v is not an allowed value", e.Method)
+	}
+	return nil
+}
+
+// OrderCompletedV1 holds the properties of the "Order Completed" event version 1
+type OrderCompletedV1 struct {
+	OrderID string `json:"order_id"`
+}
+
+// Validate checks the values against the tracking plan rules
+func (e OrderCompletedV1) Validate() error {
+	return nil
+}
+
+// EventName returns the name of the event in the tracking plan
+func (e OrderCompletedV1) EventName() string {
+	return "Order Completed"
+}
+
+// Track validates the event and returns the payload of its track call
+func (e OrderCompletedV1) Track(userID string) (Track, error) {
+	if err := e.Validate(); err != nil {
+		return Track{}, err
+	}
+	return Track{Type: "track", Event: "Order Completed", UserID: userID, Properties: e}, nil
+}
@@ -0,0 +1,66 @@
+package analytics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validOrder() OrderCompleted {
+	return OrderCompleted{
+		Currency: OrderCompletedCurrencyUSD,
+		OrderID:  "o-1",
+		Products: []OrderCompletedProductsItem{{Price: 10, SKU: "kick-1"}},
+	}
+}
+
+func TestAnalytics_Track(t *testing.T) {
+	track, err := validOrder().Track("user-1")
+	assert.NoError(t, err)
+
+	b, err := json.Marshal(track)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "track",
+		"event": "Order Completed",
+		"userId": "user-1",
+		"properties": {
+			"currency": "USD",
+			"order_id": "o-1",
+			"products": [{"price": 10, "sku": "kick-1"}],
+			"referrer": null
+		}
+	}`, string(b))
+
+	track, err = CartViewed{}.Track("")
+	assert.NoError(t, err)
+	track.AnonymousID = "anon-1"
+	b, err = json.Marshal(track)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "track", "event": "Cart Viewed", "anonymousId": "anon-1", "properties": {}}`, string(b))
+}
+
+func TestAnalytics_Validate(t *testing.T) {
+	coupon := "bad"
+	zero := int64(0)
+	long := "a-tag-that-is-far-too-long"
+
+	for expected, change := range map[string]func(*OrderCompleted){
+		"currency: GBP is not an allowed value":                              func(o *OrderCompleted) { o.Currency = "GBP" },
+		`coupon: "bad" does not match ^[A-Z0-9]{4,12}$`:                      func(o *OrderCompleted) { o.Coupon = &coupon },
+		`order_id: "" is shorter than 1 characters`:                          func(o *OrderCompleted) { o.OrderID = "" },
+		"products: missing required property":                                func(o *OrderCompleted) { o.Products = nil },
+		"products[0].price: -1 is less than the minimum 0":                   func(o *OrderCompleted) { o.Products[0].Price = -1 },
+		"quantity: 0 is less than the minimum 1":                             func(o *OrderCompleted) { o.Quantity = &zero },
+		"shipping.method: drone is not an allowed value":                     func(o *OrderCompleted) { o.Shipping = &OrderCompletedShipping{Method: "drone"} },
+		`tags[1]: "a-tag-that-is-far-too-long" is longer than 20 characters`: func(o *OrderCompleted) { o.Tags = []string{"ok", long} },
+	} {
+		o := validOrder()
+		change(&o)
+		_, err := o.Track("user-1")
+		assert.EqualError(t, err, expected)
+	}
+
+	assert.Equal(t, "Order Completed", OrderCompletedV1{}.EventName())
+}
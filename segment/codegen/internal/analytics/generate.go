@@ -0,0 +1,5 @@
+// Package analytics is generated from the codegen test plan to check that generated code compiles
+// and behaves as expected.
+package analytics
+
+//go:generate go run ../../../../cmd/segment-codegen -file ../../testdata/plan.yaml -out analytics_gen.go
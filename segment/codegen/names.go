@@ -0,0 +1,81 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go identifiers, following golint
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"SKU": true, "SQL": true, "UI": true, "URI": true, "URL": true, "UTM": true, "UUID": true,
+}
+
+// exportedName converts a tracking plan name such as "Order Completed" or "product_id" to an
+// exported Go identifier such as OrderCompleted or ProductID
+func exportedName(name string) string {
+	ident := camelCase(name)
+	if ident == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(ident)[0]) {
+		return "X" + ident
+	}
+	return ident
+}
+
+// camelCase joins the words of a name with their first letter in upper case
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+// splitWords splits a name on anything that is not a letter or digit, and on lower to upper case
+// transitions, so "orderID", "order_id" and "Order Id" all give the same words
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// namer hands out unique identifiers
+type namer map[string]bool
+
+func (n namer) unique(ident string) string {
+	name := ident
+	for i := 2; n[name]; i++ {
+		name = fmt.Sprintf("%s%d", ident, i)
+	}
+	n[name] = true
+	return name
+}
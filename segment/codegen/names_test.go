@@ -0,0 +1,28 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNames_ExportedName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Order Completed": "OrderCompleted",
+		"product_id":      "ProductID",
+		"orderId":         "OrderID",
+		"utm-source":      "UTMSource",
+		"3d secure":       "X3dSecure",
+		"":                "X",
+		"émoji ünicode":   "ÉmojiÜnicode",
+	} {
+		assert.Equal(t, expected, exportedName(name), name)
+	}
+}
+
+func TestNames_Unique(t *testing.T) {
+	n := namer{"Track": true}
+	assert.Equal(t, "Track2", n.unique("Track"))
+	assert.Equal(t, "Track3", n.unique("Track"))
+	assert.Equal(t, "Event", n.unique("Event"))
+}
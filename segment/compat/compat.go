@@ -0,0 +1,417 @@
+// Package compat classifies the changes between two versions of tracking plan rules as breaking,
+// non-breaking or additive, so CI can refuse edits that would break instrumented clients.
+package compat
+
+import (
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+// Severity is the compatibility class of a change
+type Severity string
+
+// Severities
+const (
+	Breaking    Severity = "breaking"
+	NonBreaking Severity = "non_breaking"
+	Additive    Severity = "additive"
+)
+
+// Kind identifies what kind of change was made
+type Kind string
+
+// Change kinds
+const (
+	EventAdded            Kind = "event_added"
+	EventRemoved          Kind = "event_removed"
+	EventVersionChanged   Kind = "event_version_changed"
+	DescriptionChanged    Kind = "description_changed"
+	LabelsChanged         Kind = "labels_changed"
+	PropertyAddedRequired Kind = "property_added_required"
+	PropertyAddedOptional Kind = "property_added_optional"
+	PropertyRemoved       Kind = "property_removed"
+	PropertyMadeRequired  Kind = "property_made_required"
+	PropertyMadeOptional  Kind = "property_made_optional"
+	TypeNarrowed          Kind = "type_narrowed"
+	TypeWidened           Kind = "type_widened"
+	EnumAdded             Kind = "enum_added"
+	EnumRemoved           Kind = "enum_removed"
+	EnumValueRemoved      Kind = "enum_value_removed"
+	EnumValueAdded        Kind = "enum_value_added"
+	PatternChanged        Kind = "pattern_changed"
+	PatternRemoved        Kind = "pattern_removed"
+	FormatChanged         Kind = "format_changed"
+	FormatRemoved         Kind = "format_removed"
+	BoundsNarrowed        Kind = "bounds_narrowed"
+	BoundsWidened         Kind = "bounds_widened"
+	RefChanged            Kind = "ref_changed"
+	// AdditionalPropertiesRestricted is set when properties that are not listed are rejected or
+	// constrained where they were accepted before
+	AdditionalPropertiesRestricted Kind = "additional_properties_restricted"
+	AdditionalPropertiesRelaxed    Kind = "additional_properties_relaxed"
+	SchemaChanged                  Kind = "schema_changed"
+	DefinitionsChanged             Kind = "definitions_changed"
+	// RuleChanged and TraitsChanged are changes the diff does not break down, which are assumed
+	// to be breaking
+	RuleChanged   Kind = "rule_changed"
+	TraitsChanged Kind = "traits_changed"
+)
+
+// Sections of tracking plan rules
+const (
+	SectionEvent    = "event"
+	SectionGlobal   = "global"
+	SectionIdentify = "identify"
+	SectionGroup    = "group"
+	// The identify_traits and group_traits sections of the rules
+	SectionIdentifyTraits = "identify_traits"
+	SectionGroupTraits    = "group_traits"
+)
+
+// defaultSeverities classifies changes for clients that send events already matching the old rules
+var defaultSeverities = map[Kind]Severity{
+	EventAdded:            Additive,
+	EventRemoved:          Breaking,
+	EventVersionChanged:   NonBreaking,
+	DescriptionChanged:    NonBreaking,
+	LabelsChanged:         NonBreaking,
+	PropertyAddedRequired: Breaking,
+	PropertyAddedOptional: Additive,
+	PropertyRemoved:       NonBreaking,
+	PropertyMadeRequired:  Breaking,
+	PropertyMadeOptional:  NonBreaking,
+	TypeNarrowed:          Breaking,
+	TypeWidened:           NonBreaking,
+	EnumAdded:             Breaking,
+	EnumRemoved:           NonBreaking,
+	EnumValueRemoved:      Breaking,
+	EnumValueAdded:        NonBreaking,
+	PatternChanged:        Breaking,
+	PatternRemoved:        NonBreaking,
+	FormatChanged:         Breaking,
+	FormatRemoved:         NonBreaking,
+	BoundsNarrowed:        Breaking,
+	BoundsWidened:         NonBreaking,
+	RefChanged:            Breaking,
+
+	AdditionalPropertiesRestricted: Breaking,
+	AdditionalPropertiesRelaxed:    NonBreaking,
+	SchemaChanged:                  NonBreaking,
+	DefinitionsChanged:             Breaking,
+	RuleChanged:                    Breaking,
+	TraitsChanged:                  Breaking,
+}
+
+// Policy controls how changes are classified
+type Policy struct {
+	// Severities overrides the default severity of change kinds
+	Severities map[Kind]Severity
+	// VersionBumpAllowsBreaking downgrades breaking changes to an event to non-breaking
+	// when the event version was bumped
+	VersionBumpAllowsBreaking bool
+}
+
+// DefaultPolicy returns the default policy, under which breaking event changes are
+// accepted when the event version is bumped
+func DefaultPolicy() Policy {
+	return Policy{VersionBumpAllowsBreaking: true}
+}
+
+// StrictPolicy returns a policy for sources that block unplanned properties, under which
+// removing a property breaks clients still sending it
+func StrictPolicy() Policy {
+	return Policy{
+		Severities:                map[Kind]Severity{PropertyRemoved: Breaking},
+		VersionBumpAllowsBreaking: true,
+	}
+}
+
+func (p Policy) severity(k Kind) Severity {
+	if s, ok := p.Severities[k]; ok {
+		return s
+	}
+	return defaultSeverities[k]
+}
+
+// Change is a single classified change
+type Change struct {
+	Section       string   `json:"section"`
+	Event         string   `json:"event,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	Kind          Kind     `json:"kind"`
+	Severity      Severity `json:"severity"`
+	Message       string   `json:"message"`
+	VersionBumped bool     `json:"version_bumped,omitempty"`
+}
+
+func (c Change) String() string {
+	where := c.Section
+	if c.Event != "" {
+		where = fmt.Sprintf("event %q", c.Event)
+	}
+	if c.Path != "" {
+		where += " " + c.Path
+	}
+	return fmt.Sprintf("[%s] %s: %s", c.Severity, where, c.Message)
+}
+
+// Result contains every classified change between two rule sets
+type Result struct {
+	Compatible  bool     `json:"compatible"`
+	Breaking    int      `json:"breaking"`
+	NonBreaking int      `json:"non_breaking"`
+	Additive    int      `json:"additive"`
+	Changes     []Change `json:"changes,omitempty"`
+}
+
+// BreakingChanges returns only the breaking changes
+func (r Result) BreakingChanges() []Change {
+	var changes []Change
+	for _, c := range r.Changes {
+		if c.Severity == Breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// Check classifies the changes from old to updated rules under a policy
+func Check(old, updated segment.Rules, policy Policy) Result {
+	d := segment.DiffRules(old, updated)
+	var changes []Change
+
+	for _, e := range d.Events {
+		switch e.Change {
+		case segment.ChangeAdded:
+			changes = append(changes, Change{Section: SectionEvent, Event: e.Name, Kind: EventAdded,
+				Message: fmt.Sprintf("event added at version %d", e.NewVersion)})
+			continue
+		case segment.ChangeRemoved:
+			changes = append(changes, Change{Section: SectionEvent, Event: e.Name, Kind: EventRemoved,
+				Message: fmt.Sprintf("event removed at version %d", e.OldVersion)})
+			continue
+		}
+		var eventChanges []Change
+		if e.VersionBumped() {
+			eventChanges = append(eventChanges, Change{Kind: EventVersionChanged,
+				Message: fmt.Sprintf("version changed from %d to %d", e.OldVersion, e.NewVersion)})
+		}
+		if e.Description != nil {
+			eventChanges = append(eventChanges, Change{Kind: DescriptionChanged, Message: "event description changed"})
+		}
+		eventChanges = append(eventChanges, classifyProperties(e.Properties)...)
+		for _, c := range eventChanges {
+			c.Section = SectionEvent
+			c.Event = e.Name
+			c.VersionBumped = e.NewVersion > e.OldVersion
+			changes = append(changes, c)
+		}
+	}
+	for _, section := range []struct {
+		name    string
+		changes []segment.PropertyChange
+	}{{SectionGlobal, d.Global}, {SectionIdentify, d.Identify}, {SectionGroup, d.Group}} {
+		for _, c := range classifyProperties(section.changes) {
+			c.Section = section.name
+			changes = append(changes, c)
+		}
+	}
+	for _, section := range []struct {
+		name   string
+		change *segment.ValueChange
+	}{{SectionIdentifyTraits, d.IdentifyTraits}, {SectionGroupTraits, d.GroupTraits}} {
+		if section.change != nil {
+			changes = append(changes, Change{Section: section.name, Kind: TraitsChanged, Message: "trait rules changed"})
+		}
+	}
+
+	r := Result{Changes: changes}
+	for i := range r.Changes {
+		c := &r.Changes[i]
+		c.Severity = policy.severity(c.Kind)
+		if c.Severity == Breaking && c.VersionBumped && policy.VersionBumpAllowsBreaking {
+			c.Severity = NonBreaking
+		}
+		switch c.Severity {
+		case Breaking:
+			r.Breaking++
+		case NonBreaking:
+			r.NonBreaking++
+		case Additive:
+			r.Additive++
+		}
+	}
+	r.Compatible = r.Breaking == 0
+	return r
+}
+
+func classifyProperties(pcs []segment.PropertyChange) []Change {
+	var changes []Change
+	add := func(pc segment.PropertyChange, k Kind, format string, args ...interface{}) {
+		changes = append(changes, Change{Path: pc.Path, Kind: k, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, pc := range pcs {
+		switch pc.Change {
+		case segment.ChangeAdded:
+			if pc.Required {
+				add(pc, PropertyAddedRequired, "required property added")
+			} else {
+				add(pc, PropertyAddedOptional, "optional property added")
+			}
+			continue
+		case segment.ChangeRemoved:
+			add(pc, PropertyRemoved, "property removed")
+			continue
+		}
+
+		switch pc.Field {
+		case segment.FieldRequired:
+			if pc.New == true {
+				add(pc, PropertyMadeRequired, "property made required")
+			} else {
+				add(pc, PropertyMadeOptional, "property made optional")
+			}
+		case segment.FieldType:
+			oldTypes, _ := pc.Old.([]string)
+			newTypes, _ := pc.New.([]string)
+			if accepts(newTypes, oldTypes) {
+				add(pc, TypeWidened, "type widened from %s to %s", typeString(oldTypes), typeString(newTypes))
+			} else {
+				add(pc, TypeNarrowed, "type narrowed from %s to %s", typeString(oldTypes), typeString(newTypes))
+			}
+		case segment.FieldEnum:
+			oldEnum, _ := pc.Old.(segment.Enum)
+			newEnum, _ := pc.New.(segment.Enum)
+			switch {
+			case len(oldEnum) == 0:
+				add(pc, EnumAdded, "enum added")
+			case len(newEnum) == 0:
+				add(pc, EnumRemoved, "enum removed")
+			default:
+				removed := false
+				for _, v := range oldEnum {
+					if !newEnum.Contains(v) {
+						removed = true
+						add(pc, EnumValueRemoved, "enum value %v removed", v)
+					}
+				}
+				if !removed {
+					add(pc, EnumValueAdded, "enum values added")
+				}
+			}
+		case segment.FieldPattern:
+			if pc.New == "" {
+				add(pc, PatternRemoved, "pattern removed")
+			} else {
+				add(pc, PatternChanged, "pattern changed from %q to %q", pc.Old, pc.New)
+			}
+		case segment.FieldFormat:
+			if pc.New == "" {
+				add(pc, FormatRemoved, "format removed")
+			} else {
+				add(pc, FormatChanged, "format changed from %q to %q", pc.Old, pc.New)
+			}
+		case segment.FieldMinimum, segment.FieldMinLength:
+			if lowerBoundNarrowed(pc.Old, pc.New) {
+				add(pc, BoundsNarrowed, "%s raised", pc.Field)
+			} else {
+				add(pc, BoundsWidened, "%s lowered", pc.Field)
+			}
+		case segment.FieldMaximum, segment.FieldMaxLength:
+			if upperBoundNarrowed(pc.Old, pc.New) {
+				add(pc, BoundsNarrowed, "%s lowered", pc.Field)
+			} else {
+				add(pc, BoundsWidened, "%s raised", pc.Field)
+			}
+		case segment.FieldRef:
+			add(pc, RefChanged, "reference changed from %q to %q", pc.Old, pc.New)
+		case segment.FieldDescription:
+			add(pc, DescriptionChanged, "description changed")
+		case segment.FieldLabels:
+			add(pc, LabelsChanged, "labels changed")
+		case segment.FieldAdditionalProperties:
+			updated, _ := pc.New.(*segment.AdditionalProperties)
+			switch {
+			case acceptsAny(updated):
+				add(pc, AdditionalPropertiesRelaxed, "additional properties allowed")
+			case !updated.Allowed:
+				add(pc, AdditionalPropertiesRestricted, "additional properties disallowed")
+			default:
+				add(pc, AdditionalPropertiesRestricted, "additional properties constrained")
+			}
+		case segment.FieldSchema:
+			add(pc, SchemaChanged, "$schema changed from %q to %q", pc.Old, pc.New)
+		case segment.FieldDefinitions:
+			add(pc, DefinitionsChanged, "definitions changed")
+		case segment.FieldRule:
+			add(pc, RuleChanged, "rule changed")
+		}
+	}
+	return changes
+}
+
+// accepts reports whether a value of any of the inner types is also accepted by the outer types.
+// An empty type list accepts anything.
+func accepts(outer, inner []string) bool {
+	if len(outer) == 0 {
+		return true
+	}
+	if len(inner) == 0 {
+		return false
+	}
+	for _, t := range inner {
+		if !hasType(outer, t) && !(t == segment.TypeInteger && hasType(outer, segment.TypeNumber)) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsAny reports whether an additionalProperties keyword accepts any additional property
+func acceptsAny(a *segment.AdditionalProperties) bool {
+	return a == nil || (a.Allowed && a.Schema == nil)
+}
+
+func hasType(types []string, t string) bool {
+	for _, name := range types {
+		if name == t {
+			return true
+		}
+	}
+	return false
+}
+
+func typeString(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return fmt.Sprint(types)
+}
+
+// bound returns the numeric value of a *float64 or *int bound, and whether it is set
+func bound(v interface{}) (float64, bool) {
+	switch b := v.(type) {
+	case *float64:
+		if b != nil {
+			return *b, true
+		}
+	case *int:
+		if b != nil {
+			return float64(*b), true
+		}
+	}
+	return 0, false
+}
+
+func lowerBoundNarrowed(old, updated interface{}) bool {
+	o, oldSet := bound(old)
+	n, newSet := bound(updated)
+	return newSet && (!oldSet || n > o)
+}
+
+func upperBoundNarrowed(old, updated interface{}) bool {
+	o, oldSet := bound(old)
+	n, newSet := bound(updated)
+	return newSet && (!oldSet || n < o)
+}
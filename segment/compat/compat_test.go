@@ -0,0 +1,170 @@
+package compat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func oldRules() segment.Rules {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(1).
+		Prop("product", segment.String().Required()).
+		Prop("price", segment.Number()).
+		Prop("quantity", segment.Integer().Min(1)).
+		Prop("currency", segment.String().Enum("USD", "EUR")).
+		Prop("color", segment.String())
+	tp.Event("Cart Viewed").Version(1)
+	tp.IdentifyTrait("email", segment.String())
+	return tp.Rules()
+}
+
+func TestCompat_BreakingChanges(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(1).
+		Prop("product", segment.String().Required()).
+		Prop("price", segment.Integer().Required()).
+		Prop("quantity", segment.Number().Min(0)).
+		Prop("currency", segment.String().Enum("USD")).
+		Prop("coupon", segment.String())
+	tp.IdentifyTrait("email", segment.String().Format("email"))
+
+	r := Check(oldRules(), tp.Rules(), DefaultPolicy())
+
+	expected := []Change{
+		{Section: SectionEvent, Event: "Cart Viewed", Kind: EventRemoved, Severity: Breaking, Message: "event removed at version 1"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.color", Kind: PropertyRemoved, Severity: NonBreaking, Message: "property removed"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.coupon", Kind: PropertyAddedOptional, Severity: Additive, Message: "optional property added"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.currency", Kind: EnumValueRemoved, Severity: Breaking, Message: "enum value EUR removed"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.price", Kind: TypeNarrowed, Severity: Breaking, Message: "type narrowed from [number] to [integer]"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.price", Kind: PropertyMadeRequired, Severity: Breaking, Message: "property made required"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.quantity", Kind: TypeWidened, Severity: NonBreaking, Message: "type widened from [integer] to [number]"},
+		{Section: SectionEvent, Event: "Order Completed", Path: "properties.quantity", Kind: BoundsWidened, Severity: NonBreaking, Message: "minimum lowered"},
+		{Section: SectionIdentify, Path: "traits.email", Kind: FormatChanged, Severity: Breaking, Message: `format changed from "" to "email"`},
+	}
+	assert.Equal(t, expected, r.Changes)
+	assert.False(t, r.Compatible)
+	assert.Equal(t, 5, r.Breaking)
+	assert.Equal(t, 3, r.NonBreaking)
+	assert.Equal(t, 1, r.Additive)
+	assert.Len(t, r.BreakingChanges(), 5)
+}
+
+func TestCompat_VersionBump(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(2).
+		Prop("product", segment.String().Required()).
+		Prop("price", segment.Number().Required()).
+		Prop("quantity", segment.Integer().Min(1)).
+		Prop("currency", segment.String().Enum("USD", "EUR")).
+		Prop("color", segment.String())
+	tp.Event("Cart Viewed").Version(1)
+	tp.Event("Product Viewed").Version(1)
+	tp.IdentifyTrait("email", segment.String())
+
+	r := Check(oldRules(), tp.Rules(), DefaultPolicy())
+	assert.True(t, r.Compatible, "%v", r.BreakingChanges())
+	assert.Equal(t, 1, r.Additive)
+
+	strict := Policy{}
+	r = Check(oldRules(), tp.Rules(), strict)
+	assert.False(t, r.Compatible)
+	assert.Equal(t, PropertyMadeRequired, r.BreakingChanges()[0].Kind)
+	assert.True(t, r.BreakingChanges()[0].VersionBumped)
+}
+
+func TestCompat_PolicyOverrides(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(1).
+		Prop("product", segment.String().Required()).
+		Prop("price", segment.Number()).
+		Prop("quantity", segment.Integer().Min(1)).
+		Prop("currency", segment.String().Enum("USD", "EUR"))
+	tp.Event("Cart Viewed").Version(1)
+	tp.IdentifyTrait("email", segment.String())
+
+	assert.True(t, Check(oldRules(), tp.Rules(), DefaultPolicy()).Compatible)
+
+	r := Check(oldRules(), tp.Rules(), StrictPolicy())
+	assert.False(t, r.Compatible)
+	assert.Equal(t, "[breaking] event \"Order Completed\" properties.color: property removed", r.BreakingChanges()[0].String())
+
+	r = Check(oldRules(), tp.Rules(), Policy{Severities: map[Kind]Severity{PropertyRemoved: Additive}})
+	assert.Equal(t, 1, r.Additive)
+}
+
+func TestCompat_ResultJSON(t *testing.T) {
+	r := Check(oldRules(), oldRules(), DefaultPolicy())
+	assert.True(t, r.Compatible)
+
+	b, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"compatible":true,"breaking":0,"non_breaking":0,"additive":0}`, string(b))
+}
+
+func TestCompat_Accepts(t *testing.T) {
+	assert.True(t, accepts(nil, []string{segment.TypeString}))
+	assert.False(t, accepts([]string{segment.TypeString}, nil))
+	assert.True(t, accepts([]string{segment.TypeNumber}, []string{segment.TypeInteger}))
+	assert.False(t, accepts([]string{segment.TypeInteger}, []string{segment.TypeNumber}))
+	assert.False(t, accepts([]string{segment.TypeString}, []string{segment.TypeString, segment.TypeNull}))
+}
+
+func TestCompat_AdditionalProperties(t *testing.T) {
+	closed := oldRules()
+	props := closed.Events[0].Rules.Properties["properties"]
+	props.AdditionalProperties = &segment.AdditionalProperties{Allowed: false}
+	closed.Events[0].Rules.Properties["properties"] = props
+
+	r := Check(oldRules(), closed, DefaultPolicy())
+	assert.False(t, r.Compatible)
+	assert.Equal(t, []Change{{Section: SectionEvent, Event: "Order Completed", Path: "properties",
+		Kind: AdditionalPropertiesRestricted, Severity: Breaking, Message: "additional properties disallowed"}}, r.Changes)
+
+	r = Check(closed, oldRules(), DefaultPolicy())
+	assert.True(t, r.Compatible)
+	assert.Equal(t, AdditionalPropertiesRelaxed, r.Changes[0].Kind)
+}
+
+func TestCompat_EventVersionRemoved(t *testing.T) {
+	version := func(v int) segment.Event {
+		return segment.NewRulesBuilder().Event("Order Completed").Version(v).
+			Prop("product", segment.String().Required()).Event()
+	}
+	old := segment.Rules{Events: []segment.Event{version(1), version(2)}}
+	updated := segment.Rules{Events: []segment.Event{version(2)}}
+
+	r := Check(old, updated, DefaultPolicy())
+	assert.False(t, r.Compatible)
+	assert.Equal(t, []Change{{Section: SectionEvent, Event: "Order Completed", Kind: EventRemoved,
+		Severity: Breaking, Message: "event removed at version 1"}}, r.Changes)
+}
+
+func TestCompat_Traits(t *testing.T) {
+	updated := oldRules()
+	updated.GroupTraits = []segment.Rule{segment.String().Rule()}
+
+	r := Check(oldRules(), updated, DefaultPolicy())
+	assert.False(t, r.Compatible)
+	assert.Equal(t, []Change{{Section: SectionGroupTraits, Kind: TraitsChanged, Severity: Breaking,
+		Message: "trait rules changed"}}, r.Changes)
+	assert.Equal(t, "[breaking] group_traits: trait rules changed", r.Changes[0].String())
+}
+
+func TestCompat_Rules(t *testing.T) {
+	updated := oldRules()
+	updated.Global.Schema = segment.SchemaDraft07
+	updated.Global.Definitions = map[string]segment.Rule{"id": segment.String().Rule()}
+
+	r := Check(oldRules(), updated, DefaultPolicy())
+	assert.Equal(t, []Kind{SchemaChanged, DefinitionsChanged}, []Kind{r.Changes[0].Kind, r.Changes[1].Kind})
+	assert.Equal(t, 1, r.Breaking)
+
+	// a change the diff does not break down is breaking
+	updated = oldRules()
+	updated.Global.Required = []string{"context"}
+	r = Check(oldRules(), updated, DefaultPolicy())
+	assert.Equal(t, []Change{{Section: SectionGlobal, Kind: RuleChanged, Severity: Breaking, Message: "rule changed"}}, r.Changes)
+}
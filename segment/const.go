@@ -11,4 +11,28 @@ const (
 	TrackingPlanEndpoint = "tracking-plans"
 	// TrackingPlanSourceConnectionEndpoint is the API endpoint for the connecting a source to a tracking plan
 	TrackingPlanSourceConnectionEndpoint = "source-connections"
+	// SourceSchemaConfigEndpoint is the API endpoint for a source's schema settings
+	SourceSchemaConfigEndpoint = "schema-config"
+	// EventDeliveryMetricsEndpoint is the API endpoint for a destination's event delivery metrics
+	EventDeliveryMetricsEndpoint = "event-delivery-metrics"
+)
+
+const (
+	// GranularityMinute buckets event delivery metrics by minute
+	GranularityMinute = "MINUTE"
+	// GranularityHour buckets event delivery metrics by hour
+	GranularityHour = "HOUR"
+	// GranularityDay buckets event delivery metrics by day
+	GranularityDay = "DAY"
+)
+
+const (
+	// SchemaViolationAllow lets events with violations through unchanged
+	SchemaViolationAllow = "ALLOW"
+	// SchemaViolationOmitProperties drops the offending properties of track calls with violations
+	SchemaViolationOmitProperties = "OMIT_PROPS"
+	// SchemaViolationOmitTraits drops the offending traits of identify and group calls with violations
+	SchemaViolationOmitTraits = "OMIT_TRAITS"
+	// SchemaViolationBlock blocks events with violations
+	SchemaViolationBlock = "BLOCK"
 )
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"unicode"
 
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
 	"github.com/pkg/errors"
 )
 
@@ -47,6 +50,10 @@ func (c *Client) GetDestination(srcName string, destName string) (Destination, e
 // CreateDestination creates a new destination for a source
 func (c *Client) CreateDestination(srcName string, destName string, connMode string, enabled bool, configs []DestinationConfig) (Destination, error) {
 	var d Destination
+	configs, err := c.ResolveSecrets(configs)
+	if err != nil {
+		return d, err
+	}
 	destFullName := fmt.Sprintf("%s/%s/%s/%s/%s/%s",
 		WorkspacesEndpoint, c.workspace, SourceEndpoint, srcName, DestinationEndpoint, destName)
 	dest := Destination{
@@ -87,6 +94,10 @@ func (c *Client) DeleteDestination(srcName string, destName string) error {
 // UpdateDestination updates an existing destination with a new config
 func (c *Client) UpdateDestination(srcName string, destName string, enabled bool, configs []DestinationConfig) (Destination, error) {
 	var d Destination
+	configs, err := c.ResolveSecrets(configs)
+	if err != nil {
+		return d, err
+	}
 	destFullName := fmt.Sprintf("%s/%s/%s/%s/%s/%s",
 		WorkspacesEndpoint, c.workspace, SourceEndpoint, srcName, DestinationEndpoint, destName)
 	dest := Destination{
@@ -106,3 +117,61 @@ func (c *Client) UpdateDestination(srcName string, destName string, enabled bool
 
 	return d, nil
 }
+
+// ResolveSecrets returns a copy of configs with their secret references resolved by the resolver of
+// the client, or configs as is when it has none
+func (c *Client) ResolveSecrets(configs []DestinationConfig) ([]DestinationConfig, error) {
+	if c.secrets == nil {
+		return configs, nil
+	}
+	return ResolveSecrets(c.secrets, configs)
+}
+
+// secretSettingSuffixes are the last words of the names of settings that hold credentials whatever
+// their type, e.g. apiKey, api_key, clientSecret or accessToken
+var secretSettingSuffixes = [][]string{{"secret"}, {"password"}, {"token"}, {"apikey"}, {"api", "key"}}
+
+// IsSecret reports whether a destination setting holds a credential, either because it is of type
+// password or because its name ends with secret, password, token or API key. Names are compared
+// word by word, so settings such as tokenizeIds or secretManagerRegion are not secrets.
+func (dc DestinationConfig) IsSecret() bool {
+	if dc.Type == "password" {
+		return true
+	}
+	words := nameWords(Slug(dc.Name))
+	for _, suffix := range secretSettingSuffixes {
+		if len(words) >= len(suffix) && jsonvalue.StringsEqual(words[len(words)-len(suffix):], suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameWords splits a camelCase, snake_case or kebab-case name into lower case words
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := runes[i-1]
+			// a new word starts after a lower case letter, or at the last capital of an acronym
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
@@ -200,3 +200,20 @@ func TestDestinations_UpdateDestination(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestDestinationConfig_IsSecret(t *testing.T) {
+	assert.True(t, DestinationConfig{Name: "anything", Type: "password"}.IsSecret())
+	assert.True(t, DestinationConfig{Name: "workspaces/ws/sources/js/destinations/ga/config/apiKey", Type: "string"}.IsSecret())
+	assert.True(t, DestinationConfig{Name: "clientSecret"}.IsSecret())
+	assert.True(t, DestinationConfig{Name: "accessToken"}.IsSecret())
+	assert.False(t, DestinationConfig{Name: "workspaces/ws/sources/js/destinations/ga/config/trackingId", Type: "string"}.IsSecret())
+
+	assert.True(t, DestinationConfig{Name: "api_key"}.IsSecret())
+	assert.True(t, DestinationConfig{Name: "APIKey"}.IsSecret())
+	assert.True(t, DestinationConfig{Name: "password"}.IsSecret())
+
+	// names are compared word by word, so mentioning a credential does not make a setting secret
+	assert.False(t, DestinationConfig{Name: "tokenizeIds", Type: "boolean"}.IsSecret())
+	assert.False(t, DestinationConfig{Name: "secretManagerRegion", Type: "string"}.IsSecret())
+	assert.False(t, DestinationConfig{Name: "passwordless"}.IsSecret())
+}
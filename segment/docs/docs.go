@@ -0,0 +1,175 @@
+// Package docs renders tracking plans as documentation for people who do not read JSON Schema,
+// either as a single Markdown file or as a self-contained static HTML site with search.
+package docs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/planfile"
+)
+
+// Sections of an envelope rule, in the order they are documented
+var sections = []string{"properties", "traits", "context"}
+
+// Plan is the documentation model of a tracking plan
+type Plan struct {
+	Title    string
+	Events   []Event
+	Identify []Property
+	Group    []Property
+	Global   []Property
+}
+
+// Event is the documentation of one event version
+type Event struct {
+	Name        string
+	Version     int
+	Description string
+	Labels      []Label
+	Properties  []Property
+	// Slug identifies the event in anchors and file names
+	Slug string
+}
+
+// Title returns the event name with its version
+func (e Event) Title() string {
+	if e.Version > 0 {
+		return fmt.Sprintf("%s (v%d)", e.Name, e.Version)
+	}
+	return e.Name
+}
+
+// Label is a key and value from Rule.Labels
+type Label struct {
+	Key   string
+	Value string
+}
+
+// Property is one row of a properties table
+type Property struct {
+	// Path is the property path, e.g. shipping.method or tags[]. Properties outside of the
+	// properties section are prefixed with their section, e.g. context.library.
+	Path        string
+	Type        string
+	Required    bool
+	Enum        []string
+	Pattern     string
+	Format      string
+	Description string
+	Labels      []Label
+}
+
+// NewPlan returns the documentation model of a tracking plan. Events are sorted by name and version.
+func NewPlan(plan segment.TrackingPlan) Plan {
+	p := Plan{
+		Title:    plan.DisplayName,
+		Identify: properties(plan.Rules.Identify),
+		Group:    properties(plan.Rules.Group),
+		Global:   properties(plan.Rules.Global),
+	}
+	if p.Title == "" {
+		p.Title = "Tracking plan"
+	}
+	for _, e := range plan.Rules.Events {
+		p.Events = append(p.Events, Event{
+			Name:        e.Name,
+			Version:     e.Version,
+			Description: e.Description,
+			Labels:      labels(e.Rules.Labels),
+			Properties:  properties(e.Rules),
+			Slug:        planfile.EventSlug(e.Name, e.Version),
+		})
+	}
+	sort.SliceStable(p.Events, func(i, j int) bool {
+		if p.Events[i].Name != p.Events[j].Name {
+			return p.Events[i].Name < p.Events[j].Name
+		}
+		return p.Events[i].Version < p.Events[j].Version
+	})
+	used := map[string]bool{}
+	for i := range p.Events {
+		e := &p.Events[i]
+		base := e.Slug
+		for n := 2; used[e.Slug]; n++ {
+			e.Slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[e.Slug] = true
+	}
+	return p
+}
+
+// properties flattens the sections of an envelope rule into table rows
+func properties(envelope segment.Rule) []Property {
+	var props []Property
+	for _, s := range sections {
+		prefix := s + "."
+		if s == "properties" {
+			prefix = ""
+		}
+		props = appendProperties(props, prefix, envelope.Properties[s])
+	}
+	return props
+}
+
+func appendProperties(props []Property, prefix string, r segment.Rule) []Property {
+	names := make([]string, 0, len(r.Properties))
+	for name := range r.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		props = appendProperty(props, prefix+name, r.Properties[name], r.IsRequired(name))
+	}
+	return props
+}
+
+func appendProperty(props []Property, path string, r segment.Rule, required bool) []Property {
+	p := Property{
+		Path:        path,
+		Type:        typeString(r),
+		Required:    required,
+		Pattern:     r.Pattern,
+		Format:      r.Format,
+		Description: r.Description,
+		Labels:      labels(r.Labels),
+	}
+	for _, v := range r.Enum {
+		if v == nil {
+			p.Enum = append(p.Enum, "null")
+			continue
+		}
+		p.Enum = append(p.Enum, fmt.Sprint(v))
+	}
+	props = append(props, p)
+	props = appendProperties(props, path+".", r)
+	if r.Items != nil {
+		props = appendProperty(props, path+"[]", *r.Items, false)
+	}
+	return props
+}
+
+func typeString(r segment.Rule) string {
+	if r.Ref != "" {
+		return r.Ref
+	}
+	if len(r.Type.Names) == 0 {
+		return "any"
+	}
+	t := strings.Join(r.Type.Names, " or ")
+	if r.Type.Has(segment.TypeArray) && r.Items != nil && len(r.Items.Type.Names) == 1 {
+		t = strings.Replace(t, segment.TypeArray, r.Items.Type.Names[0]+"[]", 1)
+	}
+	return t
+}
+
+func labels(m map[string]interface{}) []Label {
+	var ls []Label
+	for k, v := range m {
+		ls = append(ls, Label{Key: k, Value: fmt.Sprint(v)})
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].Key < ls[j].Key })
+	return ls
+}
@@ -0,0 +1,69 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPlan() segment.TrackingPlan {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(2).Description("An order was placed").Label("owner", "checkout").
+		Prop("order_id", segment.String().Required().Pattern("^[0-9]+$").Description("Order identifier")).
+		Prop("payment_method", segment.String().Enum("card", "paypal").Description("How the order | was paid")).
+		Prop("shipping", segment.Object().Prop("method", segment.String())).
+		Prop("tags", segment.Array(segment.String())).
+		Context("locale", segment.String())
+	tp.Event("Cart Viewed")
+	tp.IdentifyTrait("email", segment.String().Required().Format("email").Label("pii", true))
+	tp.Global().Context("app", segment.Object().Required())
+	plan := tp.TrackingPlan("Storefront")
+	v1 := segment.NewRulesBuilder().Event("Order Completed").Version(1).Description("An order was placed")
+	plan.Rules.Events = append(plan.Rules.Events, v1.Event())
+	return plan
+}
+
+func TestNewPlan(t *testing.T) {
+	p := NewPlan(testPlan())
+	assert.Equal(t, "Storefront", p.Title)
+
+	var titles, slugs []string
+	for _, e := range p.Events {
+		titles = append(titles, e.Title())
+		slugs = append(slugs, e.Slug)
+	}
+	assert.Equal(t, []string{"Cart Viewed", "Order Completed (v1)", "Order Completed (v2)"}, titles)
+	assert.Equal(t, []string{"cart-viewed", "order-completed", "order-completed-v2"}, slugs)
+
+	e := p.Events[2]
+	assert.Equal(t, []Label{{Key: "owner", Value: "checkout"}}, e.Labels)
+	assert.Equal(t, []Property{
+		{Path: "order_id", Type: "string", Required: true, Pattern: "^[0-9]+$", Description: "Order identifier"},
+		{Path: "payment_method", Type: "string", Enum: []string{"card", "paypal"}, Description: "How the order | was paid"},
+		{Path: "shipping", Type: "object"},
+		{Path: "shipping.method", Type: "string"},
+		{Path: "tags", Type: "string[]"},
+		{Path: "tags[]", Type: "string"},
+		{Path: "context.locale", Type: "string"},
+	}, e.Properties)
+
+	assert.Equal(t, []Property{
+		{Path: "traits.email", Type: "string", Required: true, Format: "email", Labels: []Label{{Key: "pii", Value: "true"}}},
+	}, p.Identify)
+	assert.Empty(t, p.Group)
+	assert.Equal(t, []Property{{Path: "context.app", Type: "object", Required: true}}, p.Global)
+}
+
+func TestNewPlan_DefaultTitle(t *testing.T) {
+	assert.Equal(t, "Tracking plan", NewPlan(segment.TrackingPlan{}).Title)
+}
+
+func TestNewPlan_DuplicateSlugs(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed")
+	tp.Event("order_completed")
+	p := NewPlan(tp.TrackingPlan(""))
+	assert.Equal(t, "order-completed", p.Events[0].Slug)
+	assert.Equal(t, "order-completed-2", p.Events[1].Slug)
+}
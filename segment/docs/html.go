@@ -0,0 +1,161 @@
+package docs
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Files of a documentation site
+const (
+	IndexFileName = "index.html"
+	EventsDirName = "events"
+)
+
+const style = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;margin:0 auto;max-width:1100px;padding:0 24px 48px;color:#1f2328}
+a{color:#0969da;text-decoration:none}a:hover{text-decoration:underline}
+table{border-collapse:collapse;width:100%;margin:16px 0}th,td{border:1px solid #d0d7de;padding:6px 10px;text-align:left;vertical-align:top}
+th{background:#f6f8fa}code{background:#f6f8fa;padding:1px 4px;border-radius:4px;font-size:90%}
+.label{display:inline-block;background:#ddf4ff;border-radius:12px;padding:1px 8px;margin-right:4px;font-size:85%}
+.muted{color:#656d76}#search{width:100%;padding:8px;font-size:16px;margin:8px 0;box-sizing:border-box}`
+
+var templates = template.Must(template.New("layout").Parse(`{{define "layout"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>` + style + `</style>
+</head>
+<body>
+{{template "content" .}}
+</body>
+</html>
+{{end}}
+
+{{define "properties"}}{{if .}}<table>
+<thead><tr><th>Property</th><th>Type</th><th>Required</th><th>Enum</th><th>Pattern</th><th>Description</th></tr></thead>
+<tbody>
+{{range .}}<tr><td><code>{{.Path}}</code></td><td>{{.Type}}</td><td>{{if .Required}}yes{{end}}</td><td>{{range $i, $v := .Enum}}{{if $i}}, {{end}}<code>{{$v}}</code>{{end}}</td><td>{{if .Pattern}}<code>{{.Pattern}}</code>{{end}}</td><td>{{.Description}}{{if .Format}} <span class="muted">(format: {{.Format}})</span>{{end}}{{range .Labels}} <span class="label">{{.Key}}: {{.Value}}</span>{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="muted">No properties.</p>{{end}}{{end}}`))
+
+var indexTemplate = template.Must(template.Must(templates.Clone()).Parse(`{{define "content"}}<h1>{{.Plan.Title}}</h1>
+<input id="search" type="search" placeholder="Search events and properties" autofocus>
+<table id="events">
+<thead><tr><th>Event</th><th>Version</th><th>Description</th><th>Properties</th></tr></thead>
+<tbody>
+{{range .Events}}<tr data-search="{{.Search}}"><td><a href="events/{{.Event.Slug}}.html">{{.Event.Name}}</a></td><td>{{.Event.Version}}</td><td>{{.Event.Description}}</td><td>{{len .Event.Properties}}</td></tr>
+{{end}}</tbody>
+</table>
+<p id="no-results" class="muted" hidden>No matching events.</p>
+{{range .Sections}}<p><a href="{{.Slug}}.html">{{.Title}}</a></p>
+{{end}}<script>
+var search = document.getElementById("search");
+search.addEventListener("input", function () {
+  var terms = search.value.toLowerCase().split(/\s+/).filter(Boolean);
+  var shown = 0;
+  document.querySelectorAll("#events tbody tr").forEach(function (row) {
+    var text = row.getAttribute("data-search");
+    var match = terms.every(function (t) { return text.indexOf(t) >= 0; });
+    row.hidden = !match;
+    if (match) shown++;
+  });
+  document.getElementById("no-results").hidden = shown > 0;
+});
+</script>
+{{end}}`))
+
+var eventTemplate = template.Must(template.Must(templates.Clone()).Parse(`{{define "content"}}<p><a href="../index.html">&larr; {{.Plan.Title}}</a></p>
+<h1>{{.Event.Name}}</h1>
+{{if .Event.Version}}<p class="muted">Version {{.Event.Version}}</p>{{end}}
+{{if .Event.Description}}<p>{{.Event.Description}}</p>{{end}}
+{{if .Event.Labels}}<p>{{range .Event.Labels}}<span class="label">{{.Key}}: {{.Value}}</span>{{end}}</p>{{end}}
+{{template "properties" .Event.Properties}}
+{{end}}`))
+
+var sectionTemplate = template.Must(template.Must(templates.Clone()).Parse(`{{define "content"}}<p><a href="index.html">&larr; {{.Plan.Title}}</a></p>
+<h1>{{.Section.Title}}</h1>
+{{template "properties" .Section.Properties}}
+{{end}}`))
+
+type indexEvent struct {
+	Event  Event
+	Search string
+}
+
+type indexSection struct {
+	Title      string
+	Slug       string
+	Properties []Property
+}
+
+// WriteHTML writes the documentation of a tracking plan as a static HTML site, with an index
+// page listing and searching the events, one page per event and pages for the identify, group
+// and global rules. Pages do not load any external resources.
+func WriteHTML(dir string, plan segment.TrackingPlan) error {
+	p := NewPlan(plan)
+	if err := os.MkdirAll(filepath.Join(dir, EventsDirName), 0755); err != nil {
+		return errors.Wrap(err, "failed to create events directory")
+	}
+
+	var sections []indexSection
+	for _, s := range p.sections() {
+		sections = append(sections, indexSection{Title: s.title, Slug: s.slug, Properties: s.properties})
+	}
+	var events []indexEvent
+	for _, e := range p.Events {
+		events = append(events, indexEvent{Event: e, Search: searchText(e)})
+	}
+
+	title := p.Title
+	if err := writePage(filepath.Join(dir, IndexFileName), indexTemplate, map[string]interface{}{
+		"Title": title, "Plan": p, "Events": events, "Sections": sections,
+	}); err != nil {
+		return err
+	}
+	for _, e := range p.Events {
+		if err := writePage(filepath.Join(dir, EventsDirName, e.Slug+".html"), eventTemplate, map[string]interface{}{
+			"Title": e.Title() + " - " + title, "Plan": p, "Event": e,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, s := range sections {
+		if err := writePage(filepath.Join(dir, s.Slug+".html"), sectionTemplate, map[string]interface{}{
+			"Title": s.Title + " - " + title, "Plan": p, "Section": s,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePage(path string, t *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return errors.Wrapf(err, "failed to render %s", path)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+// searchText returns the lower case text an event is searched by
+func searchText(e Event) string {
+	parts := []string{e.Name, e.Description}
+	for _, l := range e.Labels {
+		parts = append(parts, l.Key, l.Value)
+	}
+	for _, p := range e.Properties {
+		parts = append(parts, p.Path, p.Description)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
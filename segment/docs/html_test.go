@@ -0,0 +1,69 @@
+package docs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	return string(data)
+}
+
+func TestWriteHTML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docs")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, WriteHTML(dir, testPlan()))
+
+	index := readFile(t, filepath.Join(dir, IndexFileName))
+	assert.Contains(t, index, "<title>Storefront</title>")
+	assert.Contains(t, index, `<input id="search"`)
+	assert.Contains(t, index, `<a href="events/order-completed-v2.html">Order Completed</a>`)
+	assert.Contains(t, index, `data-search="order completed an order was placed owner checkout order_id order identifier`)
+	assert.Contains(t, index, `<a href="identify-traits.html">Identify traits</a>`)
+	assert.Contains(t, index, `<a href="global-rules.html">Global rules</a>`)
+	assert.NotContains(t, index, "group-traits.html")
+	assert.NotContains(t, index, "<link")
+
+	event := readFile(t, filepath.Join(dir, EventsDirName, "order-completed-v2.html"))
+	assert.Contains(t, event, "<title>Order Completed (v2) - Storefront</title>")
+	assert.Contains(t, event, `<a href="../index.html">&larr; Storefront</a>`)
+	assert.Contains(t, event, "Version 2")
+	assert.Contains(t, event, `<span class="label">owner: checkout</span>`)
+	assert.Contains(t, event, "<td><code>order_id</code></td><td>string</td><td>yes</td><td></td><td><code>^[0-9]&#43;$</code></td><td>Order identifier</td>")
+	assert.Contains(t, event, "<td><code>card</code>, <code>paypal</code></td>")
+
+	empty := readFile(t, filepath.Join(dir, EventsDirName, "cart-viewed.html"))
+	assert.Contains(t, empty, "No properties.")
+
+	identify := readFile(t, filepath.Join(dir, "identify-traits.html"))
+	assert.Contains(t, identify, "<code>traits.email</code>")
+	assert.Contains(t, identify, `(format: email)`)
+	assert.Contains(t, identify, `<span class="label">pii: true</span>`)
+}
+
+func TestWriteHTML_Escapes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docs")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	tp := testPlan()
+	tp.DisplayName = "<script>alert(1)</script>"
+	assert.NoError(t, WriteHTML(dir, tp))
+	index := readFile(t, filepath.Join(dir, IndexFileName))
+	assert.NotContains(t, index, "<script>alert(1)</script>")
+	assert.Contains(t, index, "&lt;script&gt;alert(1)&lt;/script&gt;")
+}
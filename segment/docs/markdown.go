@@ -0,0 +1,108 @@
+package docs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+// WriteMarkdown writes the documentation of a tracking plan as a single Markdown file
+func WriteMarkdown(w io.Writer, plan segment.TrackingPlan) error {
+	p := NewPlan(plan)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", markdownEscape(p.Title))
+
+	b.WriteString("\n## Contents\n\n")
+	for _, e := range p.Events {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", markdownEscape(e.Title()), e.Slug)
+	}
+	for _, s := range p.sections() {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", s.title, s.slug)
+	}
+
+	if len(p.Events) > 0 {
+		b.WriteString("\n## Events\n")
+	}
+	for _, e := range p.Events {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n### %s\n", e.Slug, markdownEscape(e.Title()))
+		if e.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", e.Description)
+		}
+		if len(e.Labels) > 0 {
+			b.WriteString("\nLabels:")
+			for i, l := range e.Labels {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				fmt.Fprintf(&b, " `%s: %s`", l.Key, l.Value)
+			}
+			b.WriteString("\n")
+		}
+		writePropertiesTable(&b, e.Properties)
+	}
+
+	for _, s := range p.sections() {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n## %s\n", s.slug, s.title)
+		writePropertiesTable(&b, s.properties)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePropertiesTable(b *strings.Builder, props []Property) {
+	if len(props) == 0 {
+		b.WriteString("\nNo properties.\n")
+		return
+	}
+	b.WriteString("\n| Property | Type | Required | Enum | Pattern | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, p := range props {
+		required := ""
+		if p.Required {
+			required = "yes"
+		}
+		var enum []string
+		for _, v := range p.Enum {
+			enum = append(enum, "`"+v+"`")
+		}
+		pattern := ""
+		if p.Pattern != "" {
+			pattern = "`" + p.Pattern + "`"
+		}
+		description := p.Description
+		if p.Format != "" {
+			description = strings.TrimSpace(fmt.Sprintf("%s (format: %s)", description, p.Format))
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s | %s |\n", p.Path, markdownEscape(p.Type), required,
+			markdownEscape(strings.Join(enum, ", ")), markdownEscape(pattern), markdownEscape(description))
+	}
+}
+
+// section is a documented set of rules other than an event
+type section struct {
+	title      string
+	slug       string
+	properties []Property
+}
+
+func (p Plan) sections() []section {
+	var s []section
+	if len(p.Identify) > 0 {
+		s = append(s, section{"Identify traits", "identify-traits", p.Identify})
+	}
+	if len(p.Group) > 0 {
+		s = append(s, section{"Group traits", "group-traits", p.Group})
+	}
+	if len(p.Global) > 0 {
+		s = append(s, section{"Global rules", "global-rules", p.Global})
+	}
+	return s
+}
+
+// markdownEscape keeps a value on one line and from breaking tables
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
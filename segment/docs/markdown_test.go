@@ -0,0 +1,52 @@
+package docs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMarkdown(&buf, testPlan()))
+
+	expected := "# Storefront\n" +
+		"\n## Contents\n\n" +
+		"- [Cart Viewed](#cart-viewed)\n" +
+		"- [Order Completed (v1)](#order-completed)\n" +
+		"- [Order Completed (v2)](#order-completed-v2)\n" +
+		"- [Identify traits](#identify-traits)\n" +
+		"- [Global rules](#global-rules)\n" +
+		"\n## Events\n" +
+		"\n<a id=\"cart-viewed\"></a>\n### Cart Viewed\n" +
+		"\nNo properties.\n" +
+		"\n<a id=\"order-completed\"></a>\n### Order Completed (v1)\n" +
+		"\nAn order was placed\n" +
+		"\nNo properties.\n" +
+		"\n<a id=\"order-completed-v2\"></a>\n### Order Completed (v2)\n" +
+		"\nAn order was placed\n" +
+		"\nLabels: `owner: checkout`\n" +
+		"\n| Property | Type | Required | Enum | Pattern | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `order_id` | string | yes |  | `^[0-9]+$` | Order identifier |\n" +
+		"| `payment_method` | string |  | `card`, `paypal` |  | How the order \\| was paid |\n" +
+		"| `shipping` | object |  |  |  |  |\n" +
+		"| `shipping.method` | string |  |  |  |  |\n" +
+		"| `tags` | string[] |  |  |  |  |\n" +
+		"| `tags[]` | string |  |  |  |  |\n" +
+		"| `context.locale` | string |  |  |  |  |\n" +
+		"\n<a id=\"identify-traits\"></a>\n## Identify traits\n" +
+		"\n| Property | Type | Required | Enum | Pattern | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `traits.email` | string | yes |  |  | (format: email) |\n" +
+		"\n<a id=\"global-rules\"></a>\n## Global rules\n" +
+		"\n| Property | Type | Required | Enum | Pattern | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `context.app` | object | yes |  |  |  |\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownEscape(t *testing.T) {
+	assert.Equal(t, `a \| b<br>c<br>d`, markdownEscape("a | b\nc\r\nd"))
+}
@@ -0,0 +1,313 @@
+// Package drift detects configuration changes made to a Segment workspace outside of code, by
+// comparing its live state with a baseline: a declarative spec or a snapshot.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/snapshot"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+)
+
+// Kind is the type of a drift
+type Kind string
+
+// Kinds of drift
+const (
+	SourceUnexpected       Kind = "source-unexpected"
+	SourceMissing          Kind = "source-missing"
+	SourceCatalogChanged   Kind = "source-catalog-changed"
+	DestinationUnexpected  Kind = "destination-unexpected"
+	DestinationMissing     Kind = "destination-missing"
+	DestinationToggled     Kind = "destination-toggled"
+	DestinationConfig      Kind = "destination-config-changed"
+	TrackingPlanUnexpected Kind = "tracking-plan-unexpected"
+	TrackingPlanMissing    Kind = "tracking-plan-missing"
+	TrackingPlanRules      Kind = "tracking-plan-rules-changed"
+	ConnectionUnexpected   Kind = "connection-unexpected"
+	ConnectionMissing      Kind = "connection-missing"
+)
+
+// Mask replaces the values of secret destination settings in a report
+const Mask = segment.RedactedValue
+
+// Drift is one difference between the baseline and the live workspace
+type Drift struct {
+	Kind   Kind   `json:"kind"`
+	Source string `json:"source,omitempty"`
+	// Destination is the slug of a destination of Source
+	Destination string `json:"destination,omitempty"`
+	// TrackingPlan is the display name of a tracking plan
+	TrackingPlan string `json:"tracking_plan,omitempty"`
+	// Setting is the name of a changed destination setting
+	Setting  string      `json:"setting,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	// Diff contains the changes made to the rules of a tracking plan since the baseline
+	Diff *segment.TrackingPlanDiff `json:"diff,omitempty"`
+}
+
+// String renders the drift as one line
+func (d Drift) String() string {
+	switch d.Kind {
+	case SourceUnexpected:
+		return fmt.Sprintf("+ source %s (unexpected)", d.Source)
+	case SourceMissing:
+		return fmt.Sprintf("- source %s (missing)", d.Source)
+	case SourceCatalogChanged:
+		return fmt.Sprintf("~ source %s catalog: %v -> %v", d.Source, d.Expected, d.Actual)
+	case DestinationUnexpected:
+		return fmt.Sprintf("+ destination %s/%s (unexpected)", d.Source, d.Destination)
+	case DestinationMissing:
+		return fmt.Sprintf("- destination %s/%s (missing)", d.Source, d.Destination)
+	case DestinationToggled:
+		return fmt.Sprintf("~ destination %s/%s enabled: %v -> %v", d.Source, d.Destination, d.Expected, d.Actual)
+	case DestinationConfig:
+		return fmt.Sprintf("~ destination %s/%s config.%s: %s -> %s", d.Source, d.Destination, d.Setting,
+			formatValue(d.Expected), formatValue(d.Actual))
+	case TrackingPlanUnexpected:
+		return fmt.Sprintf("+ tracking plan %q (unexpected)", d.TrackingPlan)
+	case TrackingPlanMissing:
+		return fmt.Sprintf("- tracking plan %q (missing)", d.TrackingPlan)
+	case TrackingPlanRules:
+		return fmt.Sprintf("~ tracking plan %q rules changed", d.TrackingPlan)
+	case ConnectionUnexpected:
+		return fmt.Sprintf("+ connection %s -> %q (unexpected)", d.Source, d.TrackingPlan)
+	case ConnectionMissing:
+		return fmt.Sprintf("- connection %s -> %q (missing)", d.Source, d.TrackingPlan)
+	}
+	return string(d.Kind)
+}
+
+// Report lists the drifts of a workspace
+type Report struct {
+	Drifts []Drift `json:"drifts"`
+}
+
+// HasDrift reports whether the workspace drifted from its baseline
+func (r Report) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// ExitCode returns the exit status of a drift check: 0 without drift and 1 with drift
+func (r Report) ExitCode() int {
+	if r.HasDrift() {
+		return 1
+	}
+	return 0
+}
+
+// Counts returns the number of drifts of each kind
+func (r Report) Counts() map[Kind]int {
+	counts := map[Kind]int{}
+	for _, d := range r.Drifts {
+		counts[d.Kind]++
+	}
+	return counts
+}
+
+// JSON returns the report as indented JSON
+func (r Report) JSON() ([]byte, error) {
+	if r.Drifts == nil {
+		r.Drifts = []Drift{}
+	}
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// String renders the report as a human readable summary
+func (r Report) String() string {
+	if !r.HasDrift() {
+		return "no drift\n"
+	}
+	var b strings.Builder
+	for _, d := range r.Drifts {
+		b.WriteString(d.String() + "\n")
+		if d.Diff != nil {
+			for _, line := range strings.Split(strings.TrimSuffix(d.Diff.String(), "\n"), "\n") {
+				fmt.Fprintf(&b, "    %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d drifts\n", len(r.Drifts))
+	return b.String()
+}
+
+// Check compares the live state of a workspace with a spec. Resources missing from the spec are
+// reported as unexpected, but only the destination settings declared in the spec are compared.
+func Check(baseline spec.Spec, live spec.State) Report {
+	return check(baseline, live, false)
+}
+
+// CheckSnapshot compares the live state of a workspace with a snapshot. Every destination setting
+// is compared, and settings that are not in the snapshot are reported too.
+func CheckSnapshot(baseline snapshot.Snapshot, live spec.State) Report {
+	return CheckState(baseline.State(), live)
+}
+
+// CheckState compares the live state of a workspace with another state, such as the state of
+// another workspace, in the same way as CheckSnapshot
+func CheckState(baseline spec.State, live spec.State) Report {
+	return check(baseline.Spec(), live, true)
+}
+
+func check(baseline spec.Spec, live spec.State, allSettings bool) Report {
+	var r Report
+	declared := map[string]bool{}
+	for _, src := range baseline.Sources {
+		declared[src.Name] = true
+		liveSource, ok := live.Source(src.Name)
+		if !ok {
+			r.Drifts = append(r.Drifts, Drift{Kind: SourceMissing, Source: src.Name})
+			continue
+		}
+		if liveSource.CatalogName != src.Catalog {
+			r.Drifts = append(r.Drifts, Drift{Kind: SourceCatalogChanged, Source: src.Name, Expected: src.Catalog, Actual: liveSource.CatalogName})
+		}
+		r.Drifts = append(r.Drifts, checkDestinations(src, live, allSettings)...)
+	}
+	for _, src := range live.Sources {
+		if name := segment.Slug(src.Name); !declared[name] {
+			r.Drifts = append(r.Drifts, Drift{Kind: SourceUnexpected, Source: name})
+		}
+	}
+	r.Drifts = append(r.Drifts, checkTrackingPlans(baseline, live)...)
+	return r
+}
+
+func checkDestinations(src spec.Source, live spec.State, allSettings bool) []Drift {
+	var drifts []Drift
+	declared := map[string]bool{}
+	for _, d := range src.Destinations {
+		declared[d.Name] = true
+		liveDest, ok := live.Destination(src.Name, d.Name)
+		if !ok {
+			drifts = append(drifts, Drift{Kind: DestinationMissing, Source: src.Name, Destination: d.Name})
+			continue
+		}
+		if d.Enabled != nil && *d.Enabled != liveDest.Enabled {
+			drifts = append(drifts, Drift{Kind: DestinationToggled, Source: src.Name, Destination: d.Name, Expected: *d.Enabled, Actual: liveDest.Enabled})
+		}
+
+		liveConfig := map[string]segment.DestinationConfig{}
+		for _, c := range liveDest.Configs {
+			liveConfig[segment.Slug(c.Name)] = c
+		}
+		keys := map[string]bool{}
+		for k := range d.Config {
+			keys[k] = true
+		}
+		if allSettings {
+			for k := range liveConfig {
+				keys[k] = true
+			}
+		}
+		for _, key := range sortedKeys(keys) {
+			expected, inBaseline := d.Config[key]
+			actual, inLive := liveConfig[key]
+			// Redacted values and secret references cannot be compared with the live values
+			unknown := expected == segment.RedactedValue || segment.IsSecretReference(expected)
+			if inBaseline && inLive && (unknown || reflect.DeepEqual(expected, actual.Value)) {
+				continue
+			}
+			drift := Drift{Kind: DestinationConfig, Source: src.Name, Destination: d.Name, Setting: key}
+			if inBaseline {
+				drift.Expected = expected
+			}
+			if inLive {
+				drift.Actual = actual.Value
+			} else {
+				actual.Name = key
+			}
+			drift.Expected, drift.Actual = redact(actual, drift.Expected), redact(actual, drift.Actual)
+			drifts = append(drifts, drift)
+		}
+	}
+	for _, d := range live.Destinations[src.Name] {
+		if name := segment.Slug(d.Name); !declared[name] {
+			drifts = append(drifts, Drift{Kind: DestinationUnexpected, Source: src.Name, Destination: name})
+		}
+	}
+	return drifts
+}
+
+func checkTrackingPlans(baseline spec.Spec, live spec.State) []Drift {
+	var drifts []Drift
+	declared := map[string]bool{}
+	connected := map[string]bool{}
+	for _, p := range baseline.TrackingPlans {
+		declared[p.DisplayName] = true
+		matches := live.TrackingPlansNamed(p.DisplayName)
+		if len(matches) == 0 {
+			drifts = append(drifts, Drift{Kind: TrackingPlanMissing, TrackingPlan: p.DisplayName})
+			continue
+		}
+		livePlan := matches[0]
+		if p.Rules != nil {
+			diff := segment.DiffRules(*p.Rules, livePlan.Rules)
+			if !diff.IsEmpty() {
+				drifts = append(drifts, Drift{Kind: TrackingPlanRules, TrackingPlan: p.DisplayName, Diff: &diff})
+			}
+		}
+
+		name := segment.Slug(livePlan.Name)
+		for _, src := range p.Sources {
+			connected[src+"\x00"+name] = true
+			if live.Connections[src] != name {
+				drifts = append(drifts, Drift{Kind: ConnectionMissing, Source: src, TrackingPlan: p.DisplayName})
+			}
+		}
+	}
+
+	for _, p := range live.TrackingPlans {
+		if !declared[p.DisplayName] {
+			drifts = append(drifts, Drift{Kind: TrackingPlanUnexpected, TrackingPlan: p.DisplayName})
+			continue
+		}
+		name := segment.Slug(p.Name)
+		var sources []string
+		for src, plan := range live.Connections {
+			if plan == name && !connected[src+"\x00"+name] {
+				sources = append(sources, src)
+			}
+		}
+		sort.Strings(sources)
+		for _, src := range sources {
+			drifts = append(drifts, Drift{Kind: ConnectionUnexpected, Source: src, TrackingPlan: p.DisplayName})
+		}
+	}
+	return drifts
+}
+
+// redact returns a value of a setting, redacted if the setting holds a credential
+func redact(c segment.DestinationConfig, v interface{}) interface{} {
+	c.Value = v
+	return c.Redacted().Value
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	if v == Mask {
+		return Mask
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
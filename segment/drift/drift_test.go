@@ -0,0 +1,176 @@
+package drift
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/fenderdigital/segment-apis-go/segment/snapshot"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddDestination("ws", "js", "google-analytics", true,
+		segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-1"},
+		segment.DestinationConfig{Name: "apiSecret", Type: "password", Value: "s3cr3t"})
+	api.AddDestination("ws", "js", "amplitude", false)
+	b := segment.NewRulesBuilder()
+	b.Event("Order Completed").Version(1).Prop("order_id", segment.String().Required())
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", b.Rules())
+	api.Connect("ws", "js", "rs_1")
+	return api
+}
+
+func readState(t *testing.T, api *fakeapi.Server) spec.State {
+	live, err := spec.ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+	return live
+}
+
+func TestCheckSnapshot_NoDrift(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := snapshot.Take(api.Client("ws"))
+	assert.NoError(t, err)
+	r := CheckSnapshot(s, readState(t, api))
+	assert.False(t, r.HasDrift())
+	assert.Equal(t, 0, r.ExitCode())
+	assert.Equal(t, "no drift\n", r.String())
+
+	// secret settings are redacted in saved snapshots, and not compared
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteTar(&buf))
+	saved, err := snapshot.ReadTar(&buf)
+	assert.NoError(t, err)
+	assert.False(t, CheckSnapshot(saved, readState(t, api)).HasDrift())
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := snapshot.Take(api.Client("ws"))
+	assert.NoError(t, err)
+
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		w.Sources = w.Sources[:1]
+		ga := &w.Destinations["js"][0]
+		ga.Enabled = false
+		ga.Configs[0].Value = "UA-2"
+		ga.Configs[1].Value = "changed"
+		ga.Configs = append(ga.Configs, segment.DestinationConfig{
+			Name: ga.Name + "/config/anonymizeIp", Type: "boolean", Value: true})
+		w.Destinations["js"] = w.Destinations["js"][:1]
+		w.TrackingPlans[0].Rules.Events[0].Description = "An order was placed"
+	})
+	api.AddSource("ws", "android", "catalog/sources/android")
+	api.AddDestination("ws", "js", "mixpanel", true)
+	api.AddTrackingPlan("ws", "rs_2", "Legacy", segment.Rules{})
+	api.Connect("ws", "android", "rs_1")
+
+	r := CheckSnapshot(s, readState(t, api))
+	expected := `- source ios (missing)
+- destination js/amplitude (missing)
+~ destination js/google-analytics enabled: true -> false
+~ destination js/google-analytics config.anonymizeIp: (unset) -> true
+~ destination js/google-analytics config.apiSecret: ******** -> ********
+~ destination js/google-analytics config.trackingId: "UA-1" -> "UA-2"
++ destination js/mixpanel (unexpected)
++ source android (unexpected)
+~ tracking plan "Kicks App" rules changed
+    ~ event "Order Completed" (v1)
+        ~ description: "" -> "An order was placed"
++ connection android -> "Kicks App" (unexpected)
++ tracking plan "Legacy" (unexpected)
+11 drifts
+`
+	assert.Equal(t, expected, r.String())
+	assert.Equal(t, 1, r.ExitCode())
+	assert.Equal(t, 1, r.Counts()[DestinationToggled])
+	assert.Equal(t, 3, r.Counts()[DestinationConfig])
+}
+
+func TestCheck(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	enabled := true
+	baseline := spec.Spec{
+		Sources: []spec.Source{
+			{Name: "js", Catalog: "catalog/sources/javascript", Destinations: []spec.Destination{
+				{Name: "google-analytics", Enabled: &enabled, Config: map[string]interface{}{"trackingId": "UA-1"}},
+				{Name: "amplitude"},
+			}},
+			{Name: "ios", Catalog: "catalog/sources/ios"},
+			{Name: "android", Catalog: "catalog/sources/android"},
+		},
+		TrackingPlans: []spec.TrackingPlan{{DisplayName: "Kicks App", Sources: []string{"js", "ios"}}},
+	}
+
+	// Settings missing from the spec and the rules of plans without a file are not compared
+	r := Check(baseline, readState(t, api))
+	assert.Equal(t, []Drift{
+		{Kind: SourceMissing, Source: "android"},
+		{Kind: ConnectionMissing, Source: "ios", TrackingPlan: "Kicks App"},
+	}, r.Drifts)
+
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		w.Destinations["js"][1].Enabled = true
+	})
+	r = Check(baseline, readState(t, api))
+	assert.Len(t, r.Drifts, 2, "enabled is not managed by the spec")
+}
+
+func TestCheckSnapshot_TrackingPlanRules(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := snapshot.Take(api.Client("ws"))
+	assert.NoError(t, err)
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		rules := &w.TrackingPlans[0].Rules
+		v2 := segment.NewRulesBuilder().Event("Order Completed").Version(2).Prop("order_id", segment.String()).Event()
+		rules.Events = append(rules.Events, v2)
+		rules.GroupTraits = []segment.Rule{segment.String().Rule()}
+		props := rules.Events[0].Rules.Properties["properties"]
+		props.AdditionalProperties = &segment.AdditionalProperties{Allowed: false}
+		rules.Events[0].Rules.Properties["properties"] = props
+	})
+
+	r := CheckSnapshot(s, readState(t, api))
+	expected := `~ tracking plan "Kicks App" rules changed
+    ~ event "Order Completed" (v1)
+        ~ properties additional_properties: null -> false
+    + event "Order Completed" (v2)
+    ~ group traits
+1 drifts
+`
+	assert.Equal(t, expected, r.String(), "other event versions, keywords and traits are compared")
+}
+
+func TestReport_JSON(t *testing.T) {
+	data, err := Report{}.JSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"drifts": []}`, string(data))
+
+	r := Report{Drifts: []Drift{
+		{Kind: DestinationConfig, Source: "js", Destination: "ga", Setting: "apiSecret", Expected: Mask, Actual: Mask},
+		{Kind: DestinationToggled, Source: "js", Destination: "ga", Expected: true, Actual: false},
+	}}
+	data, err = r.JSON()
+	assert.NoError(t, err)
+	var decoded map[string][]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"kind": "destination-config-changed", "source": "js", "destination": "ga",
+		"setting": "apiSecret", "expected": Mask, "actual": Mask,
+	}, decoded["drifts"][0])
+	assert.Equal(t, false, decoded["drifts"][1]["actual"], "false values are kept")
+}
@@ -0,0 +1,92 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GetEventDeliveryMetrics returns the event delivery metrics of a destination between start and end,
+// bucketed by the given granularity
+func (c *Client) GetEventDeliveryMetrics(srcName string, destName string, start time.Time, end time.Time, granularity string) (EventDeliveryMetrics, error) {
+	var m EventDeliveryMetrics
+	params := url.Values{}
+	params.Set("start", start.UTC().Format(time.RFC3339))
+	params.Set("end", end.UTC().Format(time.RFC3339))
+	if granularity != "" {
+		params.Set("granularity", granularity)
+	}
+	data, err := c.doRequest(http.MethodGet,
+		fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s?%s",
+			WorkspacesEndpoint, c.workspace, SourceEndpoint, srcName, DestinationEndpoint, destName,
+			EventDeliveryMetricsEndpoint, params.Encode()),
+		nil)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return m, errors.Wrap(err, "failed to unmarshal event delivery metrics response")
+	}
+
+	return m, nil
+}
+
+// SummarizeEventDelivery fetches the event delivery metrics of every destination of every source in the
+// workspace and flags those whose success rate fell below threshold
+func (c *Client) SummarizeEventDelivery(start time.Time, end time.Time, granularity string, threshold float64) (EventDeliverySummary, error) {
+	summary := EventDeliverySummary{Threshold: threshold}
+	srcs, err := c.ListSources()
+	if err != nil {
+		return summary, err
+	}
+	for _, src := range srcs.Sources {
+		srcName := Slug(src.Name)
+		dests, err := c.ListDestinations(srcName)
+		if err != nil {
+			return summary, errors.Wrapf(err, "failed to list destinations for source %s", srcName)
+		}
+		for _, dest := range dests.Destinations {
+			destName := Slug(dest.Name)
+			m, err := c.GetEventDeliveryMetrics(srcName, destName, start, end, granularity)
+			if err != nil {
+				return summary, errors.Wrapf(err, "failed to get event delivery metrics for %s", dest.Name)
+			}
+			rate := m.SuccessRate()
+			summary.Destinations = append(summary.Destinations, DestinationDeliveryHealth{
+				Source:         src.Name,
+				Destination:    dest.Name,
+				Metrics:        m,
+				SuccessRate:    rate,
+				BelowThreshold: rate < threshold,
+			})
+		}
+	}
+
+	return summary, nil
+}
+
+// SuccessRate returns the share of finished events that were delivered. Retried events are still in
+// flight and are not counted. A destination that received no events has a success rate of 1.
+func (m EventDeliveryMetrics) SuccessRate() float64 {
+	total := m.Delivered + m.Failed + m.Discarded
+	if total == 0 {
+		return 1
+	}
+	return float64(m.Delivered) / float64(total)
+}
+
+// Unhealthy returns the destinations whose success rate fell below the summary threshold
+func (s EventDeliverySummary) Unhealthy() []DestinationDeliveryHealth {
+	var unhealthy []DestinationDeliveryHealth
+	for _, d := range s.Destinations {
+		if d.BelowThreshold {
+			unhealthy = append(unhealthy, d)
+		}
+	}
+	return unhealthy
+}
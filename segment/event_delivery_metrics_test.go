@@ -0,0 +1,109 @@
+package segment
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventDeliveryMetrics_GetEventDeliveryMetrics(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testSource := "js"
+	testDest := "google-analytics"
+	start, _ := time.Parse(time.RFC3339, "2019-02-05T00:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2019-02-06T00:00:00Z")
+
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s/%s/%s/%s",
+		apiVersion, WorkspacesEndpoint, testWorkspace, SourceEndpoint, testSource, DestinationEndpoint, testDest,
+		EventDeliveryMetricsEndpoint)
+
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2019-02-05T00:00:00Z", r.URL.Query().Get("start"))
+		assert.Equal(t, "2019-02-06T00:00:00Z", r.URL.Query().Get("end"))
+		assert.Equal(t, GranularityDay, r.URL.Query().Get("granularity"))
+		fmt.Fprint(w, `{
+			"name": "workspaces/myworkspace/sources/js/destinations/google-analytics/event-delivery-metrics",
+			"granularity": "DAY",
+			"delivered": 95,
+			"failed": 3,
+			"retried": 4,
+			"discarded": 2,
+			"failure_reasons": [
+				{"reason": "Bad Request", "count": 3}
+			],
+			"datapoints": [
+				{"time": "2019-02-05T00:00:00Z", "delivered": 95, "failed": 3, "retried": 4, "discarded": 2}
+			]
+		}`)
+	})
+
+	actual, err := client.GetEventDeliveryMetrics(testSource, testDest, start, end, GranularityDay)
+	assert.NoError(t, err)
+
+	expected := EventDeliveryMetrics{
+		Name:           "workspaces/myworkspace/sources/js/destinations/google-analytics/event-delivery-metrics",
+		Granularity:    GranularityDay,
+		Delivered:      95,
+		Failed:         3,
+		Retried:        4,
+		Discarded:      2,
+		FailureReasons: []EventDeliveryFailureReason{{Reason: "Bad Request", Count: 3}},
+		Datapoints: []EventDeliveryDatapoint{
+			{Time: &start, Delivered: 95, Failed: 3, Retried: 4, Discarded: 2},
+		},
+	}
+	assert.Equal(t, expected, actual)
+	assert.InDelta(t, 0.95, actual.SuccessRate(), 0.0001)
+}
+
+func TestEventDeliveryMetrics_SuccessRateWithoutEvents(t *testing.T) {
+	assert.Equal(t, float64(1), EventDeliveryMetrics{Retried: 10}.SuccessRate())
+}
+
+func TestEventDeliveryMetrics_SummarizeEventDelivery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	start, _ := time.Parse(time.RFC3339, "2019-02-05T00:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2019-02-06T00:00:00Z")
+	sourcesEndpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, SourceEndpoint)
+
+	mux.HandleFunc(sourcesEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"sources": [
+				{"name": "workspaces/test-workspace/sources/js"}
+			]
+		}`)
+	})
+	mux.HandleFunc(sourcesEndpoint+"/js/"+DestinationEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"destinations": [
+				{"name": "workspaces/test-workspace/sources/js/destinations/google-analytics"},
+				{"name": "workspaces/test-workspace/sources/js/destinations/amplitude"}
+			]
+		}`)
+	})
+	mux.HandleFunc(sourcesEndpoint+"/js/"+DestinationEndpoint+"/google-analytics/"+EventDeliveryMetricsEndpoint,
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"delivered": 99, "failed": 1}`)
+		})
+	mux.HandleFunc(sourcesEndpoint+"/js/"+DestinationEndpoint+"/amplitude/"+EventDeliveryMetricsEndpoint,
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"delivered": 50, "failed": 30, "discarded": 20}`)
+		})
+
+	actual, err := client.SummarizeEventDelivery(start, end, GranularityHour, 0.9)
+	assert.NoError(t, err)
+
+	assert.Len(t, actual.Destinations, 2)
+	unhealthy := actual.Unhealthy()
+	assert.Len(t, unhealthy, 1)
+	assert.Equal(t, "workspaces/test-workspace/sources/js/destinations/amplitude", unhealthy[0].Destination)
+	assert.Equal(t, "workspaces/test-workspace/sources/js", unhealthy[0].Source)
+	assert.InDelta(t, 0.5, unhealthy[0].SuccessRate, 0.0001)
+}
@@ -0,0 +1,401 @@
+// Package infer builds a draft tracking plan from sample Segment events. Property types are the
+// union of the types observed, properties seen in enough events are required, and string
+// properties with few distinct values become enums. The draft rules can be passed straight to
+// Client.CreateTrackingPlan.
+package infer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+	"github.com/pkg/errors"
+)
+
+// Call types of sample events
+const (
+	CallTrack    = "track"
+	CallIdentify = "identify"
+	CallGroup    = "group"
+)
+
+// Options controls the confidence thresholds of the inference
+type Options struct {
+	// RequiredRatio is the share of events a property must be present in to be required.
+	// It defaults to 1, so only properties present in every sample are required.
+	RequiredRatio float64
+	// MinEventSamples is the number of samples an event needs to be part of the draft. It defaults to 1.
+	MinEventSamples int
+	// EnumMaxValues is the number of distinct values up to which a string property becomes an enum.
+	// It defaults to 10, and a negative value disables enums.
+	EnumMaxValues int
+	// EnumMinSamples is the number of samples of a property needed before an enum is inferred.
+	// It defaults to 20, so that a handful of samples does not produce an overly strict enum.
+	EnumMinSamples int
+	// EnumMaxRatio is the highest ratio of distinct values to samples of an enum. It defaults to 0.5,
+	// which keeps identifiers that are unique per event from becoming enums.
+	EnumMaxRatio float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.RequiredRatio <= 0 {
+		o.RequiredRatio = 1
+	}
+	if o.MinEventSamples <= 0 {
+		o.MinEventSamples = 1
+	}
+	if o.EnumMaxValues == 0 {
+		o.EnumMaxValues = 10
+	}
+	if o.EnumMinSamples <= 0 {
+		o.EnumMinSamples = 20
+	}
+	if o.EnumMaxRatio <= 0 {
+		o.EnumMaxRatio = 0.5
+	}
+	return o
+}
+
+// EventSamples counts the samples seen of one event, or of one call type for calls other than track
+type EventSamples struct {
+	Event    string `json:"event"`
+	Samples  int    `json:"samples"`
+	Included bool   `json:"included"`
+}
+
+// Inferrer accumulates sample events
+type Inferrer struct {
+	opts      Options
+	events    map[string]*node
+	identify  *node
+	group     *node
+	malformed int
+}
+
+// New returns an inferrer with no samples
+func New(opts Options) *Inferrer {
+	return &Inferrer{
+		opts:     opts.withDefaults(),
+		events:   map[string]*node{},
+		identify: newNode(),
+		group:    newNode(),
+	}
+}
+
+// Add adds a sample event given as JSON
+func (in *Inferrer) Add(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var event map[string]interface{}
+	if err := dec.Decode(&event); err != nil {
+		in.malformed++
+		return errors.Wrap(err, "failed to unmarshal event")
+	}
+	in.AddEvent(event)
+	return nil
+}
+
+// AddEvent adds a decoded sample event. Events other than track, identify and group calls are ignored.
+func (in *Inferrer) AddEvent(event map[string]interface{}) {
+	callType, _ := event["type"].(string)
+	if callType == "" {
+		if _, ok := event["event"]; ok {
+			callType = CallTrack
+		}
+	}
+	switch callType {
+	case CallTrack:
+		name, _ := event["event"].(string)
+		if name == "" {
+			return
+		}
+		n, ok := in.events[name]
+		if !ok {
+			n = newNode()
+			in.events[name] = n
+		}
+		n.observe(objectOrEmpty(event["properties"]), in.opts)
+	case CallIdentify:
+		in.identify.observe(objectOrEmpty(event["traits"]), in.opts)
+	case CallGroup:
+		in.group.observe(objectOrEmpty(event["traits"]), in.opts)
+	}
+}
+
+// AddStream adds the newline-delimited sample events read from r, which may be gzipped. Lines that
+// are not valid JSON are counted as malformed and skipped.
+func (in *Inferrer) AddStream(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return errors.Wrap(err, "failed to read gzip header")
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+	for {
+		data, err := br.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			in.Add(trimmed)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read events")
+		}
+	}
+}
+
+// AddFile adds the newline-delimited sample events of a file, which may be gzipped
+func (in *Inferrer) AddFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+
+	return in.AddStream(f)
+}
+
+// Malformed returns the number of samples that could not be decoded
+func (in *Inferrer) Malformed() int {
+	return in.malformed
+}
+
+// Samples returns how many samples were seen of each event, sorted by event name, followed by
+// the identify and group calls
+func (in *Inferrer) Samples() []EventSamples {
+	var samples []EventSamples
+	for _, name := range in.eventNames() {
+		n := in.events[name]
+		samples = append(samples, EventSamples{Event: name, Samples: n.count, Included: n.count >= in.opts.MinEventSamples})
+	}
+	for _, s := range []struct {
+		call string
+		n    *node
+	}{{CallIdentify, in.identify}, {CallGroup, in.group}} {
+		if s.n.count > 0 {
+			samples = append(samples, EventSamples{Event: s.call, Samples: s.n.count, Included: true})
+		}
+	}
+	return samples
+}
+
+// Rules returns the draft rules inferred from the samples
+func (in *Inferrer) Rules() segment.Rules {
+	rules := segment.Rules{
+		Events:         []segment.Event{},
+		IdentifyTraits: []segment.Rule{},
+		GroupTraits:    []segment.Rule{},
+	}
+	for _, name := range in.eventNames() {
+		n := in.events[name]
+		if n.count < in.opts.MinEventSamples {
+			continue
+		}
+		rules.Events = append(rules.Events, segment.Event{
+			Name:    name,
+			Version: 1,
+			Rules:   envelope("properties", n.rule(in.opts)),
+		})
+	}
+	if in.identify.count > 0 {
+		rules.Identify = envelope("traits", in.identify.rule(in.opts))
+	}
+	if in.group.count > 0 {
+		rules.Group = envelope("traits", in.group.rule(in.opts))
+	}
+	return rules
+}
+
+// TrackingPlan returns a draft tracking plan inferred from the samples
+func (in *Inferrer) TrackingPlan(displayName string) segment.TrackingPlan {
+	return segment.TrackingPlan{DisplayName: displayName, Rules: in.Rules()}
+}
+
+// InferFile infers draft rules from a file of newline-delimited sample events
+func InferFile(path string, opts Options) (segment.Rules, error) {
+	in := New(opts)
+	if err := in.AddFile(path); err != nil {
+		return segment.Rules{}, err
+	}
+	return in.Rules(), nil
+}
+
+func (in *Inferrer) eventNames() []string {
+	names := make([]string, 0, len(in.events))
+	for name := range in.events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// envelope wraps the rule of one section in an event rule, in the shape Segment and
+// segment.RulesBuilder use
+func envelope(section string, r segment.Rule) segment.Rule {
+	env := segment.Rule{
+		Schema: segment.SchemaDraft07,
+		Type:   segment.SingleType(segment.TypeObject),
+		Properties: map[string]segment.Rule{
+			"context":    {},
+			"traits":     {},
+			"properties": {},
+		},
+	}
+	if len(r.Properties) == 0 {
+		return env
+	}
+	r.Type = segment.SingleType(segment.TypeObject)
+	env.Properties[section] = r
+	if len(r.Required) > 0 {
+		env.Required = []string{section}
+	}
+	return env
+}
+
+func objectOrEmpty(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// node accumulates the values observed at one path
+type node struct {
+	count      int
+	types      map[string]int
+	values     map[string]bool
+	tooMany    bool
+	objects    int
+	properties map[string]*node
+	items      *node
+}
+
+func newNode() *node {
+	return &node{types: map[string]int{}}
+}
+
+func (n *node) observe(v interface{}, opts Options) {
+	n.count++
+	t := jsonvalue.Type(v)
+	n.types[t]++
+	switch val := v.(type) {
+	case string:
+		if !n.tooMany {
+			if n.values == nil {
+				n.values = map[string]bool{}
+			}
+			n.values[val] = true
+			if len(n.values) > opts.EnumMaxValues {
+				n.tooMany = true
+				n.values = nil
+			}
+		}
+	case map[string]interface{}:
+		n.objects++
+		if n.properties == nil {
+			n.properties = map[string]*node{}
+		}
+		for k, child := range val {
+			c, ok := n.properties[k]
+			if !ok {
+				c = newNode()
+				n.properties[k] = c
+			}
+			c.observe(child, opts)
+		}
+	case []interface{}:
+		for _, item := range val {
+			if n.items == nil {
+				n.items = newNode()
+			}
+			n.items.observe(item, opts)
+		}
+	}
+}
+
+func (n *node) rule(opts Options) segment.Rule {
+	var r segment.Rule
+	var types []string
+	for t := range n.types {
+		types = append(types, t)
+	}
+	types = unionTypes(types)
+	switch {
+	case len(types) == 1 && types[0] == segment.TypeObject:
+		// objects are typed like segment.Object and the envelope sections
+		r.Type = segment.SingleType(segment.TypeObject)
+	case len(types) > 0:
+		r.Type = segment.TypeList(types...)
+	}
+
+	if len(n.properties) > 0 {
+		r.Properties = map[string]segment.Rule{}
+		for _, name := range sortedKeys(n.properties) {
+			c := n.properties[name]
+			r.Properties[name] = c.rule(opts)
+			if float64(c.count) >= opts.RequiredRatio*float64(n.objects) {
+				r.Required = append(r.Required, name)
+			}
+		}
+	}
+	if n.items != nil {
+		items := n.items.rule(opts)
+		r.Items = &items
+	}
+
+	stringCount := n.types[segment.TypeString]
+	if opts.EnumMaxValues > 0 && !n.tooMany && stringCount > 0 && stringCount >= opts.EnumMinSamples &&
+		stringCount+n.types[segment.TypeNull] == n.count && float64(len(n.values)) <= opts.EnumMaxRatio*float64(stringCount) {
+		values := make([]string, 0, len(n.values))
+		for v := range n.values {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		r.Enum = segment.StringEnum(values...)
+		if n.types[segment.TypeNull] > 0 {
+			r.Enum = append(r.Enum, nil)
+		}
+	}
+	return r
+}
+
+// unionTypes sorts the observed types, folding integer into number and putting null last
+func unionTypes(types []string) []string {
+	has := map[string]bool{}
+	for _, t := range types {
+		has[t] = true
+	}
+	if has[segment.TypeNumber] {
+		delete(has, segment.TypeInteger)
+	}
+	var union []string
+	for t := range has {
+		if t != segment.TypeNull {
+			union = append(union, t)
+		}
+	}
+	sort.Strings(union)
+	if has[segment.TypeNull] {
+		union = append(union, segment.TypeNull)
+	}
+	return union
+}
+
+func sortedKeys(m map[string]*node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package infer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func samples() string {
+	var b strings.Builder
+	for i := 0; i < 40; i++ {
+		currency := []string{"USD", "EUR"}[i%2]
+		method := []string{"standard", "express"}[i%2]
+		price := fmt.Sprint(10 + i)
+		if i%2 == 1 {
+			price += ".5"
+		}
+		extra := ""
+		if i%4 == 0 {
+			extra += fmt.Sprintf(`, "coupon": "C%d"`, i)
+		}
+		if i%2 == 0 {
+			extra += `, "tags": ["a", "b"]`
+		}
+		fmt.Fprintf(&b, `{"type": "track", "event": "Order Completed", "properties": {"order_id": "o%d", "currency": %q, "price": %s, "shipping": {"method": %q}%s}}`+"\n",
+			i, currency, price, method, extra)
+	}
+	b.WriteString(`{"type": "identify", "traits": {"email": "jane@example.com", "age": 30}}` + "\n")
+	b.WriteString(`{"type": "identify", "traits": {"email": "john@example.com", "age": null}}` + "\n")
+	b.WriteString(`{"event": "Cart Viewed"}` + "\n")
+	b.WriteString(`{"type": "page", "name": "Home"}` + "\n")
+	b.WriteString("not json\n")
+	return b.String()
+}
+
+func TestInfer_Rules(t *testing.T) {
+	in := New(Options{RequiredRatio: 0.9})
+	assert.NoError(t, in.AddStream(strings.NewReader(samples())))
+
+	tp := segment.NewRulesBuilder()
+	tp.Event("Cart Viewed").Version(1)
+	tp.Event("Order Completed").Version(1).
+		Prop("coupon", segment.String()).
+		Prop("currency", segment.String().Required().Enum("EUR", "USD")).
+		Prop("order_id", segment.String().Required()).
+		Prop("price", segment.Number().Required()).
+		Prop("shipping", segment.Object().Required().
+			Prop("method", segment.String().Required().Enum("express", "standard"))).
+		Prop("tags", segment.Array(segment.String().Enum("a", "b")))
+	tp.Identify().
+		Trait("age", segment.Integer().Required().Nullable()).
+		Trait("email", segment.String().Required())
+
+	assert.Equal(t, tp.Rules(), in.Rules())
+	assert.Equal(t, 1, in.Malformed())
+	assert.Equal(t, []EventSamples{
+		{Event: "Cart Viewed", Samples: 1, Included: true},
+		{Event: "Order Completed", Samples: 40, Included: true},
+		{Event: CallIdentify, Samples: 2, Included: true},
+	}, in.Samples())
+}
+
+func TestInfer_Thresholds(t *testing.T) {
+	in := New(Options{RequiredRatio: 0.2, MinEventSamples: 2, EnumMaxValues: -1})
+	assert.NoError(t, in.AddStream(strings.NewReader(samples())))
+
+	rules := in.Rules()
+	assert.Len(t, rules.Events, 1)
+	props := rules.Events[0].Rules.Properties["properties"]
+	assert.Equal(t, []string{"coupon", "currency", "order_id", "price", "shipping", "tags"}, props.Required)
+	assert.Empty(t, props.Properties["currency"].Enum)
+	assert.False(t, in.Samples()[0].Included)
+}
+
+func TestInfer_UnionTypes(t *testing.T) {
+	in := New(Options{EnumMinSamples: 2})
+	assert.NoError(t, in.Add([]byte(`{"event": "Signed Up", "properties": {"plan": "pro", "seats": 1, "value": "x"}}`)))
+	assert.NoError(t, in.Add([]byte(`{"event": "Signed Up", "properties": {"plan": null, "seats": 1.5, "value": true}}`)))
+	assert.NoError(t, in.Add([]byte(`{"event": "Signed Up", "properties": {"plan": "pro", "seats": 2, "value": [1]}}`)))
+	assert.NoError(t, in.Add([]byte(`{"event": "Signed Up", "properties": {"plan": "pro", "seats": 2, "value": {}}}`)))
+	assert.Error(t, in.Add([]byte(`{`)))
+
+	props := in.Rules().Events[0].Rules.Properties["properties"].Properties
+	assert.Equal(t, segment.TypeList(segment.TypeString, segment.TypeNull), props["plan"].Type)
+	assert.Equal(t, segment.Enum{"pro", nil}, props["plan"].Enum)
+	assert.Equal(t, segment.TypeList(segment.TypeNumber), props["seats"].Type)
+	assert.Equal(t, segment.TypeList(segment.TypeArray, segment.TypeBoolean, segment.TypeObject, segment.TypeString), props["value"].Type)
+}
+
+func TestInfer_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(samples()))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	in := New(Options{})
+	assert.NoError(t, in.AddStream(&buf))
+	assert.Equal(t, "Kicks", in.TrackingPlan("Kicks").DisplayName)
+	assert.Len(t, in.TrackingPlan("Kicks").Rules.Events, 2)
+}
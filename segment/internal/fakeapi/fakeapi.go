@@ -0,0 +1,491 @@
+// Package fakeapi is an in-memory Segment Config API for tests. It serves the workspace, source,
+// schema settings, destination, tracking plan and source connection endpoints used by segment.Client,
+// and logs the requests that change something.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+// Server is a fake Config API. Its fields must only be changed through its methods once requests are
+// being served.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	workspaces map[string]*Workspace
+	calls      []string
+	failures   map[string]int
+	nextPlanID int
+
+	// Now returns the create and update times of resources. It defaults to a fixed time.
+	Now func() time.Time
+}
+
+// Workspace is the state of one fake workspace
+type Workspace struct {
+	Workspace     segment.Workspace
+	Sources       []segment.Source
+	Destinations  map[string][]segment.Destination
+	TrackingPlans []segment.TrackingPlan
+	// Connections maps source slugs to tracking plan names
+	Connections map[string]string
+	// SchemaConfigs are the schema settings of sources, by source slug
+	SchemaConfigs map[string]segment.SourceSchemaConfig
+}
+
+// DefaultTime is the time resources are created and updated at unless Server.Now is set
+var DefaultTime = time.Date(2019, 2, 5, 0, 28, 31, 0, time.UTC)
+
+// New starts a fake Config API server. Close it when done.
+func New() *Server {
+	s := &Server{
+		workspaces: map[string]*Workspace{},
+		failures:   map[string]int{},
+		nextPlanID: 100,
+		Now:        func() time.Time { return DefaultTime },
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Client returns a client of a workspace of the server
+func (s *Server) Client(workspace string) *segment.Client {
+	s.Workspace(workspace)
+	c := segment.NewClient("test-token", workspace)
+	c.SetBaseURL(s.URL)
+	return c
+}
+
+// Workspace returns a workspace of the server, creating it if needed. Hold no reference to it while
+// requests are being served.
+func (s *Server) Workspace(name string) *Workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.workspace(name)
+}
+
+func (s *Server) workspace(name string) *Workspace {
+	w, ok := s.workspaces[name]
+	if !ok {
+		now := s.Now()
+		w = &Workspace{
+			Workspace:     segment.Workspace{Name: "workspaces/" + name, DisplayName: name, ID: "ws_" + name, CreateTime: &now},
+			Destinations:  map[string][]segment.Destination{},
+			Connections:   map[string]string{},
+			SchemaConfigs: map[string]segment.SourceSchemaConfig{},
+		}
+		s.workspaces[name] = w
+	}
+	return w
+}
+
+// AddSource adds a source to a workspace
+func (s *Server) AddSource(workspace, slug, catalog string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addSource(s.workspace(workspace), workspace, slug, catalog)
+}
+
+func (s *Server) addSource(w *Workspace, workspace, slug, catalog string) segment.Source {
+	now := s.Now()
+	src := segment.Source{
+		Name:        fmt.Sprintf("workspaces/%s/sources/%s", workspace, slug),
+		Parent:      "workspaces/" + workspace,
+		CatalogName: catalog,
+		WriteKeys:   []string{"key_" + slug},
+		CreateTime:  &now,
+	}
+	w.Sources = append(w.Sources, src)
+	return src
+}
+
+// AddDestination adds a destination to a source of a workspace. Config names may be given as the
+// short setting name, e.g. apiKey.
+func (s *Server) AddDestination(workspace, source, slug string, enabled bool, configs ...segment.DestinationConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addDestination(s.workspace(workspace), workspace, source, segment.Destination{
+		Name: slug, Enabled: enabled, ConnectionMode: "CLOUD", Configs: configs,
+	})
+}
+
+func (s *Server) addDestination(w *Workspace, workspace, source string, d segment.Destination) segment.Destination {
+	now := s.Now()
+	slug := segment.Slug(d.Name)
+	d.Name = fmt.Sprintf("workspaces/%s/sources/%s/destinations/%s", workspace, source, slug)
+	d.Parent = fmt.Sprintf("workspaces/%s/sources/%s", workspace, source)
+	if d.DisplayName == "" {
+		d.DisplayName = slug
+	}
+	configs := make([]segment.DestinationConfig, len(d.Configs))
+	for i, c := range d.Configs {
+		if !strings.Contains(c.Name, "/") {
+			c.Name = d.Name + "/config/" + c.Name
+		}
+		configs[i] = c
+	}
+	d.Configs = configs
+	d.CreateTime = &now
+	d.UpdateTime = &now
+	w.Destinations[source] = append(w.Destinations[source], d)
+	return d
+}
+
+// AddTrackingPlan adds a tracking plan to a workspace
+func (s *Server) AddTrackingPlan(workspace, id, displayName string, rules segment.Rules) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+	w := s.workspace(workspace)
+	w.TrackingPlans = append(w.TrackingPlans, segment.TrackingPlan{
+		Name:        fmt.Sprintf("workspaces/%s/tracking-plans/%s", workspace, id),
+		DisplayName: displayName,
+		Rules:       rules,
+		CreateTime:  &now,
+		UpdateTime:  &now,
+	})
+}
+
+// Connect connects a source of a workspace to a tracking plan
+func (s *Server) Connect(workspace, source, plan string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspace(workspace).Connections[source] = plan
+}
+
+// Update changes a workspace while no request is being served
+func (s *Server) Update(workspace string, f func(w *Workspace)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.workspace(workspace))
+}
+
+// Fail makes the next requests with a method and path fail with a bad request error. The path is
+// relative to the workspace, e.g. sources/js/destinations.
+func (s *Server) Fail(method, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method+" "+path]++
+}
+
+// Calls returns the requests that changed something, as the method and the path relative to the
+// workspace, e.g. "POST sources"
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1beta/workspaces/"), "/")
+	parts := strings.Split(path, "/")
+	ws := s.workspace(parts[0])
+	rel := strings.Join(parts[1:], "/")
+	if r.Method != http.MethodGet {
+		s.calls = append(s.calls, strings.TrimSpace(r.Method+" "+rel))
+	}
+	if s.failures[r.Method+" "+rel] > 0 {
+		s.failures[r.Method+" "+rel]--
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	res, status := s.route(ws, parts[0], r, parts[1:])
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if res == nil {
+		res = struct{}{}
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (s *Server) route(w *Workspace, workspace string, r *http.Request, parts []string) (interface{}, int) {
+	route := r.Method + " " + pattern(parts)
+	switch route {
+	case "GET ":
+		return w.Workspace, http.StatusOK
+
+	case "GET sources":
+		return segment.Sources{Sources: w.Sources}, http.StatusOK
+	case "POST sources":
+		var req struct {
+			Source segment.Source `json:"source"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		slug := segment.Slug(req.Source.Name)
+		if _, i := w.source(slug); i >= 0 {
+			return nil, http.StatusBadRequest
+		}
+		return s.addSource(w, workspace, slug, req.Source.CatalogName), http.StatusOK
+	case "GET sources/*":
+		src, i := w.source(parts[1])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		return src, http.StatusOK
+	case "DELETE sources/*":
+		_, i := w.source(parts[1])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		w.Sources = append(w.Sources[:i], w.Sources[i+1:]...)
+		delete(w.Destinations, parts[1])
+		delete(w.Connections, parts[1])
+		delete(w.SchemaConfigs, parts[1])
+		return nil, http.StatusOK
+
+	case "GET sources/*/schema-config":
+		if _, i := w.source(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		sc := w.SchemaConfigs[parts[1]]
+		sc.Name = fmt.Sprintf("workspaces/%s/sources/%s/schema-config", workspace, parts[1])
+		return sc, http.StatusOK
+	case "PATCH sources/*/schema-config":
+		var req struct {
+			SchemaConfig map[string]interface{} `json:"schema_config"`
+			UpdateMask   segment.UpdateMask     `json:"update_mask"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		if _, i := w.source(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		var current map[string]interface{}
+		data, _ := json.Marshal(w.SchemaConfigs[parts[1]])
+		json.Unmarshal(data, &current)
+		for _, p := range req.UpdateMask.Paths {
+			field := strings.TrimPrefix(p, "schema_config.")
+			current[field] = req.SchemaConfig[field]
+		}
+		var sc segment.SourceSchemaConfig
+		data, _ = json.Marshal(current)
+		json.Unmarshal(data, &sc)
+		sc.Name = fmt.Sprintf("workspaces/%s/sources/%s/schema-config", workspace, parts[1])
+		w.SchemaConfigs[parts[1]] = sc
+		return sc, http.StatusOK
+
+	case "GET sources/*/destinations":
+		if _, i := w.source(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		return segment.Destinations{Destinations: w.Destinations[parts[1]]}, http.StatusOK
+	case "POST sources/*/destinations":
+		var req struct {
+			Destination segment.Destination `json:"destination"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		if _, i := w.source(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		slug := segment.Slug(req.Destination.Name)
+		if _, i := w.destination(parts[1], slug); i >= 0 {
+			return nil, http.StatusBadRequest
+		}
+		return s.addDestination(w, workspace, parts[1], req.Destination), http.StatusOK
+	case "GET sources/*/destinations/*":
+		d, i := w.destination(parts[1], parts[3])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		return d, http.StatusOK
+	case "PATCH sources/*/destinations/*":
+		var req struct {
+			Destination segment.Destination `json:"destination"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		d, i := w.destination(parts[1], parts[3])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		now := s.Now()
+		d.Enabled = req.Destination.Enabled
+		d.Configs = req.Destination.Configs
+		d.UpdateTime = &now
+		w.Destinations[parts[1]][i] = d
+		return d, http.StatusOK
+	case "DELETE sources/*/destinations/*":
+		_, i := w.destination(parts[1], parts[3])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		dests := w.Destinations[parts[1]]
+		w.Destinations[parts[1]] = append(dests[:i:i], dests[i+1:]...)
+		return nil, http.StatusOK
+
+	case "GET tracking-plans":
+		return segment.TrackingPlans{TrackingPlans: w.TrackingPlans}, http.StatusOK
+	case "POST tracking-plans":
+		var req struct {
+			TrackingPlan segment.TrackingPlan `json:"tracking_plan"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		now := s.Now()
+		tp := req.TrackingPlan
+		tp.Name = fmt.Sprintf("workspaces/%s/tracking-plans/rs_%d", workspace, s.nextPlanID)
+		tp.CreateTime = &now
+		tp.UpdateTime = &now
+		s.nextPlanID++
+		w.TrackingPlans = append(w.TrackingPlans, tp)
+		return tp, http.StatusOK
+	case "GET tracking-plans/*":
+		tp, i := w.trackingPlan(parts[1])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		return tp, http.StatusOK
+	case "PUT tracking-plans/*":
+		var req struct {
+			TrackingPlan segment.TrackingPlan `json:"tracking_plan"`
+			UpdateMask   segment.UpdateMask   `json:"update_mask"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		tp, i := w.trackingPlan(parts[1])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		for _, p := range req.UpdateMask.Paths {
+			switch p {
+			case segment.TrackingPlanDisplayNamePath:
+				tp.DisplayName = req.TrackingPlan.DisplayName
+			case segment.TrackingPlanRulesEventsPath:
+				tp.Rules.Events = req.TrackingPlan.Rules.Events
+			case segment.TrackingPlanRulesGlobalPath:
+				tp.Rules.Global = req.TrackingPlan.Rules.Global
+			case segment.TrackingPlanRulesIdentifyPath:
+				tp.Rules.Identify = req.TrackingPlan.Rules.Identify
+			case segment.TrackingPlanRulesGroupPath:
+				tp.Rules.Group = req.TrackingPlan.Rules.Group
+			}
+		}
+		now := s.Now()
+		tp.UpdateTime = &now
+		w.TrackingPlans[i] = tp
+		return tp, http.StatusOK
+	case "DELETE tracking-plans/*":
+		_, i := w.trackingPlan(parts[1])
+		if i < 0 {
+			return nil, http.StatusNotFound
+		}
+		w.TrackingPlans = append(w.TrackingPlans[:i:i], w.TrackingPlans[i+1:]...)
+		for src, plan := range w.Connections {
+			if plan == parts[1] {
+				delete(w.Connections, src)
+			}
+		}
+		return nil, http.StatusOK
+
+	case "GET tracking-plans/*/source-connections":
+		if _, i := w.trackingPlan(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		var sources []string
+		for src, plan := range w.Connections {
+			if plan == parts[1] {
+				sources = append(sources, src)
+			}
+		}
+		sort.Strings(sources)
+		var conns segment.TrackingPlanSourceConnections
+		for _, src := range sources {
+			conns.Connections = append(conns.Connections, segment.TrackingPlanSourceConnection{
+				SourceName:     fmt.Sprintf("workspaces/%s/sources/%s", workspace, src),
+				TrackingPlanID: parts[1],
+			})
+		}
+		return conns, http.StatusOK
+	case "POST tracking-plans/*/source-connections":
+		var req segment.TrackingPlanSourceConnection
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, http.StatusBadRequest
+		}
+		src := segment.Slug(req.SourceName)
+		if _, i := w.trackingPlan(parts[1]); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		if _, i := w.source(src); i < 0 {
+			return nil, http.StatusNotFound
+		}
+		if _, ok := w.Connections[src]; ok {
+			return nil, http.StatusBadRequest
+		}
+		w.Connections[src] = parts[1]
+		return segment.TrackingPlanSourceConnection{
+			SourceName:     fmt.Sprintf("workspaces/%s/sources/%s", workspace, src),
+			TrackingPlanID: parts[1],
+		}, http.StatusOK
+	case "DELETE tracking-plans/*/source-connections/*":
+		if w.Connections[parts[3]] != parts[1] {
+			return nil, http.StatusNotFound
+		}
+		delete(w.Connections, parts[3])
+		return nil, http.StatusOK
+	}
+	return nil, http.StatusNotFound
+}
+
+// pattern replaces the identifiers of a path with *, e.g. sources/js/destinations becomes
+// sources/*/destinations
+func pattern(parts []string) string {
+	p := make([]string, len(parts))
+	for i, part := range parts {
+		if i%2 == 1 {
+			part = "*"
+		}
+		p[i] = part
+	}
+	return strings.Join(p, "/")
+}
+
+func (w *Workspace) source(slug string) (segment.Source, int) {
+	for i, src := range w.Sources {
+		if strings.HasSuffix(src.Name, "/"+slug) {
+			return src, i
+		}
+	}
+	return segment.Source{}, -1
+}
+
+func (w *Workspace) destination(source, slug string) (segment.Destination, int) {
+	for i, d := range w.Destinations[source] {
+		if strings.HasSuffix(d.Name, "/"+slug) {
+			return d, i
+		}
+	}
+	return segment.Destination{}, -1
+}
+
+func (w *Workspace) trackingPlan(id string) (segment.TrackingPlan, int) {
+	for i, tp := range w.TrackingPlans {
+		if strings.HasSuffix(tp.Name, "/"+id) {
+			return tp, i
+		}
+	}
+	return segment.TrackingPlan{}, -1
+}
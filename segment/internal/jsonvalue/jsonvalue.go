@@ -0,0 +1,103 @@
+// Package jsonvalue has helpers for values decoded from JSON and YAML, shared by package segment and
+// its subpackages. It must not import package segment.
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Float returns the value of a number of any of the types JSON and YAML decoders produce, and
+// whether v is a number
+func Float(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Type returns the JSON Schema type of a decoded value, one of the segment.Type constants. As in
+// draft-07, numbers without a fractional part are integers. Values JSON cannot hold are named by
+// their Go type.
+func Type(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if f, ok := Float(v); ok {
+		if f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Normalize converts a value decoded from YAML to the value encoding/json would have decoded, so
+// that numbers become float64 and maps have string keys
+func Normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			m[fmt.Sprint(k)] = Normalize(child)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			m[k] = Normalize(child)
+		}
+		return m
+	case []interface{}:
+		if val == nil {
+			return val
+		}
+		values := make([]interface{}, len(val))
+		for i, child := range val {
+			values[i] = Normalize(child)
+		}
+		return values
+	case json.Number:
+		return val
+	}
+	if f, ok := Float(v); ok {
+		return f
+	}
+	return v
+}
+
+// StringsEqual reports whether two string lists, such as type names or required properties, hold
+// the same strings in the same order
+func StringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat(t *testing.T) {
+	for _, v := range []interface{}{1.5, float32(1.5), json.Number("1.5")} {
+		f, ok := Float(v)
+		assert.True(t, ok, "%T", v)
+		assert.Equal(t, 1.5, f)
+	}
+	for _, v := range []interface{}{2, int64(2), uint64(2)} {
+		f, ok := Float(v)
+		assert.True(t, ok, "%T", v)
+		assert.Equal(t, 2.0, f)
+	}
+	for _, v := range []interface{}{"1", nil, json.Number("x")} {
+		_, ok := Float(v)
+		assert.False(t, ok, "%v", v)
+	}
+}
+
+func TestType(t *testing.T) {
+	for v, expected := range map[interface{}]string{
+		nil:                "null",
+		true:               "boolean",
+		"1":                "string",
+		1.0:                "integer",
+		json.Number("2.0"): "integer",
+		json.Number("1e3"): "integer",
+		1.5:                "number",
+		json.Number("1.5"): "number",
+		3:                  "integer",
+	} {
+		assert.Equal(t, expected, Type(v), "%#v", v)
+	}
+	assert.Equal(t, "object", Type(map[string]interface{}{}))
+	assert.Equal(t, "array", Type([]interface{}{}))
+	assert.Equal(t, "[]string", Type([]string{}))
+}
+
+func TestNormalize(t *testing.T) {
+	v := Normalize(map[interface{}]interface{}{
+		"ids":    []interface{}{1, uint64(2), map[interface{}]interface{}{3: true}},
+		"ratio":  float32(0.5),
+		"number": json.Number("7"),
+		"none":   []interface{}(nil),
+	})
+	assert.Equal(t, map[string]interface{}{
+		"ids":    []interface{}{1.0, 2.0, map[string]interface{}{"3": true}},
+		"ratio":  0.5,
+		"number": json.Number("7"),
+		"none":   []interface{}(nil),
+	}, v)
+}
+
+func TestStringsEqual(t *testing.T) {
+	assert.True(t, StringsEqual(nil, []string{}))
+	assert.True(t, StringsEqual([]string{"a", "b"}, []string{"a", "b"}))
+	assert.False(t, StringsEqual([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, StringsEqual([]string{"a"}, []string{"a", "b"}))
+}
@@ -0,0 +1,205 @@
+// Package inventory lists every destination instance of a Segment workspace for audits, with the
+// source it belongs to, its state and the tracking plan in use, and flags the destinations that
+// need attention. Inventories are written as CSV, JSON or Markdown reports.
+package inventory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Defaults of the crawl options
+const (
+	DefaultParallelism  = 4
+	DefaultDisabledDays = 30
+)
+
+// Row is a destination instance of the inventory
+type Row struct {
+	Source        string `json:"source"`
+	SourceCatalog string `json:"source_catalog"`
+	// Destination is the slug of the destination, which is its catalog type
+	Destination    string     `json:"destination"`
+	DisplayName    string     `json:"display_name"`
+	Enabled        bool       `json:"enabled"`
+	ConnectionMode string     `json:"connection_mode"`
+	CreateTime     *time.Time `json:"create_time"`
+	UpdateTime     *time.Time `json:"update_time"`
+	// TrackingPlan is the display name of the tracking plan of the source, if any
+	TrackingPlan   string `json:"tracking_plan"`
+	TrackingPlanID string `json:"tracking_plan_id"`
+
+	// NeverUpdated is set when the destination was not changed since it was created
+	NeverUpdated bool `json:"never_updated"`
+	// DisabledTooLong is set when the destination is disabled and was last changed more than the
+	// DisabledDays of the crawl ago
+	DisabledTooLong bool `json:"disabled_too_long"`
+	// NoTrackingPlan is set when the source of the destination is not connected to a tracking plan
+	NoTrackingPlan bool `json:"no_tracking_plan"`
+}
+
+// Inventory lists the destination instances of a workspace, ordered by source and destination
+type Inventory struct {
+	Workspace   string    `json:"workspace"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// DisabledDays is the number of days after which disabled destinations are flagged
+	DisabledDays int   `json:"disabled_days"`
+	Rows         []Row `json:"rows"`
+}
+
+// Options controls a crawl
+type Options struct {
+	// Parallelism is the maximum number of concurrent requests, DefaultParallelism when zero
+	Parallelism int
+	// DisabledDays is the number of days after which disabled destinations are flagged,
+	// DefaultDisabledDays when zero
+	DisabledDays int
+	// Now returns the time the inventory is taken at, time.Now when nil
+	Now func() time.Time
+}
+
+func (o Options) withDefaults() Options {
+	if o.Parallelism <= 0 {
+		o.Parallelism = DefaultParallelism
+	}
+	if o.DisabledDays <= 0 {
+		o.DisabledDays = DefaultDisabledDays
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// Crawl lists the destinations of every source and the source connections of every tracking plan
+// of the workspace of a client, with at most opts.Parallelism requests at a time
+func Crawl(c *segment.Client, opts Options) (Inventory, error) {
+	opts = opts.withDefaults()
+	inv := Inventory{DisabledDays: opts.DisabledDays}
+
+	w, err := c.GetWorkspace()
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to get workspace")
+	}
+	inv.Workspace = segment.Slug(w.Name)
+
+	sources, err := c.ListSources()
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to list sources")
+	}
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to list tracking plans")
+	}
+
+	// Each task fills its own slot, so that results keep the order of the sources and plans
+	destinations := make([][]segment.Destination, len(sources.Sources))
+	connections := make([][]segment.TrackingPlanSourceConnection, len(plans.TrackingPlans))
+	var tasks []func() error
+	for i, src := range sources.Sources {
+		i, name := i, segment.Slug(src.Name)
+		tasks = append(tasks, func() error {
+			dests, err := c.ListDestinations(name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list destinations of source %s", name)
+			}
+			destinations[i] = dests.Destinations
+			return nil
+		})
+	}
+	for i, p := range plans.TrackingPlans {
+		i, name := i, segment.Slug(p.Name)
+		tasks = append(tasks, func() error {
+			conns, err := c.ListTrackingPlanSourceConnections(name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list source connections of tracking plan %s", name)
+			}
+			connections[i] = conns.Connections
+			return nil
+		})
+	}
+	if err := run(tasks, opts.Parallelism); err != nil {
+		return inv, err
+	}
+
+	planOf := map[string]segment.TrackingPlan{}
+	for i, conns := range connections {
+		for _, conn := range conns {
+			planOf[segment.Slug(conn.SourceName)] = plans.TrackingPlans[i]
+		}
+	}
+	now := opts.Now()
+	inv.GeneratedAt = now
+	for i, src := range sources.Sources {
+		name := segment.Slug(src.Name)
+		plan, connected := planOf[name]
+		for _, d := range destinations[i] {
+			r := Row{
+				Source:         name,
+				SourceCatalog:  segment.Slug(src.CatalogName),
+				Destination:    segment.Slug(d.Name),
+				DisplayName:    d.DisplayName,
+				Enabled:        d.Enabled,
+				ConnectionMode: d.ConnectionMode,
+				CreateTime:     d.CreateTime,
+				UpdateTime:     d.UpdateTime,
+				NoTrackingPlan: !connected,
+			}
+			if connected {
+				r.TrackingPlan = plan.DisplayName
+				r.TrackingPlanID = segment.Slug(plan.Name)
+			}
+			r.NeverUpdated = r.UpdateTime == nil || (r.CreateTime != nil && r.UpdateTime.Equal(*r.CreateTime))
+			if changed := lastChange(d); !d.Enabled && changed != nil {
+				r.DisabledTooLong = now.Sub(*changed) > time.Duration(opts.DisabledDays)*24*time.Hour
+			}
+			inv.Rows = append(inv.Rows, r)
+		}
+	}
+	sort.SliceStable(inv.Rows, func(i, j int) bool {
+		if inv.Rows[i].Source != inv.Rows[j].Source {
+			return inv.Rows[i].Source < inv.Rows[j].Source
+		}
+		return inv.Rows[i].Destination < inv.Rows[j].Destination
+	})
+	return inv, nil
+}
+
+// lastChange returns the time a destination was last changed. The Config API does not tell when a
+// destination was disabled, so this is the latest time it could have been.
+func lastChange(d segment.Destination) *time.Time {
+	if d.UpdateTime != nil {
+		return d.UpdateTime
+	}
+	return d.CreateTime
+}
+
+// run runs tasks with at most parallelism of them at a time, and returns the error of the first
+// task that failed in the order they are given
+func run(tasks []func() error, parallelism int) error {
+	errs := make([]error, len(tasks))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, task func() error) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = task()
+		}(i, task)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
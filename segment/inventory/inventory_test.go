@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = fakeapi.DefaultTime.Add(60 * 24 * time.Hour)
+
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddDestination("ws", "js", "mixpanel", false)
+	api.AddDestination("ws", "js", "google-analytics", true)
+	api.AddDestination("ws", "ios", "mixpanel", false)
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", segment.Rules{})
+	api.Connect("ws", "js", "rs_1")
+
+	// the ios destination was disabled yesterday
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		updated := now.Add(-24 * time.Hour)
+		w.Destinations["ios"][0].UpdateTime = &updated
+	})
+	return api
+}
+
+func TestCrawl(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	inv, err := Crawl(api.Client("ws"), Options{Now: func() time.Time { return now }})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "ws", inv.Workspace)
+	assert.Equal(t, now, inv.GeneratedAt)
+	assert.Equal(t, DefaultDisabledDays, inv.DisabledDays)
+	if !assert.Len(t, inv.Rows, 3) {
+		return
+	}
+
+	created := fakeapi.DefaultTime
+	updated := now.Add(-24 * time.Hour)
+	assert.Equal(t, Row{
+		Source: "ios", SourceCatalog: "ios", Destination: "mixpanel", DisplayName: "mixpanel",
+		ConnectionMode: "CLOUD", CreateTime: &created, UpdateTime: &updated, NoTrackingPlan: true,
+	}, inv.Rows[0])
+	assert.Equal(t, Row{
+		Source: "js", SourceCatalog: "javascript", Destination: "google-analytics", DisplayName: "google-analytics",
+		Enabled: true, ConnectionMode: "CLOUD", CreateTime: &created, UpdateTime: &created,
+		TrackingPlan: "Kicks App", TrackingPlanID: "rs_1", NeverUpdated: true,
+	}, inv.Rows[1])
+	assert.Equal(t, "mixpanel", inv.Rows[2].Destination)
+	assert.True(t, inv.Rows[2].DisabledTooLong)
+	assert.True(t, inv.Rows[2].NeverUpdated)
+
+	inv, err = Crawl(api.Client("ws"), Options{DisabledDays: 90, Now: func() time.Time { return now }})
+	assert.NoError(t, err)
+	assert.False(t, inv.Rows[2].DisabledTooLong)
+}
+
+func TestCrawl_Error(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	api.Fail("GET", "sources/ios/destinations")
+
+	_, err := Crawl(api.Client("ws"), Options{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to list destinations of source ios")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var running, max int32
+	var mu sync.Mutex
+	var done []int
+	var tasks []func() error
+	for i := 0; i < 20; i++ {
+		i := i
+		tasks = append(tasks, func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			mu.Lock()
+			done = append(done, i)
+			mu.Unlock()
+			if i == 7 || i == 3 {
+				return fmt.Errorf("task %d failed", i)
+			}
+			return nil
+		})
+	}
+	err := run(tasks, 3)
+	assert.EqualError(t, err, "task 3 failed", "the first error in task order is returned")
+	assert.Len(t, done, 20, "every task runs")
+	assert.True(t, max <= 3, "at most 3 tasks run at a time, got %d", max)
+}
@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column is a column of the CSV and Markdown reports
+type column struct {
+	header string
+	value  func(r Row) string
+}
+
+var columns = []column{
+	{"source", func(r Row) string { return r.Source }},
+	{"source_catalog", func(r Row) string { return r.SourceCatalog }},
+	{"destination", func(r Row) string { return r.Destination }},
+	{"display_name", func(r Row) string { return r.DisplayName }},
+	{"enabled", func(r Row) string { return strconv.FormatBool(r.Enabled) }},
+	{"connection_mode", func(r Row) string { return r.ConnectionMode }},
+	{"create_time", func(r Row) string { return formatTime(r.CreateTime) }},
+	{"update_time", func(r Row) string { return formatTime(r.UpdateTime) }},
+	{"tracking_plan", func(r Row) string { return r.TrackingPlan }},
+	{"tracking_plan_id", func(r Row) string { return r.TrackingPlanID }},
+	{"never_updated", func(r Row) string { return strconv.FormatBool(r.NeverUpdated) }},
+	{"disabled_too_long", func(r Row) string { return strconv.FormatBool(r.DisabledTooLong) }},
+	{"no_tracking_plan", func(r Row) string { return strconv.FormatBool(r.NoTrackingPlan) }},
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteCSV writes the rows of the inventory as CSV with a header line
+func (inv Inventory) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, r := range inv.Rows {
+		for i, c := range columns {
+			record[i] = c.value(r)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the inventory as indented JSON
+func (inv Inventory) WriteJSON(w io.Writer) error {
+	if inv.Rows == nil {
+		inv.Rows = []Row{}
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Summary counts the rows of the inventory that are flagged by each derived column
+type Summary struct {
+	Destinations    int
+	Disabled        int
+	NeverUpdated    int
+	DisabledTooLong int
+	NoTrackingPlan  int
+}
+
+// Summary returns the counts of the inventory
+func (inv Inventory) Summary() Summary {
+	s := Summary{Destinations: len(inv.Rows)}
+	for _, r := range inv.Rows {
+		if !r.Enabled {
+			s.Disabled++
+		}
+		if r.NeverUpdated {
+			s.NeverUpdated++
+		}
+		if r.DisabledTooLong {
+			s.DisabledTooLong++
+		}
+		if r.NoTrackingPlan {
+			s.NoTrackingPlan++
+		}
+	}
+	return s
+}
+
+// WriteMarkdown writes the inventory as a Markdown report with a summary and a table of the rows
+func (inv Inventory) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Destination inventory of %s\n\n", markdownEscape(inv.Workspace))
+	fmt.Fprintf(&b, "Generated at %s.\n\n", inv.GeneratedAt.UTC().Format(time.RFC3339))
+
+	s := inv.Summary()
+	fmt.Fprintf(&b, "- Destinations: %d\n", s.Destinations)
+	fmt.Fprintf(&b, "- Disabled: %d\n", s.Disabled)
+	fmt.Fprintf(&b, "- Never updated: %d\n", s.NeverUpdated)
+	fmt.Fprintf(&b, "- Disabled for more than %d days: %d\n", inv.DisabledDays, s.DisabledTooLong)
+	fmt.Fprintf(&b, "- Source has no tracking plan: %d\n", s.NoTrackingPlan)
+
+	if len(inv.Rows) == 0 {
+		b.WriteString("\nNo destinations.\n")
+	} else {
+		headers := make([]string, len(columns))
+		separators := make([]string, len(columns))
+		for i, c := range columns {
+			headers[i] = c.header
+			separators[i] = "---"
+		}
+		fmt.Fprintf(&b, "\n| %s |\n| %s |\n", strings.Join(headers, " | "), strings.Join(separators, " | "))
+		values := make([]string, len(columns))
+		for _, r := range inv.Rows {
+			for i, c := range columns {
+				values[i] = markdownEscape(c.value(r))
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(values, " | "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscape keeps a value on one line and from breaking tables
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
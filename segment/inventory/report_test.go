@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testInventory() Inventory {
+	created := time.Date(2019, 2, 5, 0, 28, 31, 0, time.UTC)
+	return Inventory{
+		Workspace:    "ws",
+		GeneratedAt:  created.Add(60 * 24 * time.Hour),
+		DisabledDays: 30,
+		Rows: []Row{
+			{
+				Source: "js", SourceCatalog: "javascript", Destination: "google-analytics", DisplayName: "Google | Analytics",
+				Enabled: true, ConnectionMode: "CLOUD", CreateTime: &created, UpdateTime: &created,
+				TrackingPlan: "Kicks App", TrackingPlanID: "rs_1", NeverUpdated: true,
+			},
+			{
+				Source: "ios", SourceCatalog: "ios", Destination: "mixpanel", DisplayName: "Mixpanel",
+				ConnectionMode: "DEVICE", CreateTime: &created, DisabledTooLong: true, NoTrackingPlan: true,
+			},
+		},
+	}
+}
+
+func TestInventory_WriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, testInventory().WriteCSV(&b))
+	expected := `source,source_catalog,destination,display_name,enabled,connection_mode,create_time,update_time,tracking_plan,tracking_plan_id,never_updated,disabled_too_long,no_tracking_plan
+js,javascript,google-analytics,Google | Analytics,true,CLOUD,2019-02-05T00:28:31Z,2019-02-05T00:28:31Z,Kicks App,rs_1,true,false,false
+ios,ios,mixpanel,Mixpanel,false,DEVICE,2019-02-05T00:28:31Z,,,,false,true,true
+`
+	assert.Equal(t, expected, b.String())
+}
+
+func TestInventory_WriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	inv := testInventory()
+	assert.NoError(t, inv.WriteJSON(&b))
+	var decoded Inventory
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.Equal(t, inv.Rows[1], decoded.Rows[1])
+	assert.Contains(t, b.String(), `"update_time": null`)
+
+	b.Reset()
+	assert.NoError(t, Inventory{}.WriteJSON(&b))
+	assert.Contains(t, b.String(), `"rows": []`)
+}
+
+func TestInventory_WriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, testInventory().WriteMarkdown(&b))
+	expected := `# Destination inventory of ws
+
+Generated at 2019-04-06T00:28:31Z.
+
+- Destinations: 2
+- Disabled: 1
+- Never updated: 1
+- Disabled for more than 30 days: 1
+- Source has no tracking plan: 1
+
+| source | source_catalog | destination | display_name | enabled | connection_mode | create_time | update_time | tracking_plan | tracking_plan_id | never_updated | disabled_too_long | no_tracking_plan |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| js | javascript | google-analytics | Google \| Analytics | true | CLOUD | 2019-02-05T00:28:31Z | 2019-02-05T00:28:31Z | Kicks App | rs_1 | true | false | false |
+| ios | ios | mixpanel | Mixpanel | false | DEVICE | 2019-02-05T00:28:31Z |  |  |  | false | true | true |
+`
+	assert.Equal(t, expected, b.String())
+
+	b.Reset()
+	assert.NoError(t, Inventory{Workspace: "ws"}.WriteMarkdown(&b))
+	assert.Contains(t, b.String(), "\nNo destinations.\n")
+}
@@ -0,0 +1,278 @@
+// Package lint checks tracking plans against naming and quality conventions. Rules are pluggable,
+// every rule has a severity that can be overridden, and findings can be suppressed inline with the
+// lint-ignore label on an event or property.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+// Severities, from most to least serious
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+	Off     Severity = "off"
+)
+
+var severityRank = map[Severity]int{Error: 3, Warning: 2, Info: 1, Off: 0}
+
+// AtLeast reports whether s is as serious as min
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// Sections of tracking plan rules
+const (
+	SectionEvent    = "event"
+	SectionGlobal   = "global"
+	SectionIdentify = "identify"
+	SectionGroup    = "group"
+)
+
+// IgnoreLabel is the label that suppresses findings on an event or property and everything below it.
+// Its value is a comma separated list of rule names, or "all".
+const IgnoreLabel = "lint-ignore"
+
+// Target is an event or property being linted
+type Target struct {
+	Section string
+	// Event is the event being linted, or the event the property belongs to
+	Event *segment.Event
+	// Path is the path of the property, e.g. properties.product or properties.tags[], and is
+	// empty when the target is an event
+	Path string
+	// Name is the name of the property, and is empty for events and array items
+	Name     string
+	Rule     segment.Rule
+	Required bool
+}
+
+// IsEvent reports whether the target is an event rather than a property
+func (t Target) IsEvent() bool {
+	return t.Path == ""
+}
+
+// Rule checks a single convention
+type Rule interface {
+	// Name identifies the rule in findings, severities and suppressions
+	Name() string
+	// Severity is the default severity of the findings of the rule
+	Severity() Severity
+	// Check returns a message for every problem found on the target
+	Check(t Target) []string
+}
+
+type funcRule struct {
+	name     string
+	severity Severity
+	check    func(Target) []string
+}
+
+func (r funcRule) Name() string            { return r.name }
+func (r funcRule) Severity() Severity      { return r.severity }
+func (r funcRule) Check(t Target) []string { return r.check(t) }
+
+// NewRule returns a rule from a check function
+func NewRule(name string, severity Severity, check func(t Target) []string) Rule {
+	return funcRule{name: name, severity: severity, check: check}
+}
+
+// Config selects the rules to run and their severities
+type Config struct {
+	Rules []Rule
+	// Severities overrides the default severity of rules by name. Rules set to Off are skipped.
+	Severities map[string]Severity
+}
+
+// DefaultConfig returns a config running every built-in rule with its default severity
+func DefaultConfig() Config {
+	return Config{Rules: DefaultRules()}
+}
+
+func (c Config) severity(r Rule) Severity {
+	if s, ok := c.Severities[r.Name()]; ok {
+		return s
+	}
+	return r.Severity()
+}
+
+// Finding is a single problem found by a rule
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Section  string   `json:"section"`
+	Event    string   `json:"event,omitempty"`
+	Version  int      `json:"version,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	where := f.Section
+	if f.Event != "" {
+		where = fmt.Sprintf("event %q", f.Event)
+		if f.Version > 0 {
+			where += fmt.Sprintf(" v%d", f.Version)
+		}
+	}
+	if f.Path != "" {
+		where += " " + f.Path
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, where, f.Message, f.Rule)
+}
+
+// Result contains the findings of a lint run
+type Result struct {
+	Errors     int       `json:"errors"`
+	Warnings   int       `json:"warnings"`
+	Infos      int       `json:"infos"`
+	Suppressed int       `json:"suppressed"`
+	Findings   []Finding `json:"findings,omitempty"`
+}
+
+// Failed reports whether any finding is at least as serious as min
+func (r Result) Failed(min Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(min) {
+			return true
+		}
+	}
+	return false
+}
+
+// LintTrackingPlan lints the rules of a tracking plan
+func LintTrackingPlan(plan segment.TrackingPlan, cfg Config) Result {
+	return Lint(plan.Rules, cfg)
+}
+
+// Lint checks tracking plan rules against the rules of a config
+func Lint(rules segment.Rules, cfg Config) Result {
+	l := &linter{cfg: cfg}
+	events := append([]segment.Event(nil), rules.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		return events[i].Version < events[j].Version
+	})
+	for i := range events {
+		e := &events[i]
+		ignored := ignoredRules(nil, e.Rules.Labels)
+		l.check(Target{Section: SectionEvent, Event: e, Rule: e.Rules}, ignored)
+		l.walkEnvelope(SectionEvent, e, e.Rules, ignored)
+	}
+	for _, s := range []struct {
+		name string
+		rule segment.Rule
+	}{{SectionGlobal, rules.Global}, {SectionIdentify, rules.Identify}, {SectionGroup, rules.Group}} {
+		l.walkEnvelope(s.name, nil, s.rule, ignoredRules(nil, s.rule.Labels))
+	}
+	return l.result
+}
+
+type linter struct {
+	cfg    Config
+	result Result
+}
+
+// walkEnvelope lints the properties of the context, traits and properties sections of a rule
+func (l *linter) walkEnvelope(section string, e *segment.Event, r segment.Rule, ignored map[string]bool) {
+	for _, name := range sortedKeys(r.Properties) {
+		s := r.Properties[name]
+		l.walkProperties(section, e, name, s, ignoredRules(ignored, s.Labels))
+	}
+}
+
+func (l *linter) walkProperties(section string, e *segment.Event, path string, r segment.Rule, ignored map[string]bool) {
+	for _, name := range sortedKeys(r.Properties) {
+		child := r.Properties[name]
+		t := Target{
+			Section:  section,
+			Event:    e,
+			Path:     path + "." + name,
+			Name:     name,
+			Rule:     child,
+			Required: r.IsRequired(name),
+		}
+		l.walk(t, ignoredRules(ignored, child.Labels))
+	}
+}
+
+func (l *linter) walk(t Target, ignored map[string]bool) {
+	l.check(t, ignored)
+	l.walkProperties(t.Section, t.Event, t.Path, t.Rule, ignored)
+	if t.Rule.Items != nil {
+		items := *t.Rule.Items
+		l.walk(Target{Section: t.Section, Event: t.Event, Path: t.Path + "[]", Rule: items}, ignoredRules(ignored, items.Labels))
+	}
+}
+
+func (l *linter) check(t Target, ignored map[string]bool) {
+	for _, r := range l.cfg.Rules {
+		severity := l.cfg.severity(r)
+		if severity == Off {
+			continue
+		}
+		for _, msg := range r.Check(t) {
+			if ignored["all"] || ignored[r.Name()] {
+				l.result.Suppressed++
+				continue
+			}
+			f := Finding{Rule: r.Name(), Severity: severity, Section: t.Section, Path: t.Path, Message: msg}
+			if t.Event != nil {
+				f.Event = t.Event.Name
+				f.Version = t.Event.Version
+			}
+			switch severity {
+			case Error:
+				l.result.Errors++
+			case Warning:
+				l.result.Warnings++
+			case Info:
+				l.result.Infos++
+			}
+			l.result.Findings = append(l.result.Findings, f)
+		}
+	}
+}
+
+// ignoredRules adds the rules suppressed by the lint-ignore label to those already suppressed
+func ignoredRules(parent map[string]bool, labels map[string]interface{}) map[string]bool {
+	var names []string
+	switch v := labels[IgnoreLabel].(type) {
+	case string:
+		names = strings.Split(v, ",")
+	case []interface{}:
+		for _, n := range v {
+			names = append(names, fmt.Sprint(n))
+		}
+	}
+	if len(names) == 0 {
+		return parent
+	}
+	ignored := map[string]bool{}
+	for name := range parent {
+		ignored[name] = true
+	}
+	for _, name := range names {
+		ignored[strings.TrimSpace(name)] = true
+	}
+	return ignored
+}
+
+func sortedKeys(m map[string]segment.Rule) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRules() segment.Rules {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Description("An order was placed").
+		Prop("order_id", segment.String().Required().Description("Order identifier")).
+		Prop("payment_method", segment.String().Description("How the order was paid")).
+		Prop("Items", segment.Array(segment.Any()).Description("Items in the order"))
+	tp.Event("checkout_started").
+		Prop("step", segment.Integer().Description("Checkout step"))
+	tp.Identify().Trait("email", segment.String())
+	return tp.Rules()
+}
+
+func TestLint_Default(t *testing.T) {
+	r := Lint(testRules(), DefaultConfig())
+
+	expected := []Finding{
+		{Rule: RulePropertyName, Severity: Error, Section: SectionEvent, Event: "Order Completed", Path: "properties.Items", Message: `property name "Items" is not snake_case`},
+		{Rule: RuleNoAnyType, Severity: Error, Section: SectionEvent, Event: "Order Completed", Path: "properties.Items[]", Message: "property accepts any type"},
+		{Rule: RuleCategoricalEnum, Severity: Info, Section: SectionEvent, Event: "Order Completed", Path: "properties.payment_method", Message: `string property "payment_method" looks categorical but has no enum`},
+		{Rule: RuleEventName, Severity: Error, Section: SectionEvent, Event: "checkout_started", Message: `event name "checkout_started" is not in "Object Action" title case`},
+		{Rule: RuleEventDescription, Severity: Warning, Section: SectionEvent, Event: "checkout_started", Message: "event has no description"},
+		{Rule: RulePropertyDescription, Severity: Warning, Section: SectionIdentify, Path: "traits.email", Message: "property has no description"},
+	}
+	assert.Equal(t, expected, r.Findings)
+	assert.Equal(t, 3, r.Errors)
+	assert.Equal(t, 2, r.Warnings)
+	assert.Equal(t, 1, r.Infos)
+	assert.True(t, r.Failed(Error))
+	assert.Equal(t, `error: event "Order Completed" properties.Items: property name "Items" is not snake_case (property-snake-case)`, r.Findings[0].String())
+	assert.Equal(t, `warning: identify traits.email: property has no description (property-description)`, r.Findings[5].String())
+}
+
+func TestLint_Severities(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Severities = map[string]Severity{
+		RulePropertyName: Off,
+		RuleNoAnyType:    Off,
+		RuleEventName:    Warning,
+	}
+	r := Lint(testRules(), cfg)
+	assert.Equal(t, 0, r.Errors)
+	assert.Equal(t, 3, r.Warnings)
+	assert.False(t, r.Failed(Error))
+	assert.True(t, r.Failed(Warning))
+}
+
+func TestLint_Suppressions(t *testing.T) {
+	tp := segment.NewRulesBuilder()
+	tp.Event("checkout_started").Label(IgnoreLabel, "event-name-object-action, event-description").
+		Prop("Step", segment.Integer().Description("Checkout step"))
+	tp.Event("Cart Viewed").Label(IgnoreLabel, "all").
+		Prop("Cart", segment.Object().Prop("ID", segment.Any()))
+	tp.Event("Product Viewed").Description("A product was viewed").
+		Prop("tags", segment.Array(segment.Any()).Description("Tags").Label(IgnoreLabel, []interface{}{RuleNoAnyType}))
+
+	r := Lint(tp.Rules(), DefaultConfig())
+	assert.Equal(t, []Finding{
+		{Rule: RulePropertyName, Severity: Error, Section: SectionEvent, Event: "checkout_started", Path: "properties.Step", Message: `property name "Step" is not snake_case`},
+	}, r.Findings)
+	assert.Equal(t, 9, r.Suppressed)
+}
+
+func TestLint_CustomRule(t *testing.T) {
+	noPII := NewRule("no-pii", Error, func(t Target) []string {
+		if t.Name == "email" {
+			return []string{"property may contain personal data"}
+		}
+		return nil
+	})
+	r := LintTrackingPlan(segment.TrackingPlan{Rules: testRules()}, Config{Rules: []Rule{noPII}})
+	assert.Equal(t, []Finding{
+		{Rule: "no-pii", Severity: Error, Section: SectionIdentify, Path: "traits.email", Message: "property may contain personal data"},
+	}, r.Findings)
+
+	b, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"errors":1,"warnings":0,"infos":0,"suppressed":0,"findings":[{"rule":"no-pii","severity":"error","section":"identify","path":"traits.email","message":"property may contain personal data"}]}`, string(b))
+}
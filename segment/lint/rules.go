@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+)
+
+// Names of the built-in rules
+const (
+	RuleEventName           = "event-name-object-action"
+	RulePropertyName        = "property-snake-case"
+	RuleEventDescription    = "event-description"
+	RulePropertyDescription = "property-description"
+	RuleNoAnyType           = "no-any-type"
+	RuleCategoricalEnum     = "categorical-enum"
+	RuleMaxProperties       = "max-properties"
+)
+
+// DefaultMaxProperties is the property limit of the max-properties rule in the default config
+const DefaultMaxProperties = 50
+
+// DefaultCategoricalNames are the property name suffixes the categorical-enum rule expects an enum for
+var DefaultCategoricalNames = []string{"category", "currency", "level", "method", "mode", "plan", "platform", "status", "tier", "type"}
+
+var (
+	objectActionPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*( [A-Z0-9][A-Za-z0-9]*)+$`)
+	snakeCasePattern    = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+)
+
+// DefaultRules returns every built-in rule with its default settings
+func DefaultRules() []Rule {
+	return []Rule{
+		EventNameObjectAction(),
+		PropertySnakeCase(),
+		EventDescription(),
+		PropertyDescription(),
+		NoAnyType(),
+		CategoricalEnum(DefaultCategoricalNames...),
+		MaxProperties(DefaultMaxProperties),
+	}
+}
+
+// EventNameObjectAction requires event names in "Object Action" title case, e.g. "Order Completed"
+func EventNameObjectAction() Rule {
+	return NewRule(RuleEventName, Error, func(t Target) []string {
+		if !t.IsEvent() || objectActionPattern.MatchString(t.Event.Name) {
+			return nil
+		}
+		return []string{fmt.Sprintf("event name %q is not in \"Object Action\" title case", t.Event.Name)}
+	})
+}
+
+// PropertySnakeCase requires property names in snake_case
+func PropertySnakeCase() Rule {
+	return NewRule(RulePropertyName, Error, func(t Target) []string {
+		if t.Name == "" || snakeCasePattern.MatchString(t.Name) {
+			return nil
+		}
+		return []string{fmt.Sprintf("property name %q is not snake_case", t.Name)}
+	})
+}
+
+// EventDescription requires every event to have a description
+func EventDescription() Rule {
+	return NewRule(RuleEventDescription, Warning, func(t Target) []string {
+		if !t.IsEvent() || strings.TrimSpace(t.Event.Description) != "" {
+			return nil
+		}
+		return []string{"event has no description"}
+	})
+}
+
+// PropertyDescription requires every property to have a description
+func PropertyDescription() Rule {
+	return NewRule(RulePropertyDescription, Warning, func(t Target) []string {
+		if t.Name == "" || strings.TrimSpace(t.Rule.Description) != "" {
+			return nil
+		}
+		return []string{"property has no description"}
+	})
+}
+
+// NoAnyType forbids properties and array items without a type
+func NoAnyType() Rule {
+	return NewRule(RuleNoAnyType, Error, func(t Target) []string {
+		if t.IsEvent() || t.Rule.Ref != "" || len(t.Rule.Type.NonNull()) > 0 {
+			return nil
+		}
+		return []string{"property accepts any type"}
+	})
+}
+
+// CategoricalEnum expects an enum on string properties whose name ends with one of the given
+// suffixes, as such values usually come from a small fixed set
+func CategoricalEnum(suffixes ...string) Rule {
+	return NewRule(RuleCategoricalEnum, Info, func(t Target) []string {
+		r := t.Rule
+		if t.Name == "" || !r.Type.Has(segment.TypeString) || len(r.Enum) > 0 || r.Pattern != "" || r.Format != "" {
+			return nil
+		}
+		for _, s := range suffixes {
+			if t.Name == s || strings.HasSuffix(t.Name, "_"+s) {
+				return []string{fmt.Sprintf("string property %q looks categorical but has no enum", t.Name)}
+			}
+		}
+		return nil
+	})
+}
+
+// MaxProperties limits the number of properties of an event, counting nested properties
+func MaxProperties(max int) Rule {
+	return NewRule(RuleMaxProperties, Warning, func(t Target) []string {
+		if !t.IsEvent() {
+			return nil
+		}
+		n := countProperties(t.Rule.Properties["properties"])
+		if n <= max {
+			return nil
+		}
+		return []string{fmt.Sprintf("event has %d properties, more than the maximum of %d", n, max)}
+	})
+}
+
+func countProperties(r segment.Rule) int {
+	n := len(r.Properties)
+	for _, child := range r.Properties {
+		n += countProperties(child)
+	}
+	if r.Items != nil {
+		n += countProperties(*r.Items)
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func eventTarget(name, description string, props ...string) Target {
+	e := segment.NewRulesBuilder().Event(name).Description(description)
+	for _, p := range props {
+		e.Prop(p, segment.String())
+	}
+	event := e.Event()
+	return Target{Section: SectionEvent, Event: &event, Rule: event.Rules}
+}
+
+func propertyTarget(name string, p *segment.PropertyBuilder) Target {
+	return Target{Section: SectionEvent, Path: "properties." + name, Name: name, Rule: p.Rule()}
+}
+
+func TestRules_EventName(t *testing.T) {
+	r := EventNameObjectAction()
+	assert.Empty(t, r.Check(eventTarget("Order Completed", "")))
+	assert.Empty(t, r.Check(eventTarget("Checkout Step 2 Viewed", "")))
+	assert.Len(t, r.Check(eventTarget("order completed", "")), 1)
+	assert.Len(t, r.Check(eventTarget("Completed", "")), 1)
+	assert.Len(t, r.Check(eventTarget("Order_Completed", "")), 1)
+	assert.Empty(t, r.Check(propertyTarget("Bad Name", segment.String())))
+}
+
+func TestRules_PropertyName(t *testing.T) {
+	r := PropertySnakeCase()
+	assert.Empty(t, r.Check(propertyTarget("order_id", segment.String())))
+	assert.Empty(t, r.Check(propertyTarget("step2", segment.String())))
+	assert.Len(t, r.Check(propertyTarget("orderId", segment.String())), 1)
+	assert.Len(t, r.Check(propertyTarget("order__id", segment.String())), 1)
+	assert.Len(t, r.Check(propertyTarget("_id", segment.String())), 1)
+}
+
+func TestRules_Descriptions(t *testing.T) {
+	assert.Len(t, EventDescription().Check(eventTarget("Order Completed", " ")), 1)
+	assert.Empty(t, EventDescription().Check(eventTarget("Order Completed", "Placed")))
+	assert.Len(t, PropertyDescription().Check(propertyTarget("sku", segment.String())), 1)
+	assert.Empty(t, PropertyDescription().Check(propertyTarget("sku", segment.String().Description("SKU"))))
+}
+
+func TestRules_NoAnyType(t *testing.T) {
+	r := NoAnyType()
+	assert.Len(t, r.Check(propertyTarget("value", segment.Any())), 1)
+	assert.Len(t, r.Check(propertyTarget("value", segment.Any().Nullable())), 1)
+	assert.Empty(t, r.Check(propertyTarget("value", segment.Number().Nullable())))
+}
+
+func TestRules_CategoricalEnum(t *testing.T) {
+	r := CategoricalEnum("status", "type")
+	assert.Len(t, r.Check(propertyTarget("order_status", segment.String())), 1)
+	assert.Len(t, r.Check(propertyTarget("type", segment.String())), 1)
+	assert.Empty(t, r.Check(propertyTarget("order_status", segment.String().Enum("open", "closed"))))
+	assert.Empty(t, r.Check(propertyTarget("prototype", segment.String())))
+	assert.Empty(t, r.Check(propertyTarget("status_code", segment.Integer())))
+}
+
+func TestRules_MaxProperties(t *testing.T) {
+	r := MaxProperties(2)
+	assert.Empty(t, r.Check(eventTarget("Order Completed", "", "a", "b")))
+	assert.Equal(t, []string{"event has 3 properties, more than the maximum of 2"},
+		r.Check(eventTarget("Order Completed", "", "a", "b", "c")))
+}
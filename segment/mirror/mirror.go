@@ -0,0 +1,155 @@
+// Package mirror compares two Segment workspaces that should mirror each other, such as a
+// development and a production workspace, and copies the differences from one to the other.
+//
+// Sources are matched by slug, destinations by catalog type within each source, and tracking plans
+// by display name. Settings whose values are specific to each workspace, such as API keys, are
+// described by override rules so that they are neither reported nor copied.
+package mirror
+
+import (
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/drift"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/pkg/errors"
+)
+
+// Rule overrides a destination setting whose value is specific to each workspace
+type Rule struct {
+	// Source is the slug of the source the rule applies to, or empty for every source
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+	// Destination is the slug of the destination the rule applies to, or empty for every destination
+	Destination string `yaml:"destination,omitempty" json:"destination,omitempty"`
+	// Setting is the name of the setting, e.g. apiKey
+	Setting string `yaml:"setting" json:"setting"`
+	// Value is the value of the setting in the target workspace. When nil, the setting is not
+	// compared and its value in the target workspace is kept.
+	Value interface{} `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+func (r Rule) matches(source, destination, setting string) bool {
+	return (r.Source == "" || r.Source == source) &&
+		(r.Destination == "" || r.Destination == destination) &&
+		r.Setting == setting
+}
+
+// Options controls a comparison
+type Options struct {
+	// Rules override destination settings. When several rules match a setting, the first one wins.
+	Rules []Rule
+	// IgnoreSecrets leaves the secret settings of destinations out of the comparison and of syncs,
+	// as if a rule without value matched them. Otherwise their differences are reported masked.
+	IgnoreSecrets bool
+}
+
+// rule returns the rule of a setting
+func (o Options) rule(source, destination string, c segment.DestinationConfig) (Rule, bool) {
+	setting := segment.Slug(c.Name)
+	for _, r := range o.Rules {
+		if r.matches(source, destination, setting) {
+			return r, true
+		}
+	}
+	if o.IgnoreSecrets && c.IsSecret() {
+		return Rule{Source: source, Destination: destination, Setting: setting}, true
+	}
+	return Rule{}, false
+}
+
+// Diff is the comparison of a workspace with the workspace it should mirror. Its report lists what
+// the target workspace is missing as missing, and what only exists in the target as unexpected.
+type Diff struct {
+	drift.Report
+	// desired is the state the target workspace should be in after a sync
+	desired spec.State
+	target  spec.State
+}
+
+// Compare compares the workspace of the to client with the workspace of the from client
+func Compare(from, to *segment.Client, opts Options) (Diff, error) {
+	fromState, err := spec.ReadState(from)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to read the workspace to mirror")
+	}
+	toState, err := spec.ReadState(to)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to read the target workspace")
+	}
+	return CompareStates(fromState, toState, opts), nil
+}
+
+// CompareStates compares the state of a target workspace with the state of the workspace it mirrors
+func CompareStates(from, to spec.State, opts Options) Diff {
+	desired := desiredState(from, to, opts)
+	return Diff{Report: drift.CheckState(desired, to), desired: desired, target: to}
+}
+
+// desiredState returns the state of the workspace to mirror with the override rules applied to the
+// settings of its destinations
+func desiredState(from, to spec.State, opts Options) spec.State {
+	desired := from
+	desired.Destinations = map[string][]segment.Destination{}
+	for src, dests := range from.Destinations {
+		for _, d := range dests {
+			name := segment.Slug(d.Name)
+			target, _ := to.Destination(src, name)
+			d.Configs = overrideConfigs(src, name, d, target.Configs, opts)
+			desired.Destinations[src] = append(desired.Destinations[src], d)
+		}
+	}
+	return desired
+}
+
+// overrideConfigs applies the rules to the settings of a destination. Settings with a rule value
+// take that value, and settings with a rule but no value take their value in the target workspace,
+// or are left out if the target does not have them.
+func overrideConfigs(src, name string, d segment.Destination, target []segment.DestinationConfig, opts Options) []segment.DestinationConfig {
+	targetValues := map[string]segment.DestinationConfig{}
+	for _, c := range target {
+		targetValues[segment.Slug(c.Name)] = c
+	}
+
+	var configs []segment.DestinationConfig
+	seen := map[string]bool{}
+	for _, c := range d.Configs {
+		setting := segment.Slug(c.Name)
+		seen[setting] = true
+		r, ok := opts.rule(src, name, c)
+		switch {
+		case !ok:
+			configs = append(configs, c)
+		case r.Value != nil:
+			c.Value = r.Value
+			configs = append(configs, c)
+		default:
+			if t, ok := targetValues[setting]; ok {
+				c.Value = t.Value
+				configs = append(configs, c)
+			}
+		}
+	}
+
+	// Overridden settings that only the target has are kept as they are
+	for _, c := range target {
+		setting := segment.Slug(c.Name)
+		if seen[setting] {
+			continue
+		}
+		if r, ok := opts.rule(src, name, c); ok {
+			seen[setting] = true
+			if r.Value != nil {
+				c.Value = r.Value
+			}
+			configs = append(configs, c)
+		}
+	}
+
+	// Rule values of settings that neither side has yet are set by the rules of this destination
+	for _, r := range opts.Rules {
+		if r.Value == nil || r.Source != src || r.Destination != name || seen[r.Setting] {
+			continue
+		}
+		seen[r.Setting] = true
+		configs = append(configs, segment.DestinationConfig{Name: d.Name + "/config/" + r.Setting, Value: r.Value})
+	}
+	return configs
+}
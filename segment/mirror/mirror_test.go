@@ -0,0 +1,120 @@
+package mirror
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/drift"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupWorkspaces serves a dev workspace and a prod workspace that differs from it
+func setupWorkspaces() *fakeapi.Server {
+	api := fakeapi.New()
+	b := segment.NewRulesBuilder()
+	b.Event("Order Completed").Version(1).Prop("order_id", segment.String().Required())
+
+	api.AddSource("dev", "js", "catalog/sources/javascript")
+	api.AddSource("dev", "ios", "catalog/sources/ios")
+	api.AddDestination("dev", "js", "google-analytics", true,
+		segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-DEV"},
+		segment.DestinationConfig{Name: "anonymizeIp", Type: "boolean", Value: true})
+	api.AddDestination("dev", "js", "mixpanel", true,
+		segment.DestinationConfig{Name: "apiSecret", Type: "password", Value: "dev-secret"},
+		segment.DestinationConfig{Name: "people", Type: "boolean", Value: true})
+	api.AddTrackingPlan("dev", "rs_1", "Kicks App", b.Rules())
+	api.Connect("dev", "js", "rs_1")
+
+	api.AddSource("prod", "js", "catalog/sources/javascript")
+	api.AddSource("prod", "android", "catalog/sources/android")
+	api.AddDestination("prod", "js", "google-analytics", false,
+		segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-PROD"},
+		segment.DestinationConfig{Name: "anonymizeIp", Type: "boolean", Value: false})
+	api.AddDestination("prod", "js", "mixpanel", true,
+		segment.DestinationConfig{Name: "apiSecret", Type: "password", Value: "prod-secret"},
+		segment.DestinationConfig{Name: "people", Type: "boolean", Value: true})
+	api.AddTrackingPlan("prod", "rs_7", "Kicks App", segment.Rules{})
+	return api
+}
+
+func TestCompare(t *testing.T) {
+	api := setupWorkspaces()
+	defer api.Close()
+
+	d, err := Compare(api.Client("dev"), api.Client("prod"), Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := `- source ios (missing)
+~ destination js/google-analytics enabled: true -> false
+~ destination js/google-analytics config.anonymizeIp: true -> false
+~ destination js/google-analytics config.trackingId: "UA-DEV" -> "UA-PROD"
+~ destination js/mixpanel config.apiSecret: ******** -> ********
++ source android (unexpected)
+~ tracking plan "Kicks App" rules changed
+    - event "Order Completed" (v1)
+- connection js -> "Kicks App" (missing)
+8 drifts
+`
+	assert.Equal(t, expected, d.String())
+}
+
+func TestCompare_Rules(t *testing.T) {
+	api := setupWorkspaces()
+	defer api.Close()
+
+	opts := Options{
+		Rules:         []Rule{{Destination: "google-analytics", Setting: "trackingId"}},
+		IgnoreSecrets: true,
+	}
+	d, err := Compare(api.Client("dev"), api.Client("prod"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, d.Counts()[drift.DestinationConfig], "only anonymizeIp differs")
+	for _, drift := range d.Drifts {
+		assert.NotEqual(t, "trackingId", drift.Setting)
+		assert.NotEqual(t, "apiSecret", drift.Setting)
+	}
+
+	// a rule value is what the target should have
+	opts.Rules = []Rule{{Source: "js", Destination: "google-analytics", Setting: "trackingId", Value: "UA-OTHER"}}
+	d, err = Compare(api.Client("dev"), api.Client("prod"), opts)
+	assert.NoError(t, err)
+	assert.Contains(t, d.String(), `config.trackingId: "UA-OTHER" -> "UA-PROD"`)
+}
+
+func TestOverrideConfigs(t *testing.T) {
+	d := segment.Destination{
+		Name: "workspaces/dev/sources/js/destinations/mixpanel",
+		Configs: []segment.DestinationConfig{
+			{Name: "workspaces/dev/sources/js/destinations/mixpanel/config/apiSecret", Type: "password", Value: "dev"},
+			{Name: "workspaces/dev/sources/js/destinations/mixpanel/config/people", Type: "boolean", Value: true},
+		},
+	}
+	opts := Options{
+		IgnoreSecrets: true,
+		Rules: []Rule{
+			{Setting: "region", Value: "EU"},
+			{Source: "js", Destination: "mixpanel", Setting: "token", Value: "prod-token"},
+		},
+	}
+	configs := overrideConfigs("js", "mixpanel", d, nil, opts)
+	values := map[string]interface{}{}
+	for _, c := range configs {
+		values[segment.Slug(c.Name)] = c.Value
+	}
+	// the secret is left out as the target does not have it, and the region rule does not name the
+	// destination so it only overrides existing settings
+	assert.Equal(t, map[string]interface{}{"people": true, "token": "prod-token"}, values)
+
+	target := []segment.DestinationConfig{
+		{Name: "workspaces/prod/sources/js/destinations/mixpanel/config/apiSecret", Type: "password", Value: "prod"},
+		{Name: "workspaces/prod/sources/js/destinations/mixpanel/config/region", Type: "string", Value: "US"},
+	}
+	configs = overrideConfigs("js", "mixpanel", d, target, opts)
+	values = map[string]interface{}{}
+	for _, c := range configs {
+		values[segment.Slug(c.Name)] = c.Value
+	}
+	assert.Equal(t, map[string]interface{}{"apiSecret": "prod", "people": true, "region": "EU", "token": "prod-token"}, values)
+}
@@ -0,0 +1,69 @@
+package mirror
+
+import (
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+)
+
+// SyncOptions controls a sync
+type SyncOptions struct {
+	// Select chooses the changes to make. Every change is made when it is nil.
+	Select func(spec.Change) bool
+	// Prune deletes the resources that only exist in the target workspace
+	Prune spec.Options
+	// DryRun returns the changes without making them
+	DryRun bool
+}
+
+// Kinds selects the changes of some kinds of resources
+func Kinds(kinds ...spec.Kind) func(spec.Change) bool {
+	return func(c spec.Change) bool {
+		for _, k := range kinds {
+			if c.Kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Sources selects the changes of some sources, their destinations and their connections
+func Sources(slugs ...string) func(spec.Change) bool {
+	return func(c spec.Change) bool {
+		for _, s := range slugs {
+			if c.Source == s || c.Kind == spec.KindSource && c.Name == s {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Plan returns the changes that make the target workspace mirror the other one
+func (d Diff) Plan(opts SyncOptions) (spec.Plan, error) {
+	all, err := spec.NewPlan(d.desired.Spec(), d.target, opts.Prune)
+	if err != nil {
+		return all, err
+	}
+	if opts.Select == nil {
+		return all, nil
+	}
+	var selected spec.Plan
+	for _, c := range all.Changes {
+		if opts.Select(c) {
+			selected.Changes = append(selected.Changes, c)
+		}
+	}
+	return selected, nil
+}
+
+// Sync copies the selected differences to the target workspace through the to client, and returns
+// the changes made. The changes are listed but not made on a dry run. Settings are copied as read
+// from the other workspace, so the secret resolver of the client is not used.
+func (d Diff) Sync(to *segment.Client, opts SyncOptions) ([]spec.Change, error) {
+	p, err := d.Plan(opts)
+	if err != nil || opts.DryRun {
+		return p.Changes, err
+	}
+	return p.Apply(to.WithSecretResolver(nil))
+}
@@ -0,0 +1,105 @@
+package mirror
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff_Sync(t *testing.T) {
+	api := setupWorkspaces()
+	defer api.Close()
+
+	opts := Options{
+		Rules:         []Rule{{Destination: "google-analytics", Setting: "trackingId"}},
+		IgnoreSecrets: true,
+	}
+	d, err := Compare(api.Client("dev"), api.Client("prod"), opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dry, err := d.Sync(api.Client("prod"), SyncOptions{DryRun: true, Prune: spec.Options{PruneSources: true}})
+	assert.NoError(t, err)
+	var lines []string
+	for _, c := range dry {
+		lines = append(lines, c.String())
+	}
+	assert.Equal(t, []string{
+		"+ source ios (catalog/sources/ios)",
+		"~ destination js/google-analytics",
+		`~ tracking plan "Kicks App" (rs_7)`,
+		`+ connection js -> "Kicks App"`,
+		"- source android",
+	}, lines)
+	assert.Empty(t, api.Calls(), "a dry run does not change the workspace")
+
+	changes, err := d.Sync(api.Client("prod"), SyncOptions{Select: Kinds(spec.KindDestination)})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, []string{"PATCH sources/js/destinations/google-analytics"}, api.Calls())
+
+	ga := api.Workspace("prod").Destinations["js"][0]
+	assert.True(t, ga.Enabled)
+	for _, c := range ga.Configs {
+		switch segment.Slug(c.Name) {
+		case "trackingId":
+			assert.Equal(t, "UA-PROD", c.Value, "overridden settings are not copied")
+		case "anonymizeIp":
+			assert.Equal(t, true, c.Value)
+		}
+	}
+
+	d, err = Compare(api.Client("dev"), api.Client("prod"), opts)
+	assert.NoError(t, err)
+	changes, err = d.Sync(api.Client("prod"), SyncOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+
+	d, err = Compare(api.Client("dev"), api.Client("prod"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"+ source android (unexpected)"}, driftLines(d))
+}
+
+func TestDiff_Sync_SecretReferences(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+	api.AddSource("dev", "js", "catalog/sources/javascript")
+	api.AddDestination("dev", "js", "mixpanel", true,
+		segment.DestinationConfig{Name: "token", Type: "string", Value: "${env:MIRROR_TEST_TOKEN}"})
+	api.AddSource("prod", "js", "catalog/sources/javascript")
+	os.Setenv("MIRROR_TEST_TOKEN", "resolved")
+	defer os.Unsetenv("MIRROR_TEST_TOKEN")
+
+	d, err := Compare(api.Client("dev"), api.Client("prod"), Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	prod := api.Client("prod")
+	prod.SetSecretResolver(segment.DefaultSecretResolver)
+	_, err = d.Sync(prod, SyncOptions{})
+	assert.NoError(t, err)
+	mixpanel := api.Workspace("prod").Destinations["js"][0]
+	assert.Equal(t, "${env:MIRROR_TEST_TOKEN}", mixpanel.Configs[0].Value, "settings are copied as read")
+}
+
+func TestSources(t *testing.T) {
+	selected := Sources("js")
+	assert.True(t, selected(spec.Change{Kind: spec.KindSource, Name: "js"}))
+	assert.True(t, selected(spec.Change{Kind: spec.KindDestination, Source: "js", Name: "mixpanel"}))
+	assert.True(t, selected(spec.Change{Kind: spec.KindConnection, Source: "js", Name: "Kicks App"}))
+	assert.False(t, selected(spec.Change{Kind: spec.KindSource, Name: "ios"}))
+	assert.False(t, selected(spec.Change{Kind: spec.KindTrackingPlan, Name: "js"}))
+}
+
+func driftLines(d Diff) []string {
+	var lines []string
+	for _, drift := range d.Drifts {
+		lines = append(lines, drift.String())
+	}
+	return lines
+}
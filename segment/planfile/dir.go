@@ -0,0 +1,156 @@
+package planfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Names of the files of a plan directory
+const (
+	PlanFileName  = "plan.yaml"
+	EventsDirName = "events"
+)
+
+// ExportDir writes a tracking plan to a directory, with the plan level rules in plan.yaml and
+// one file per event in the events directory. Event files left over from a previous export are removed.
+func ExportDir(plan segment.TrackingPlan, dir string) error {
+	f := FromTrackingPlan(plan)
+	events := f.Events
+	f.Events = nil
+
+	eventsDir := filepath.Join(dir, EventsDirName)
+	if err := os.MkdirAll(eventsDir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create events directory")
+	}
+	if err := writeYAML(filepath.Join(dir, PlanFileName), f); err != nil {
+		return err
+	}
+
+	written := map[string]string{}
+	for _, e := range events {
+		name := EventFileName(e.Name, e.Version)
+		if other, ok := written[name]; ok {
+			return fmt.Errorf("events %q and %q both map to file %s", other, e.Name, name)
+		}
+		written[name] = e.Name
+		if err := writeYAML(filepath.Join(eventsDir, name), e); err != nil {
+			return err
+		}
+	}
+
+	existing, err := ioutil.ReadDir(eventsDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to read events directory")
+	}
+	for _, fi := range existing {
+		if _, ok := written[fi.Name()]; ok || fi.IsDir() || filepath.Ext(fi.Name()) != ".yaml" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(eventsDir, fi.Name())); err != nil {
+			return errors.Wrap(err, "failed to remove stale event file")
+		}
+	}
+	return nil
+}
+
+// ImportDir reads a tracking plan from a directory written by ExportDir
+func ImportDir(dir string) (segment.TrackingPlan, error) {
+	var f File
+	if err := readYAML(filepath.Join(dir, PlanFileName), &f); err != nil {
+		return segment.TrackingPlan{}, err
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, EventsDirName, "*.yaml"))
+	if err != nil {
+		return segment.TrackingPlan{}, errors.Wrap(err, "failed to list event files")
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var e Event
+		if err := readYAML(name, &e); err != nil {
+			return segment.TrackingPlan{}, err
+		}
+		f.Events = append(f.Events, e)
+	}
+	return f.TrackingPlan(), nil
+}
+
+// Load reads a tracking plan from a directory written by ExportDir or from a single file
+// written by Export or ExportJSON
+func Load(path string) (segment.TrackingPlan, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return segment.TrackingPlan{}, errors.Wrap(err, "failed to read tracking plan")
+	}
+	if fi.IsDir() {
+		return ImportDir(path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return segment.TrackingPlan{}, errors.Wrap(err, "failed to read tracking plan")
+	}
+	return Import(b)
+}
+
+// EventFileName returns the file name of an event in a plan directory. Versions after the
+// first are suffixed so every version gets its own file.
+func EventFileName(name string, version int) string {
+	return EventSlug(name, version) + ".yaml"
+}
+
+// EventSlug returns a lower case identifier for an event version, e.g. order-completed-v2. Versions
+// after the first are suffixed so every version gets its own identifier.
+func EventSlug(name string, version int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	slug := b.String()
+	if slug == "" {
+		slug = "event"
+	}
+	if version > 1 {
+		slug += fmt.Sprintf("-v%d", version)
+	}
+	return slug
+}
+
+func writeYAML(path string, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", path)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+func readYAML(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := yaml.UnmarshalStrict(b, v); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package planfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDir_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "planfile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	stale := filepath.Join(dir, EventsDirName, "removed-event.yaml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	assert.NoError(t, ioutil.WriteFile(stale, []byte("name: Removed Event\n"), 0644))
+
+	plan := testPlan()
+	assert.NoError(t, ExportDir(plan, dir))
+
+	files, err := filepath.Glob(filepath.Join(dir, EventsDirName, "*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, EventsDirName, "cart-viewed.yaml"),
+		filepath.Join(dir, EventsDirName, "order-completed-v2.yaml"),
+	}, files)
+
+	imported, err := ImportDir(dir)
+	assert.NoError(t, err)
+	assertSameJSON(t, plan, imported)
+
+	loaded, err := Load(dir)
+	assert.NoError(t, err)
+	assertSameJSON(t, plan, loaded)
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestDir_EventFileName(t *testing.T) {
+	assert.Equal(t, "order-completed.yaml", EventFileName("Order Completed", 1))
+	assert.Equal(t, "order-completed-v3.yaml", EventFileName("  Order_Completed!", 3))
+	assert.Equal(t, "event.yaml", EventFileName("!!", 0))
+	assert.Equal(t, "order-completed-v2", EventSlug("Order Completed", 2))
+}
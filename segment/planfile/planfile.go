@@ -0,0 +1,503 @@
+// Package planfile defines a reviewable file format for tracking plans. Each event is a compact block
+// of properties with their type, required flag, enum and description instead of raw JSON Schema.
+// Files are written with sorted keys and events, so changes produce clean diffs.
+package planfile
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Sections of a rule envelope
+const (
+	sectionContext    = "context"
+	sectionTraits     = "traits"
+	sectionProperties = "properties"
+)
+
+// File is a tracking plan in file form. Server managed fields such as the plan name and
+// timestamps are not part of it.
+type File struct {
+	DisplayName    string    `yaml:"display_name" json:"display_name"`
+	Global         *Envelope `yaml:"global,omitempty" json:"global,omitempty"`
+	Identify       *Envelope `yaml:"identify,omitempty" json:"identify,omitempty"`
+	Group          *Envelope `yaml:"group,omitempty" json:"group,omitempty"`
+	IdentifyTraits []RawRule `yaml:"identify_traits,omitempty" json:"identify_traits,omitempty"`
+	GroupTraits    []RawRule `yaml:"group_traits,omitempty" json:"group_traits,omitempty"`
+	Events         []Event   `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// Envelope holds the context, traits and properties rules of a call. Rules that cannot be expressed
+// in this compact form are kept verbatim in Rules instead.
+type Envelope struct {
+	Context          Properties `yaml:"context,omitempty" json:"context,omitempty"`
+	Traits           Properties `yaml:"traits,omitempty" json:"traits,omitempty"`
+	Properties       Properties `yaml:"properties,omitempty" json:"properties,omitempty"`
+	RequiredSections *[]string  `yaml:"required_sections,omitempty" json:"required_sections,omitempty"`
+	Rules            *RawRule   `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Event is a track event in file form
+type Event struct {
+	Name        string                 `yaml:"name" json:"name"`
+	Version     int                    `yaml:"version,omitempty" json:"version,omitempty"`
+	Description string                 `yaml:"description,omitempty" json:"description,omitempty"`
+	Labels      map[string]interface{} `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Envelope    `yaml:",inline"`
+}
+
+// Properties maps property names to their rules
+type Properties map[string]Property
+
+// Property is the rule of a single property in file form
+type Property struct {
+	Type                       TypeNames              `yaml:"type,omitempty" json:"type,omitempty"`
+	Required                   bool                   `yaml:"required,omitempty" json:"required,omitempty"`
+	Description                string                 `yaml:"description,omitempty" json:"description,omitempty"`
+	Enum                       []interface{}          `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern                    string                 `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Format                     string                 `yaml:"format,omitempty" json:"format,omitempty"`
+	Minimum                    *float64               `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum                    *float64               `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	MinLength                  *int                   `yaml:"min_length,omitempty" json:"min_length,omitempty"`
+	MaxLength                  *int                   `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+	Ref                        string                 `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Schema                     string                 `yaml:"schema,omitempty" json:"schema,omitempty"`
+	Labels                     map[string]interface{} `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Items                      *Property              `yaml:"items,omitempty" json:"items,omitempty"`
+	Properties                 Properties             `yaml:"properties,omitempty" json:"properties,omitempty"`
+	AdditionalProperties       *bool                  `yaml:"additional_properties,omitempty" json:"additional_properties,omitempty"`
+	AdditionalPropertiesSchema *Property              `yaml:"additional_properties_schema,omitempty" json:"additional_properties_schema,omitempty"`
+	Definitions                Properties             `yaml:"definitions,omitempty" json:"definitions,omitempty"`
+}
+
+// TypeNames is the type of a property. It is written as a single name when there is one type and
+// as a list otherwise.
+type TypeNames []string
+
+// MarshalYAML writes a single type as a scalar
+func (t TypeNames) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// UnmarshalYAML reads a type given as a scalar or a list
+func (t *TypeNames) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*t = TypeNames{name}
+		return nil
+	}
+	var names []string
+	if err := unmarshal(&names); err != nil {
+		return err
+	}
+	*t = names
+	return nil
+}
+
+// MarshalJSON writes a single type as a string
+func (t TypeNames) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON reads a type given as a string or a list
+func (t *TypeNames) UnmarshalJSON(data []byte) error {
+	var st segment.Type
+	if err := st.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*t = st.Names
+	return nil
+}
+
+// RawRule is a rule kept verbatim as JSON Schema
+type RawRule struct {
+	segment.Rule
+}
+
+// MarshalYAML writes the rule as its JSON Schema document
+func (r RawRule) MarshalYAML() (interface{}, error) {
+	b, err := json.Marshal(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+	var v yaml.MapSlice
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UnmarshalYAML reads the rule from a JSON Schema document
+func (r *RawRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	b, err := json.Marshal(jsonvalue.Normalize(v))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &r.Rule)
+}
+
+// FromTrackingPlan converts a tracking plan to file form
+func FromTrackingPlan(plan segment.TrackingPlan) File {
+	f := File{
+		DisplayName: plan.DisplayName,
+		Global:      envelopeFromRule(plan.Rules.Global),
+		Identify:    envelopeFromRule(plan.Rules.Identify),
+		Group:       envelopeFromRule(plan.Rules.Group),
+	}
+	for _, r := range plan.Rules.IdentifyTraits {
+		f.IdentifyTraits = append(f.IdentifyTraits, RawRule{r})
+	}
+	for _, r := range plan.Rules.GroupTraits {
+		f.GroupTraits = append(f.GroupTraits, RawRule{r})
+	}
+	for _, e := range plan.Rules.Events {
+		fe := Event{
+			Name:        e.Name,
+			Version:     e.Version,
+			Description: e.Description,
+			Labels:      e.Rules.Labels,
+		}
+		rule := e.Rules
+		rule.Labels = nil
+		if env := envelopeFromRule(rule); env != nil {
+			fe.Envelope = *env
+		}
+		f.Events = append(f.Events, fe)
+	}
+	sortEvents(f.Events)
+	return f
+}
+
+// TrackingPlan converts the file back to a tracking plan
+func (f File) TrackingPlan() segment.TrackingPlan {
+	plan := segment.TrackingPlan{
+		DisplayName: f.DisplayName,
+		Rules: segment.Rules{
+			Global:         f.Global.rule(),
+			Identify:       f.Identify.rule(),
+			Group:          f.Group.rule(),
+			IdentifyTraits: []segment.Rule{},
+			GroupTraits:    []segment.Rule{},
+		},
+	}
+	for _, r := range f.IdentifyTraits {
+		plan.Rules.IdentifyTraits = append(plan.Rules.IdentifyTraits, r.Rule)
+	}
+	for _, r := range f.GroupTraits {
+		plan.Rules.GroupTraits = append(plan.Rules.GroupTraits, r.Rule)
+	}
+	events := append([]Event(nil), f.Events...)
+	sortEvents(events)
+	for _, e := range events {
+		env := e.Envelope
+		rule := env.rule()
+		rule.Labels = jsonMap(e.Labels)
+		plan.Rules.Events = append(plan.Rules.Events, segment.Event{
+			Name:        e.Name,
+			Version:     e.Version,
+			Description: e.Description,
+			Rules:       rule,
+		})
+	}
+	return plan
+}
+
+// Export writes a tracking plan as YAML
+func Export(plan segment.TrackingPlan) ([]byte, error) {
+	b, err := yaml.Marshal(FromTrackingPlan(plan))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal tracking plan file")
+	}
+	return b, nil
+}
+
+// ExportJSON writes a tracking plan as indented JSON in file form
+func ExportJSON(plan segment.TrackingPlan) ([]byte, error) {
+	b, err := json.MarshalIndent(FromTrackingPlan(plan), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal tracking plan file")
+	}
+	return append(b, '\n'), nil
+}
+
+// Import reads a tracking plan from a file written by Export or ExportJSON. As YAML is a superset
+// of JSON both formats are accepted.
+func Import(data []byte) (segment.TrackingPlan, error) {
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return segment.TrackingPlan{}, errors.Wrap(err, "failed to unmarshal tracking plan file")
+	}
+	return f.TrackingPlan(), nil
+}
+
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		return events[i].Version < events[j].Version
+	})
+}
+
+// envelopeFromRule converts an envelope rule to file form. Rules that do not round-trip through
+// the compact form are kept verbatim.
+func envelopeFromRule(r segment.Rule) *Envelope {
+	if reflect.DeepEqual(r, segment.Rule{}) {
+		return nil
+	}
+	env := &Envelope{
+		Context:    sectionFromRule(r.Properties[sectionContext]),
+		Traits:     sectionFromRule(r.Properties[sectionTraits]),
+		Properties: sectionFromRule(r.Properties[sectionProperties]),
+	}
+	if !jsonvalue.StringsEqual(r.Required, env.derivedRequired()) {
+		required := append([]string{}, r.Required...)
+		env.RequiredSections = &required
+	}
+	if !sameRule(env.rule(), r) {
+		return &Envelope{Rules: &RawRule{r}}
+	}
+	return env
+}
+
+// sameRule reports whether two rules encode to the same JSON Schema, ignoring the order of
+// required lists and whether types are a single type or a list, which the file form does not keep
+func sameRule(a, b segment.Rule) bool {
+	ja, errA := json.Marshal(normalize(a))
+	jb, errB := json.Marshal(normalize(b))
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func sectionFromRule(r segment.Rule) Properties {
+	if len(r.Properties) == 0 {
+		return nil
+	}
+	props := Properties{}
+	for name, child := range r.Properties {
+		props[name] = propertyFromRule(child, r.IsRequired(name))
+	}
+	return props
+}
+
+func (e *Envelope) derivedRequired() []string {
+	var required []string
+	for _, s := range []struct {
+		name  string
+		props Properties
+	}{{sectionContext, e.Context}, {sectionTraits, e.Traits}, {sectionProperties, e.Properties}} {
+		for _, p := range s.props {
+			if p.Required {
+				required = append(required, s.name)
+				break
+			}
+		}
+	}
+	return required
+}
+
+func (e *Envelope) rule() segment.Rule {
+	if e == nil {
+		return segment.Rule{}
+	}
+	if e.Rules != nil {
+		return e.Rules.Rule
+	}
+	r := segment.Rule{
+		Schema: segment.SchemaDraft07,
+		Type:   segment.SingleType(segment.TypeObject),
+		Properties: map[string]segment.Rule{
+			sectionContext:    e.Context.sectionRule(),
+			sectionTraits:     e.Traits.sectionRule(),
+			sectionProperties: e.Properties.sectionRule(),
+		},
+		Required: e.derivedRequired(),
+	}
+	if e.RequiredSections != nil {
+		r.Required = nil
+		if len(*e.RequiredSections) > 0 {
+			r.Required = append([]string{}, *e.RequiredSections...)
+		}
+	}
+	return r
+}
+
+func (p Properties) sectionRule() segment.Rule {
+	if len(p) == 0 {
+		return segment.Rule{}
+	}
+	r := segment.Rule{Type: segment.SingleType(segment.TypeObject)}
+	p.into(&r)
+	return r
+}
+
+// into sets the properties and required list of r
+func (p Properties) into(r *segment.Rule) {
+	if len(p) == 0 {
+		return
+	}
+	r.Properties = make(map[string]segment.Rule, len(p))
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.Properties[name] = p[name].rule()
+		if p[name].Required {
+			r.Required = append(r.Required, name)
+		}
+	}
+}
+
+func propertyFromRule(r segment.Rule, required bool) Property {
+	p := Property{
+		Type:        TypeNames(r.Type.Names),
+		Required:    required,
+		Description: r.Description,
+		Enum:        []interface{}(r.Enum),
+		Pattern:     r.Pattern,
+		Format:      r.Format,
+		Minimum:     r.Minimum,
+		Maximum:     r.Maximum,
+		MinLength:   r.MinLength,
+		MaxLength:   r.MaxLength,
+		Ref:         r.Ref,
+		Schema:      r.Schema,
+		Labels:      r.Labels,
+	}
+	if r.Items != nil {
+		items := propertyFromRule(*r.Items, false)
+		p.Items = &items
+	}
+	if len(r.Properties) > 0 {
+		p.Properties = Properties{}
+		for name, child := range r.Properties {
+			p.Properties[name] = propertyFromRule(child, r.IsRequired(name))
+		}
+	}
+	if ap := r.AdditionalProperties; ap != nil {
+		if ap.Schema != nil {
+			schema := propertyFromRule(*ap.Schema, false)
+			p.AdditionalPropertiesSchema = &schema
+		} else {
+			allowed := ap.Allowed
+			p.AdditionalProperties = &allowed
+		}
+	}
+	if len(r.Definitions) > 0 {
+		p.Definitions = Properties{}
+		for name, def := range r.Definitions {
+			p.Definitions[name] = propertyFromRule(def, false)
+		}
+	}
+	return p
+}
+
+// ruleType returns the JSON form of the types of a property: objects are written as a single type,
+// like segment.Object and the envelope sections, and other types as a list like the other builders
+func ruleType(names TypeNames) segment.Type {
+	if len(names) == 1 && names[0] == segment.TypeObject {
+		return segment.SingleType(segment.TypeObject)
+	}
+	return segment.TypeList(names...)
+}
+
+func (p Property) rule() segment.Rule {
+	r := segment.Rule{
+		Description: p.Description,
+		Enum:        segment.Enum(jsonSlice(p.Enum)),
+		Pattern:     p.Pattern,
+		Format:      p.Format,
+		Minimum:     p.Minimum,
+		Maximum:     p.Maximum,
+		MinLength:   p.MinLength,
+		MaxLength:   p.MaxLength,
+		Ref:         p.Ref,
+		Schema:      p.Schema,
+		Labels:      jsonMap(p.Labels),
+	}
+	if len(p.Type) > 0 {
+		r.Type = ruleType(p.Type)
+	}
+	if p.Items != nil {
+		items := p.Items.rule()
+		r.Items = &items
+	}
+	p.Properties.into(&r)
+	if p.AdditionalPropertiesSchema != nil {
+		schema := p.AdditionalPropertiesSchema.rule()
+		r.AdditionalProperties = &segment.AdditionalProperties{Allowed: true, Schema: &schema}
+	} else if p.AdditionalProperties != nil {
+		r.AdditionalProperties = &segment.AdditionalProperties{Allowed: *p.AdditionalProperties}
+	}
+	if len(p.Definitions) > 0 {
+		r.Definitions = make(map[string]segment.Rule, len(p.Definitions))
+		for name, def := range p.Definitions {
+			r.Definitions[name] = def.rule()
+		}
+	}
+	return r
+}
+
+// normalize returns a copy of r with every required list sorted, as the file form does not
+// keep their order
+func normalize(r segment.Rule) segment.Rule {
+	if !r.Type.IsZero() {
+		r.Type = ruleType(r.Type.Names)
+	}
+	if len(r.Required) > 0 {
+		r.Required = append([]string{}, r.Required...)
+		sort.Strings(r.Required)
+	}
+	if r.Properties != nil {
+		props := make(map[string]segment.Rule, len(r.Properties))
+		for name, child := range r.Properties {
+			props[name] = normalize(child)
+		}
+		r.Properties = props
+	}
+	if r.Items != nil {
+		items := normalize(*r.Items)
+		r.Items = &items
+	}
+	if r.AdditionalProperties != nil && r.AdditionalProperties.Schema != nil {
+		schema := normalize(*r.AdditionalProperties.Schema)
+		r.AdditionalProperties = &segment.AdditionalProperties{Allowed: true, Schema: &schema}
+	}
+	if r.Definitions != nil {
+		defs := make(map[string]segment.Rule, len(r.Definitions))
+		for name, def := range r.Definitions {
+			defs[name] = normalize(def)
+		}
+		r.Definitions = defs
+	}
+	return r
+}
+
+func jsonSlice(values []interface{}) []interface{} {
+	return jsonvalue.Normalize(values).([]interface{})
+}
+
+func jsonMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return jsonvalue.Normalize(m).(map[string]interface{})
+}
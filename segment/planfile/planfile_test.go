@@ -0,0 +1,188 @@
+package planfile
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPlan() segment.TrackingPlan {
+	tp := segment.NewRulesBuilder()
+	tp.Global().Context("library", segment.Object().Required().
+		Prop("name", segment.String().Required()))
+	tp.Event("Order Completed").Version(2).Description("An order was placed").Label("team", "checkout").
+		Prop("product", segment.String().Required().Description("Product name")).
+		Prop("price", segment.Number().Min(0)).
+		Prop("currency", segment.String().Enum("USD", "EUR").Nullable()).
+		Prop("quantity", segment.Integer().Enum(1, 2, 3)).
+		Prop("tags", segment.Array(segment.String().MaxLength(20)))
+	tp.Event("Cart Viewed").Version(1)
+	tp.Identify().Trait("email", segment.String().Format("email").Required())
+	tp.IdentifyTrait("plan", segment.String())
+	return tp.TrackingPlan("Kicks")
+}
+
+const testYAML = `display_name: Kicks
+global:
+  context:
+    library:
+      type: object
+      required: true
+      properties:
+        name:
+          type: string
+          required: true
+identify:
+  traits:
+    email:
+      type: string
+      required: true
+      format: email
+    plan:
+      type: string
+events:
+- name: Cart Viewed
+  version: 1
+- name: Order Completed
+  version: 2
+  description: An order was placed
+  labels:
+    team: checkout
+  properties:
+    currency:
+      type:
+      - string
+      - "null"
+      enum:
+      - USD
+      - EUR
+      - null
+    price:
+      type: number
+      minimum: 0
+    product:
+      type: string
+      required: true
+      description: Product name
+    quantity:
+      type: integer
+      enum:
+      - 1
+      - 2
+      - 3
+    tags:
+      type: array
+      items:
+        type: string
+        max_length: 20
+`
+
+func TestPlanfile_Export(t *testing.T) {
+	b, err := Export(testPlan())
+	assert.NoError(t, err)
+	assert.Equal(t, testYAML, string(b))
+}
+
+func TestPlanfile_RoundTrip(t *testing.T) {
+	plan := testPlan()
+
+	b, err := Export(plan)
+	assert.NoError(t, err)
+	imported, err := Import(b)
+	assert.NoError(t, err)
+	assertSameJSON(t, plan, imported)
+
+	b, err = ExportJSON(plan)
+	assert.NoError(t, err)
+	imported, err = Import(b)
+	assert.NoError(t, err)
+	assertSameJSON(t, plan, imported)
+
+	again, err := Export(imported)
+	assert.NoError(t, err)
+	assert.Equal(t, testYAML, string(again))
+}
+
+func TestPlanfile_TypeForm(t *testing.T) {
+	b := segment.NewRulesBuilder()
+	e := b.Event("Order Completed")
+	e.Prop("product", segment.String())
+	e.Prop("shipping", segment.Object().Prop("method", segment.String()))
+	tp := b.TrackingPlan("Kicks")
+	props := tp.Rules.Events[0].Rules.Properties["properties"].Properties
+	// the API also sends scalars as a single type and objects as a list
+	product := props["product"]
+	product.Type = segment.SingleType(segment.TypeString)
+	props["product"] = product
+	shipping := props["shipping"]
+	shipping.Type = segment.TypeList(segment.TypeObject)
+	props["shipping"] = shipping
+
+	data, err := Export(tp)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "single")
+
+	// imports write objects as a single type and other types as a list
+	imported, err := Import(data)
+	assert.NoError(t, err)
+	props = imported.Rules.Events[0].Rules.Properties["properties"].Properties
+	assert.Equal(t, segment.TypeList(segment.TypeString), props["product"].Type)
+	assert.Equal(t, segment.SingleType(segment.TypeObject), props["shipping"].Type)
+	assert.Equal(t, segment.TypeList(segment.TypeString), props["shipping"].Properties["method"].Type)
+}
+
+func TestPlanfile_RawRules(t *testing.T) {
+	var plan segment.TrackingPlan
+	err := json.Unmarshal([]byte(`{
+		"display_name": "Legacy",
+		"rules": {
+			"events": [{
+				"name": "Signed Up",
+				"version": 1,
+				"rules": {
+					"$schema": "http://json-schema.org/draft-04/schema#",
+					"type": "object",
+					"properties": {"properties": {"type": "object", "properties": {"plan": {"type": "string"}}}}
+				}
+			}],
+			"identify_traits": [],
+			"group_traits": []
+		}
+	}`), &plan)
+	assert.NoError(t, err)
+
+	b, err := Export(plan)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "  rules:\n")
+	assert.Contains(t, string(b), "    $schema: http://json-schema.org/draft-04/schema#\n")
+
+	imported, err := Import(b)
+	assert.NoError(t, err)
+	assertSameJSON(t, plan, imported)
+}
+
+func TestPlanfile_ImportError(t *testing.T) {
+	_, err := Import([]byte("display_name: Kicks\nevents:\n- nam: Typo\n"))
+	assert.Error(t, err)
+}
+
+// assertSameJSON compares the JSON encoding of two plans, after sorting the expected events the
+// way the file form does
+func assertSameJSON(t *testing.T, expected, actual segment.TrackingPlan) {
+	events := append([]segment.Event(nil), expected.Rules.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		return events[i].Version < events[j].Version
+	})
+	expected.Rules.Events = events
+	e, err := json.Marshal(expected)
+	assert.NoError(t, err)
+	a, err := json.Marshal(actual)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(e), string(a))
+}
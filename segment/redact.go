@@ -0,0 +1,46 @@
+package segment
+
+import "encoding/json"
+
+// RedactedValue replaces the values of secret settings that are redacted
+const RedactedValue = "********"
+
+// Redacted returns the setting with its value replaced by RedactedValue if it holds a credential.
+// Empty values and secret references are kept, as they do not disclose anything.
+func (dc DestinationConfig) Redacted() DestinationConfig {
+	if dc.IsSecret() && dc.Value != nil && dc.Value != "" && !IsSecretReference(dc.Value) {
+		dc.Value = RedactedValue
+	}
+	return dc
+}
+
+// Redacted returns a copy of the destination with the values of its secret settings redacted
+func (d Destination) Redacted() Destination {
+	if d.Configs == nil {
+		return d
+	}
+	configs := make([]DestinationConfig, len(d.Configs))
+	for i, c := range d.Configs {
+		configs[i] = c.Redacted()
+	}
+	d.Configs = configs
+	return d
+}
+
+// String renders the destination as JSON with its secret settings redacted, so that it can be logged
+func (d Destination) String() string {
+	data, err := json.Marshal(d.Redacted())
+	if err != nil {
+		return d.Name
+	}
+	return string(data)
+}
+
+// String renders the setting as name=value, with the value redacted if it holds a credential
+func (dc DestinationConfig) String() string {
+	data, err := json.Marshal(dc.Redacted().Value)
+	if err != nil {
+		return Slug(dc.Name)
+	}
+	return Slug(dc.Name) + "=" + string(data)
+}
@@ -0,0 +1,45 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDestination() Destination {
+	return Destination{
+		Name:    "workspaces/ws/sources/js/destinations/mixpanel",
+		Enabled: true,
+		Configs: []DestinationConfig{
+			{Name: "workspaces/ws/sources/js/destinations/mixpanel/config/apiSecret", Type: "password", Value: "s3cr3t"},
+			{Name: "workspaces/ws/sources/js/destinations/mixpanel/config/token", Type: "string", Value: "${env:MIXPANEL_TOKEN}"},
+			{Name: "workspaces/ws/sources/js/destinations/mixpanel/config/apiKey", Type: "string", Value: ""},
+			{Name: "workspaces/ws/sources/js/destinations/mixpanel/config/people", Type: "boolean", Value: true},
+		},
+	}
+}
+
+func TestDestination_Redacted(t *testing.T) {
+	d := testDestination()
+	r := d.Redacted()
+	assert.Equal(t, RedactedValue, r.Configs[0].Value)
+	assert.Equal(t, "${env:MIXPANEL_TOKEN}", r.Configs[1].Value, "secret references are kept")
+	assert.Equal(t, "", r.Configs[2].Value, "empty values are kept")
+	assert.Equal(t, true, r.Configs[3].Value)
+	assert.Equal(t, "s3cr3t", d.Configs[0].Value, "the destination is not modified")
+}
+
+func TestDestination_String(t *testing.T) {
+	d := testDestination()
+	assert.NotContains(t, d.String(), "s3cr3t")
+	assert.NotContains(t, fmt.Sprint(d), "s3cr3t")
+	assert.Equal(t, `apiSecret="********"`, d.Configs[0].String())
+	assert.Equal(t, `people=true`, d.Configs[3].String())
+
+	// JSON is not redacted, as it is what is sent to the Config API
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "s3cr3t")
+}
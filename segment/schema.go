@@ -0,0 +1,269 @@
+package segment
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+	"github.com/pkg/errors"
+)
+
+// JSON Schema type names used by tracking plan rules
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// SchemaDraft07 is the $schema URI Segment uses for tracking plan rules
+const SchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// Type is the JSON Schema type keyword of a rule. It holds either a single type name or a list
+// of names, and remembers which of the two forms it was decoded from so it encodes back the same way.
+type Type struct {
+	Names []string
+	List  bool
+}
+
+// SingleType returns a type encoded as a single name, e.g. "object"
+func SingleType(name string) Type {
+	return Type{Names: []string{name}}
+}
+
+// TypeList returns a type encoded as a list of names, e.g. ["string", "null"]
+func TypeList(names ...string) Type {
+	return Type{Names: names, List: true}
+}
+
+// IsZero reports whether the type keyword is absent
+func (t Type) IsZero() bool {
+	return len(t.Names) == 0 && !t.List
+}
+
+// Has reports whether name is one of the allowed types
+func (t Type) Has(name string) bool {
+	for _, n := range t.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Nullable reports whether null is one of the allowed types
+func (t Type) Nullable() bool {
+	return t.Has(TypeNull)
+}
+
+// NonNull returns the allowed types other than null
+func (t Type) NonNull() []string {
+	var names []string
+	for _, n := range t.Names {
+		if n != TypeNull {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// MarshalJSON encodes the type as a string or a list depending on its form
+func (t Type) MarshalJSON() ([]byte, error) {
+	if !t.List && len(t.Names) == 1 {
+		return json.Marshal(t.Names[0])
+	}
+	names := t.Names
+	if names == nil {
+		names = []string{}
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON decodes a type given as a string or a list of strings
+func (t *Type) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*t = Type{}
+	case len(data) > 0 && data[0] == '[':
+		names := []string{}
+		if err := json.Unmarshal(data, &names); err != nil {
+			return errors.Wrap(err, "failed to unmarshal type list")
+		}
+		*t = Type{Names: names, List: true}
+	default:
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return errors.Wrap(err, "failed to unmarshal type")
+		}
+		*t = SingleType(name)
+	}
+	return nil
+}
+
+// Enum lists the values a rule allows. Values decoded from JSON are a string, a float64,
+// a bool or nil for null.
+type Enum []interface{}
+
+// StringEnum returns an enum of string values
+func StringEnum(values ...string) Enum {
+	e := make(Enum, len(values))
+	for i, v := range values {
+		e[i] = v
+	}
+	return e
+}
+
+// Strings returns the enum values as strings. The returned bool is false if any value is not a string.
+func (e Enum) Strings() ([]string, bool) {
+	values := make([]string, 0, len(e))
+	for _, v := range e {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
+
+// Contains reports whether v is one of the enum values. Numbers are compared by value regardless of Go type.
+func (e Enum) Contains(v interface{}) bool {
+	for _, ev := range e {
+		if enumValueEqual(ev, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumValueEqual(a, b interface{}) bool {
+	fa, aNum := jsonvalue.Float(a)
+	fb, bNum := jsonvalue.Float(b)
+	if aNum || bNum {
+		return aNum && bNum && fa == fb
+	}
+	return a == b
+}
+
+// AdditionalProperties is the additionalProperties keyword of an object rule. It is either a boolean
+// or a rule every additional property must satisfy.
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Rule
+}
+
+// MarshalJSON encodes the keyword as its schema if one is set and as a boolean otherwise
+func (a AdditionalProperties) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// UnmarshalJSON decodes the keyword from a boolean or a schema
+func (a *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("true")) || bytes.Equal(data, []byte("false")) {
+		*a = AdditionalProperties{Allowed: data[0] == 't'}
+		return nil
+	}
+	var r Rule
+	if err := json.Unmarshal(data, &r); err != nil {
+		return errors.Wrap(err, "failed to unmarshal additionalProperties")
+	}
+	*a = AdditionalProperties{Allowed: true, Schema: &r}
+	return nil
+}
+
+// ruleJSON is the wire format of a Rule. The leading fields keep the order Rule has always been
+// encoded in, so existing payloads encode to the same bytes.
+type ruleJSON struct {
+	Description          string                 `json:"description,omitempty"`
+	Enum                 Enum                   `json:"enum,omitempty"`
+	Labels               map[string]interface{} `json:"labels,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Properties           map[string]Rule        `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Type                 *Type                  `json:"type,omitempty"`
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Items                *Rule                  `json:"items,omitempty"`
+	AdditionalProperties *AdditionalProperties  `json:"additionalProperties,omitempty"`
+	Definitions          map[string]Rule        `json:"definitions,omitempty"`
+}
+
+// MarshalJSON encodes the rule as a JSON Schema, omitting unset keywords
+func (r Rule) MarshalJSON() ([]byte, error) {
+	w := ruleJSON{
+		Description:          r.Description,
+		Enum:                 r.Enum,
+		Labels:               r.Labels,
+		Pattern:              r.Pattern,
+		Properties:           r.Properties,
+		Required:             r.Required,
+		Schema:               r.Schema,
+		Ref:                  r.Ref,
+		Format:               r.Format,
+		Minimum:              r.Minimum,
+		Maximum:              r.Maximum,
+		MinLength:            r.MinLength,
+		MaxLength:            r.MaxLength,
+		Items:                r.Items,
+		AdditionalProperties: r.AdditionalProperties,
+		Definitions:          r.Definitions,
+	}
+	if !r.Type.IsZero() {
+		t := r.Type
+		w.Type = &t
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON decodes the rule from a JSON Schema
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var w ruleJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*r = Rule{
+		Schema:               w.Schema,
+		Ref:                  w.Ref,
+		Description:          w.Description,
+		Format:               w.Format,
+		Enum:                 w.Enum,
+		Pattern:              w.Pattern,
+		Minimum:              w.Minimum,
+		Maximum:              w.Maximum,
+		MinLength:            w.MinLength,
+		MaxLength:            w.MaxLength,
+		Properties:           w.Properties,
+		Required:             w.Required,
+		Items:                w.Items,
+		AdditionalProperties: w.AdditionalProperties,
+		Definitions:          w.Definitions,
+		Labels:               w.Labels,
+	}
+	if w.Type != nil {
+		r.Type = *w.Type
+	}
+	return nil
+}
+
+// IsRequired reports whether name is listed in the rule's required properties
+func (r Rule) IsRequired(name string) bool {
+	for _, req := range r.Required {
+		if req == name {
+			return true
+		}
+	}
+	return false
+}
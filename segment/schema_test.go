@@ -0,0 +1,95 @@
+package segment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema_RulesRoundTrip(t *testing.T) {
+	payload := `{
+		"identify_traits": [],
+		"group_traits": [],
+		"events": [{
+			"name": "Order Completed", "description": "Who bought what", "version": 2,
+			"rules": {
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"type": "object",
+				"labels": {"team": "checkout"},
+				"properties": {
+					"context": {},
+					"traits": {},
+					"properties": {
+						"type": "object",
+						"required": ["currency", "price"],
+						"properties": {
+							"currency": {"description": "ISO code", "type": ["string"], "enum": ["USD", "EUR", null], "pattern": "^[A-Z]{3}$"},
+							"price": {"type": ["number", "null"]},
+							"quantity": {"type": "integer", "enum": [1, 2.5, true]}
+						}
+					}
+				},
+				"required": ["properties"]
+			}
+		}],
+		"global": {"$schema": "http://json-schema.org/draft-07/schema#", "type": "object", "properties": {"context": {}, "traits": {}, "properties": {}}},
+		"identify": {},
+		"group": {"type": []}
+	}`
+	// Encoding of the payload before rules were typed
+	expected := `{"global":{"properties":{"context":{},"properties":{},"traits":{}},"type":"object","$schema":"http://json-schema.org/draft-07/schema#"},"events":[{"name":"Order Completed","version":2,"description":"Who bought what","rules":{"labels":{"team":"checkout"},"properties":{"context":{},"properties":{"properties":{"currency":{"description":"ISO code","enum":["USD","EUR",null],"pattern":"^[A-Z]{3}$","type":["string"]},"price":{"type":["number","null"]},"quantity":{"enum":[1,2.5,true],"type":"integer"}},"required":["currency","price"],"type":"object"},"traits":{}},"required":["properties"],"type":"object","$schema":"http://json-schema.org/draft-07/schema#"}}],"identify":{},"group":{"type":[]},"identify_traits":[],"group_traits":[]}`
+
+	var rules Rules
+	assert.NoError(t, json.Unmarshal([]byte(payload), &rules))
+
+	props := rules.Events[0].Rules.Properties["properties"]
+	assert.Equal(t, SingleType(TypeObject), props.Type)
+	assert.Equal(t, TypeList(TypeString), props.Properties["currency"].Type)
+	assert.Equal(t, "^[A-Z]{3}$", props.Properties["currency"].Pattern)
+	assert.True(t, props.Properties["currency"].Enum.Contains("EUR"))
+	assert.True(t, props.Properties["price"].Type.Nullable())
+	assert.Equal(t, []string{TypeNumber}, props.Properties["price"].Type.NonNull())
+	assert.True(t, props.Properties["quantity"].Enum.Contains(1))
+	assert.True(t, props.IsRequired("price"))
+	assert.False(t, props.IsRequired("quantity"))
+
+	actual, err := json.Marshal(rules)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestSchema_ExtendedKeywordsRoundTrip(t *testing.T) {
+	payload := `{"properties":{"address":{"$ref":"#/definitions/address"},"age":{"type":"integer","minimum":0,"maximum":150},` +
+		`"email":{"type":"string","format":"email","minLength":3,"maxLength":254},` +
+		`"extras":{"type":"object","additionalProperties":{"type":"string"}},` +
+		`"strict":{"type":"object","additionalProperties":false},` +
+		`"tags":{"type":"array","items":{"type":"string"}}},"type":"object",` +
+		`"definitions":{"address":{"properties":{"city":{"type":"string"}},"type":"object"}}}`
+
+	var r Rule
+	assert.NoError(t, json.Unmarshal([]byte(payload), &r))
+
+	assert.Equal(t, "#/definitions/address", r.Properties["address"].Ref)
+	assert.Equal(t, float64(150), *r.Properties["age"].Maximum)
+	assert.Equal(t, float64(0), *r.Properties["age"].Minimum)
+	assert.Equal(t, "email", r.Properties["email"].Format)
+	assert.Equal(t, 254, *r.Properties["email"].MaxLength)
+	assert.Equal(t, SingleType(TypeString), r.Properties["extras"].AdditionalProperties.Schema.Type)
+	assert.False(t, r.Properties["strict"].AdditionalProperties.Allowed)
+	assert.Equal(t, SingleType(TypeString), r.Properties["tags"].Items.Type)
+	assert.Contains(t, r.Definitions, "address")
+
+	actual, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, string(actual))
+}
+
+func TestSchema_EnumStrings(t *testing.T) {
+	values, ok := StringEnum("a", "b").Strings()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, values)
+
+	_, ok = Enum{"a", 1.0}.Strings()
+	assert.False(t, ok)
+}
@@ -0,0 +1,122 @@
+package segment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// secretReference matches references to secrets in setting values, such as ${env:GA_KEY} or
+// ${file:/secrets/ga}
+var secretReference = regexp.MustCompile(`\$\{([a-z]+):([^}]+)\}`)
+
+// SecretResolver returns the secrets that references in destination settings point to. A reference
+// such as ${env:GA_KEY} has the scheme env and the name GA_KEY.
+type SecretResolver interface {
+	ResolveSecret(scheme, name string) (string, error)
+}
+
+// SecretResolverFunc is a function that resolves secrets
+type SecretResolverFunc func(scheme, name string) (string, error)
+
+// ResolveSecret calls f
+func (f SecretResolverFunc) ResolveSecret(scheme, name string) (string, error) {
+	return f(scheme, name)
+}
+
+// SecretSchemes resolves secrets with a resolver for each scheme
+type SecretSchemes map[string]SecretResolver
+
+// ResolveSecret resolves a secret with the resolver of its scheme
+func (s SecretSchemes) ResolveSecret(scheme, name string) (string, error) {
+	r, ok := s[scheme]
+	if !ok {
+		return "", fmt.Errorf("unknown secret scheme %q", scheme)
+	}
+	return r.ResolveSecret(scheme, name)
+}
+
+// EnvSecrets resolves secrets from environment variables
+var EnvSecrets = SecretResolverFunc(func(scheme, name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+})
+
+// FileSecrets resolves secrets from the content of files, without trailing newlines
+var FileSecrets = SecretResolverFunc(func(scheme, name string) (string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read secret file %s", name)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+})
+
+// DefaultSecretResolver resolves the env and file schemes. Clients resolve nothing until they are
+// given a resolver with SetSecretResolver.
+var DefaultSecretResolver = SecretSchemes{"env": EnvSecrets, "file": FileSecrets}
+
+// IsSecretReference reports whether a setting value references a secret
+func IsSecretReference(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && secretReference.MatchString(s)
+}
+
+// ResolveSecrets returns a copy of configs with the secret references in their values replaced by
+// the secrets, including references in lists and maps
+func ResolveSecrets(r SecretResolver, configs []DestinationConfig) ([]DestinationConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	resolved := make([]DestinationConfig, len(configs))
+	for i, c := range configs {
+		v, err := resolveValue(r, c.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve setting %s", Slug(c.Name))
+		}
+		c.Value = v
+		resolved[i] = c
+	}
+	return resolved, nil
+}
+
+func resolveValue(r SecretResolver, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		var err error
+		resolved := secretReference.ReplaceAllStringFunc(v, func(ref string) string {
+			m := secretReference.FindStringSubmatch(ref)
+			secret, resolveErr := r.ResolveSecret(m[1], m[2])
+			if resolveErr != nil && err == nil {
+				err = resolveErr
+			}
+			return secret
+		})
+		return resolved, err
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if resolved[i], err = resolveValue(r, item); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			var err error
+			if resolved[k], err = resolveValue(r, item); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	}
+	return v, nil
+}
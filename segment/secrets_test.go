@@ -0,0 +1,93 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	os.Setenv("SEGMENT_TEST_GA_KEY", "ga-key")
+	defer os.Unsetenv("SEGMENT_TEST_GA_KEY")
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("file-token\n"), 0600))
+
+	configs := []DestinationConfig{
+		{Name: "apiKey", Type: "password", Value: "${env:SEGMENT_TEST_GA_KEY}"},
+		{Name: "token", Type: "string", Value: "Bearer ${file:" + file + "}"},
+		{Name: "keys", Type: "list", Value: []interface{}{"${env:SEGMENT_TEST_GA_KEY}", "plain"}},
+		{Name: "mapping", Type: "map", Value: map[string]interface{}{"key": "${env:SEGMENT_TEST_GA_KEY}"}},
+		{Name: "enabled", Type: "boolean", Value: true},
+	}
+	resolved, err := ResolveSecrets(DefaultSecretResolver, configs)
+	assert.NoError(t, err)
+	assert.Equal(t, "ga-key", resolved[0].Value)
+	assert.Equal(t, "Bearer file-token", resolved[1].Value)
+	assert.Equal(t, []interface{}{"ga-key", "plain"}, resolved[2].Value)
+	assert.Equal(t, map[string]interface{}{"key": "ga-key"}, resolved[3].Value)
+	assert.Equal(t, true, resolved[4].Value)
+	assert.Equal(t, "${env:SEGMENT_TEST_GA_KEY}", configs[0].Value, "configs are not modified")
+
+	_, err = ResolveSecrets(DefaultSecretResolver, []DestinationConfig{{Name: "x/config/apiKey", Value: "${env:SEGMENT_TEST_MISSING}"}})
+	assert.EqualError(t, err, "failed to resolve setting apiKey: environment variable SEGMENT_TEST_MISSING is not set")
+	_, err = ResolveSecrets(DefaultSecretResolver, []DestinationConfig{{Name: "apiKey", Value: "${vault:ga}"}})
+	assert.EqualError(t, err, `failed to resolve setting apiKey: unknown secret scheme "vault"`)
+
+	vault := SecretSchemes{"vault": SecretResolverFunc(func(scheme, name string) (string, error) {
+		return "vault-" + name, nil
+	})}
+	resolved, err = ResolveSecrets(vault, []DestinationConfig{{Name: "apiKey", Value: "${vault:ga}"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "vault-ga", resolved[0].Value)
+}
+
+func TestIsSecretReference(t *testing.T) {
+	assert.True(t, IsSecretReference("${env:GA_KEY}"))
+	assert.True(t, IsSecretReference("${file:/secrets/ga}"))
+	assert.False(t, IsSecretReference("GA_KEY"))
+	assert.False(t, IsSecretReference("${GA_KEY}"))
+	assert.False(t, IsSecretReference(true))
+}
+
+func TestDestinations_CreateDestination_Secrets(t *testing.T) {
+	setup()
+	defer teardown()
+	os.Setenv("SEGMENT_TEST_GA_KEY", "ga-key")
+	defer os.Unsetenv("SEGMENT_TEST_GA_KEY")
+
+	var sent destinationCreateRequest
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s/%s",
+		apiVersion, WorkspacesEndpoint, testWorkspace, SourceEndpoint, "js", DestinationEndpoint)
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		fmt.Fprint(w, `{}`)
+	})
+
+	// references are sent as is until the client is given a resolver
+	configs := []DestinationConfig{{Name: "apiKey", Type: "password", Value: "${env:SEGMENT_TEST_GA_KEY}"}}
+	_, err := client.CreateDestination("js", "google-analytics", "CLOUD", true, configs)
+	assert.NoError(t, err)
+	assert.Equal(t, "${env:SEGMENT_TEST_GA_KEY}", sent.Destination.Configs[0].Value)
+
+	client.SetSecretResolver(DefaultSecretResolver)
+	_, err = client.CreateDestination("js", "google-analytics", "CLOUD", true, configs)
+	assert.NoError(t, err)
+	assert.Equal(t, "ga-key", sent.Destination.Configs[0].Value)
+
+	_, err = client.WithSecretResolver(nil).CreateDestination("js", "google-analytics", "CLOUD", true, configs)
+	assert.NoError(t, err)
+	assert.Equal(t, "${env:SEGMENT_TEST_GA_KEY}", sent.Destination.Configs[0].Value, "the copy does not resolve")
+
+	_, err = client.CreateDestination("js", "google-analytics", "CLOUD", true,
+		[]DestinationConfig{{Name: "apiKey", Value: "${env:SEGMENT_TEST_MISSING}"}})
+	assert.Error(t, err)
+}
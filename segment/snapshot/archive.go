@@ -0,0 +1,276 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Files of a snapshot
+const (
+	IndexFileName        = "snapshot.json"
+	ConnectionsFileName  = "connections.json"
+	SourcesDirName       = "sources"
+	TrackingPlansDirName = "tracking-plans"
+)
+
+// index is the content of the index file
+type index struct {
+	FormatVersion int               `json:"format_version"`
+	Workspace     segment.Workspace `json:"workspace"`
+}
+
+// Save writes a snapshot to a gzipped tarball if path ends in .tar.gz or .tgz, and to a directory otherwise
+func (s Snapshot) Save(path string) error {
+	if isTarball(path) {
+		var buf bytes.Buffer
+		if err := s.WriteTar(&buf); err != nil {
+			return err
+		}
+		return errors.Wrapf(ioutil.WriteFile(path, buf.Bytes(), 0644), "failed to write %s", path)
+	}
+	return s.WriteDir(path)
+}
+
+// Load reads a snapshot from a gzipped tarball or a directory
+func Load(path string) (Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if info.IsDir() {
+		return ReadDir(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+	return ReadTar(f)
+}
+
+// WriteDir writes a snapshot as a directory of JSON files. Files of resources that are no longer in
+// the snapshot are removed.
+func (s Snapshot) WriteDir(dir string) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{SourcesDirName, TrackingPlansDirName} {
+		subDir := filepath.Join(dir, sub)
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create %s", subDir)
+		}
+		existing, err := filepath.Glob(filepath.Join(subDir, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, f := range existing {
+			if _, ok := files[sub+"/"+filepath.Base(f)]; !ok {
+				if err := os.Remove(f); err != nil {
+					return errors.Wrapf(err, "failed to remove %s", f)
+				}
+			}
+		}
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", p)
+		}
+	}
+	return nil
+}
+
+// ReadDir reads a snapshot written by WriteDir
+func ReadDir(dir string) (Snapshot, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(p) != ".json" {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", p)
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return fromFiles(files)
+}
+
+// WriteTar writes a snapshot as a gzipped tarball. The tarball only depends on the content of the
+// snapshot, so two snapshots of the same configuration are byte for byte identical.
+func (s Snapshot) WriteTar(w io.Writer) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(files[name])),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrap(err, "failed to write tarball")
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return errors.Wrap(err, "failed to write tarball")
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to write tarball")
+	}
+	return errors.Wrap(gz.Close(), "failed to write tarball")
+}
+
+// ReadTar reads a snapshot written by WriteTar
+func ReadTar(r io.Reader) (Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Snapshot{}, errors.Wrap(err, "failed to read gzip header")
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Snapshot{}, errors.Wrap(err, "failed to read tarball")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return Snapshot{}, errors.Wrapf(err, "failed to read %s", hdr.Name)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+	return fromFiles(files)
+}
+
+// files returns the content of the files of a snapshot by slash separated path. The values of secret
+// destination settings are redacted.
+func (s Snapshot) files() (map[string][]byte, error) {
+	s.sort()
+	files := map[string][]byte{}
+	add := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s", name)
+		}
+		files[name] = append(data, '\n')
+		return nil
+	}
+
+	version := s.FormatVersion
+	if version == 0 {
+		version = FormatVersion
+	}
+	if err := add(IndexFileName, index{FormatVersion: version, Workspace: s.Workspace}); err != nil {
+		return nil, err
+	}
+	connections := s.Connections
+	if connections == nil {
+		connections = []Connection{}
+	}
+	if err := add(ConnectionsFileName, connections); err != nil {
+		return nil, err
+	}
+	for _, src := range s.Sources {
+		if err := add(SourcesDirName+"/"+segment.Slug(src.Source.Name)+".json", src.redacted()); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range s.TrackingPlans {
+		if err := add(TrackingPlansDirName+"/"+segment.Slug(p.Name)+".json", p); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func fromFiles(files map[string][]byte) (Snapshot, error) {
+	var s Snapshot
+	data, ok := files[IndexFileName]
+	if !ok {
+		return s, fmt.Errorf("%s is missing", IndexFileName)
+	}
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return s, errors.Wrapf(err, "failed to unmarshal %s", IndexFileName)
+	}
+	if idx.FormatVersion < 1 || idx.FormatVersion > FormatVersion {
+		return s, fmt.Errorf("unsupported snapshot format version %d", idx.FormatVersion)
+	}
+	s.FormatVersion = idx.FormatVersion
+	s.Workspace = idx.Workspace
+
+	if data, ok := files[ConnectionsFileName]; ok {
+		if err := json.Unmarshal(data, &s.Connections); err != nil {
+			return s, errors.Wrapf(err, "failed to unmarshal %s", ConnectionsFileName)
+		}
+		if len(s.Connections) == 0 {
+			s.Connections = nil
+		}
+	}
+	for name, data := range files {
+		switch {
+		case strings.HasPrefix(name, SourcesDirName+"/"):
+			var src Source
+			if err := json.Unmarshal(data, &src); err != nil {
+				return s, errors.Wrapf(err, "failed to unmarshal %s", name)
+			}
+			s.Sources = append(s.Sources, src)
+		case strings.HasPrefix(name, TrackingPlansDirName+"/"):
+			var p segment.TrackingPlan
+			if err := json.Unmarshal(data, &p); err != nil {
+				return s, errors.Wrapf(err, "failed to unmarshal %s", name)
+			}
+			s.TrackingPlans = append(s.TrackingPlans, p)
+		}
+	}
+	s.sort()
+	return s, nil
+}
+
+func isTarball(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
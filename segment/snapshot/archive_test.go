@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestWriteDir(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, SourcesDirName), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, SourcesDirName, "deleted.json"), []byte("{}"), 0644))
+
+	assert.NoError(t, s.Save(dir))
+	var files []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	assert.Equal(t, []string{
+		"connections.json",
+		"snapshot.json",
+		"sources/ios.json",
+		"sources/js.json",
+		"tracking-plans/rs_1.json",
+	}, files)
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, IndexFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), `"format_version": 1`)
+
+	loaded, err := Load(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func TestWriteTar(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+
+	var first, second bytes.Buffer
+	assert.NoError(t, s.WriteTar(&first))
+	again, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+	assert.NoError(t, again.WriteTar(&second))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.tar.gz")
+	assert.NoError(t, s.Save(path))
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func TestReadDir_Errors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := ReadDir(dir)
+	assert.EqualError(t, err, "snapshot.json is missing")
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, IndexFileName), []byte(`{"format_version": 2}`), 0644))
+	_, err = ReadDir(dir)
+	assert.EqualError(t, err, "unsupported snapshot format version 2")
+
+	_, err = Load(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestWriteDir_Secrets(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	api.AddDestination("ws", "ios", "mixpanel", true,
+		segment.DestinationConfig{Name: "apiSecret", Type: "password", Value: "s3cr3t"},
+		segment.DestinationConfig{Name: "token", Type: "string", Value: "${env:SNAPSHOT_TEST_TOKEN}"})
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, s.Save(dir))
+	data, err := ioutil.ReadFile(filepath.Join(dir, SourcesDirName, "ios.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.Contains(t, string(data), segment.RedactedValue)
+	assert.Contains(t, string(data), "${env:SNAPSHOT_TEST_TOKEN}")
+	assert.Equal(t, "s3cr3t", s.Sources[0].Destinations[0].Configs[0].Value, "the snapshot is not modified")
+
+	// redacted settings are left unset by restores, and secret references are resolved by clients
+	// given a resolver
+	os.Setenv("SNAPSHOT_TEST_TOKEN", "mp-token")
+	defer os.Unsetenv("SNAPSHOT_TEST_TOKEN")
+	loaded, err := Load(dir)
+	assert.NoError(t, err)
+	prod := api.Client("prod")
+	prod.SetSecretResolver(segment.DefaultSecretResolver)
+	result, err := Restore(prod, loaded, RestoreOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Changes)
+	mixpanel := api.Workspace("prod").Destinations["ios"][0]
+	assert.Len(t, mixpanel.Configs, 1)
+	assert.Equal(t, "mp-token", mixpanel.Configs[0].Value)
+}
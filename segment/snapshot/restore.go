@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/pkg/errors"
+)
+
+// schemaConfigPaths are the update mask paths of every schema setting of a source
+var schemaConfigPaths = []string{
+	"schema_config.allow_unplanned_track_events",
+	"schema_config.allow_unplanned_identify_traits",
+	"schema_config.allow_unplanned_group_traits",
+	"schema_config.allow_unplanned_track_event_properties",
+	"schema_config.forwarding_blocked_events_to",
+	"schema_config.allow_track_event_on_violations",
+	"schema_config.allow_track_properties_on_violations",
+	"schema_config.allow_identify_traits_on_violations",
+	"schema_config.allow_group_traits_on_violations",
+	"schema_config.forwarding_violations_to",
+	"schema_config.common_track_event_on_violations",
+	"schema_config.common_identify_event_on_violations",
+	"schema_config.common_group_event_on_violations",
+}
+
+// RestoreOptions controls a restore
+type RestoreOptions struct {
+	// DryRun reports what would be restored without changing the workspace
+	DryRun bool
+}
+
+// RestoreResult reports the resources recreated by a restore
+type RestoreResult struct {
+	DryRun  bool          `json:"dry_run,omitempty"`
+	Changes []spec.Change `json:"changes"`
+	// SchemaConfigs are the slugs of the recreated sources whose schema settings were restored
+	SchemaConfigs []string `json:"schema_configs,omitempty"`
+}
+
+// String renders the result as human readable text
+func (r RestoreResult) String() string {
+	if len(r.Changes) == 0 {
+		return "nothing to restore\n"
+	}
+	var b strings.Builder
+	for _, c := range r.Changes {
+		b.WriteString(c.String() + "\n")
+	}
+	for _, src := range r.SchemaConfigs {
+		fmt.Fprintf(&b, "~ schema settings of source %s\n", src)
+	}
+	return b.String()
+}
+
+// Restore recreates the resources of a snapshot that are missing from the workspace of a client,
+// which may be another workspace than the one the snapshot was taken of. Sources, destinations,
+// tracking plans and source connections that exist are left as is, and sources are matched by slug
+// and tracking plans by display name. The schema settings of recreated sources are restored too.
+func Restore(c *segment.Client, s Snapshot, opts RestoreOptions) (RestoreResult, error) {
+	result := RestoreResult{DryRun: opts.DryRun}
+	live, err := spec.ReadState(c)
+	if err != nil {
+		return result, err
+	}
+	plan, err := spec.NewPlan(s.Spec(), live, spec.Options{})
+	if err != nil {
+		return result, err
+	}
+
+	var creates spec.Plan
+	for _, ch := range plan.Changes {
+		if ch.Action == spec.ActionCreate {
+			creates.Changes = append(creates.Changes, ch)
+		}
+	}
+	var sources []Source
+	for _, ch := range creates.Changes {
+		if ch.Kind != spec.KindSource {
+			continue
+		}
+		for _, src := range s.Sources {
+			if segment.Slug(src.Source.Name) == ch.Name && src.SchemaConfig != nil {
+				sources = append(sources, src)
+				result.SchemaConfigs = append(result.SchemaConfigs, ch.Name)
+			}
+		}
+	}
+	if opts.DryRun {
+		result.Changes = creates.Changes
+		return result, nil
+	}
+
+	result.Changes, err = creates.Apply(c)
+	if err != nil {
+		result.SchemaConfigs = nil
+		return result, err
+	}
+	for _, src := range sources {
+		name := segment.Slug(src.Source.Name)
+		if _, err := c.UpdateSourceSchemaConfig(name, schemaConfigPaths, *src.SchemaConfig); err != nil {
+			return result, errors.Wrapf(err, "failed to restore schema settings of source %s", name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestore(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	c := api.Client("ws")
+	s, err := Take(c)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.DeleteSource("js"))
+	api.Update("ws", func(w *fakeapi.Workspace) { w.TrackingPlans = nil })
+
+	dry, err := Restore(c, s, RestoreOptions{DryRun: true})
+	assert.NoError(t, err)
+	expected := `+ source js (catalog/sources/javascript)
++ destination js/amplitude
++ destination js/google-analytics
++ tracking plan "Kicks App"
++ connection js -> "Kicks App"
+~ schema settings of source js
+`
+	assert.Equal(t, expected, dry.String())
+	assert.Equal(t, []string{"DELETE sources/js"}, api.Calls())
+
+	result, err := Restore(c, s, RestoreOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result.String())
+
+	restored, err := Take(c)
+	assert.NoError(t, err)
+	assert.Equal(t, s.Sources, restored.Sources)
+	assert.Equal(t, s.TrackingPlans[0].Rules, restored.TrackingPlans[0].Rules)
+	assert.Equal(t, []Connection{{Source: "js", TrackingPlan: "rs_100"}}, restored.Connections)
+
+	again, err := Restore(c, s, RestoreOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing to restore\n", again.String())
+}
+
+func TestRestore_OtherWorkspace(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+
+	api.AddSource("prod", "ios", "catalog/sources/ios")
+	result, err := Restore(api.Client("prod"), s, RestoreOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Changes, 5)
+
+	prod := api.Workspace("prod")
+	assert.Equal(t, "workspaces/prod/sources/js/destinations/google-analytics/config/trackingId", prod.Destinations["js"][1].Configs[0].Name)
+	assert.Equal(t, "UA-1", prod.Destinations["js"][1].Configs[0].Value)
+	assert.True(t, prod.SchemaConfigs["js"].AllowUnplannedTrackEvents)
+	assert.Equal(t, map[string]string{"js": "rs_100"}, prod.Connections)
+}
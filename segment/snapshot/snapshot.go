@@ -0,0 +1,118 @@
+// Package snapshot backs up the configuration of a Segment workspace and restores it. A Snapshot
+// holds the workspace, its sources with their schema settings and destinations, its tracking plans
+// and their source connections. It is written as a directory of JSON files or a gzipped tarball
+// whose content only depends on the configuration, so that snapshots can be diffed and versioned.
+// The values of secret destination settings are redacted in written snapshots, so restores leave
+// them unset unless they are secret references.
+package snapshot
+
+import (
+	"sort"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/pkg/errors"
+)
+
+// FormatVersion is the version of the snapshot format written by this package
+const FormatVersion = 1
+
+// Snapshot is the configuration of a workspace at a point in time
+type Snapshot struct {
+	FormatVersion int                    `json:"format_version"`
+	Workspace     segment.Workspace      `json:"workspace"`
+	Sources       []Source               `json:"sources"`
+	TrackingPlans []segment.TrackingPlan `json:"tracking_plans"`
+	Connections   []Connection           `json:"connections"`
+}
+
+// Source is a source with its schema settings and destinations
+type Source struct {
+	Source       segment.Source              `json:"source"`
+	SchemaConfig *segment.SourceSchemaConfig `json:"schema_config,omitempty"`
+	Destinations []segment.Destination       `json:"destinations"`
+}
+
+// Connection is a source connected to a tracking plan
+type Connection struct {
+	// Source is the slug of the source, e.g. js
+	Source string `json:"source"`
+	// TrackingPlan is the name of the tracking plan, e.g. rs_123
+	TrackingPlan string `json:"tracking_plan"`
+}
+
+// redacted returns a copy of the source with the values of the secret settings of its destinations
+// redacted
+func (s Source) redacted() Source {
+	if s.Destinations == nil {
+		return s
+	}
+	dests := make([]segment.Destination, len(s.Destinations))
+	for i, d := range s.Destinations {
+		dests[i] = d.Redacted()
+	}
+	s.Destinations = dests
+	return s
+}
+
+// Take reads the configuration of the workspace of a client
+func Take(c *segment.Client) (Snapshot, error) {
+	s := Snapshot{FormatVersion: FormatVersion}
+	w, err := c.GetWorkspace()
+	if err != nil {
+		return s, errors.Wrap(err, "failed to get workspace")
+	}
+	s.Workspace = w
+
+	state, err := spec.ReadState(c)
+	if err != nil {
+		return s, err
+	}
+	for _, src := range state.Sources {
+		name := segment.Slug(src.Name)
+		sc, err := c.GetSourceSchemaConfig(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to get schema settings of source %s", name)
+		}
+		s.Sources = append(s.Sources, Source{Source: src, SchemaConfig: &sc, Destinations: state.Destinations[name]})
+	}
+	s.TrackingPlans = state.TrackingPlans
+	for src, plan := range state.Connections {
+		s.Connections = append(s.Connections, Connection{Source: src, TrackingPlan: plan})
+	}
+	s.sort()
+	return s, nil
+}
+
+// sort orders the resources of a snapshot by name, so that it is written the same way every time
+func (s *Snapshot) sort() {
+	sort.Slice(s.Sources, func(i, j int) bool { return s.Sources[i].Source.Name < s.Sources[j].Source.Name })
+	for _, src := range s.Sources {
+		sort.Slice(src.Destinations, func(i, j int) bool { return src.Destinations[i].Name < src.Destinations[j].Name })
+	}
+	sort.Slice(s.TrackingPlans, func(i, j int) bool { return s.TrackingPlans[i].Name < s.TrackingPlans[j].Name })
+	sort.Slice(s.Connections, func(i, j int) bool { return s.Connections[i].Source < s.Connections[j].Source })
+}
+
+// State returns the configuration of the snapshot as the live state of a workspace
+func (s Snapshot) State() spec.State {
+	state := spec.State{
+		Destinations:  map[string][]segment.Destination{},
+		TrackingPlans: s.TrackingPlans,
+		Connections:   map[string]string{},
+	}
+	for _, src := range s.Sources {
+		state.Sources = append(state.Sources, src.Source)
+		state.Destinations[segment.Slug(src.Source.Name)] = src.Destinations
+	}
+	for _, c := range s.Connections {
+		state.Connections[c.Source] = c.TrackingPlan
+	}
+	return state
+}
+
+// Spec returns a spec that declares every resource of the snapshot, with the full rules of its
+// tracking plans and every destination setting
+func (s Snapshot) Spec() spec.Spec {
+	return s.State().Spec()
+}
@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddDestination("ws", "js", "google-analytics", true,
+		segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-1"})
+	api.AddDestination("ws", "js", "amplitude", false)
+	b := segment.NewRulesBuilder()
+	b.Event("Order Completed").Version(1).Prop("order_id", segment.String().Required())
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", b.Rules())
+	api.Connect("ws", "js", "rs_1")
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		w.SchemaConfigs["js"] = segment.SourceSchemaConfig{AllowUnplannedTrackEvents: true, ForwardingViolationsTo: "violations"}
+	})
+	return api
+}
+
+func TestTake(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := Take(api.Client("ws"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, FormatVersion, s.FormatVersion)
+	assert.Equal(t, "workspaces/ws", s.Workspace.Name)
+
+	assert.Len(t, s.Sources, 2)
+	assert.Equal(t, "workspaces/ws/sources/ios", s.Sources[0].Source.Name)
+	js := s.Sources[1]
+	assert.Equal(t, "workspaces/ws/sources/js", js.Source.Name)
+	assert.True(t, js.SchemaConfig.AllowUnplannedTrackEvents)
+	assert.Equal(t, "violations", js.SchemaConfig.ForwardingViolationsTo)
+	assert.Equal(t, "workspaces/ws/sources/js/destinations/amplitude", js.Destinations[0].Name)
+	assert.Equal(t, "workspaces/ws/sources/js/destinations/google-analytics", js.Destinations[1].Name)
+
+	assert.Len(t, s.TrackingPlans, 1)
+	assert.Equal(t, "Order Completed", s.TrackingPlans[0].Rules.Events[0].Name)
+	assert.Equal(t, []Connection{{Source: "js", TrackingPlan: "rs_1"}}, s.Connections)
+}
+
+func TestSnapshot_State(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+	live, err := spec.ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+	assert.Equal(t, live, s.State())
+}
+
+func TestSnapshot_Spec(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	s, err := Take(api.Client("ws"))
+	assert.NoError(t, err)
+	sp := s.Spec()
+	assert.NoError(t, sp.Validate())
+
+	enabled, disabled := true, false
+	assert.Equal(t, []spec.Source{
+		{Name: "ios", Catalog: "catalog/sources/ios"},
+		{Name: "js", Catalog: "catalog/sources/javascript", Destinations: []spec.Destination{
+			{Name: "amplitude", ConnectionMode: "CLOUD", Enabled: &disabled},
+			{Name: "google-analytics", ConnectionMode: "CLOUD", Enabled: &enabled, Config: map[string]interface{}{"trackingId": "UA-1"}},
+		}},
+	}, sp.Sources)
+	assert.Equal(t, "Kicks App", sp.TrackingPlans[0].DisplayName)
+	assert.Equal(t, []string{"js"}, sp.TrackingPlans[0].Sources)
+
+	// a spec of the snapshot matches the workspace it was taken of
+	live, err := spec.ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+	p, err := spec.NewPlan(sp, live, spec.Options{PruneSources: true, PruneDestinations: true, PruneTrackingPlans: true, PruneConnections: true})
+	assert.NoError(t, err)
+	assert.True(t, p.IsEmpty(), p.String())
+}
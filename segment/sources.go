@@ -80,3 +80,41 @@ func (c *Client) DeleteSource(srcName string) error {
 
 	return nil
 }
+
+// GetSourceSchemaConfig returns the schema settings of a source
+func (c *Client) GetSourceSchemaConfig(srcName string) (SourceSchemaConfig, error) {
+	var sc SourceSchemaConfig
+	data, err := c.doRequest(http.MethodGet,
+		fmt.Sprintf("%s/%s/%s/%s/%s",
+			WorkspacesEndpoint, c.workspace, SourceEndpoint, srcName, SourceSchemaConfigEndpoint),
+		nil)
+	if err != nil {
+		return sc, err
+	}
+	err = json.Unmarshal(data, &sc)
+	if err != nil {
+		return sc, errors.Wrap(err, "failed to unmarshal source schema config response")
+	}
+
+	return sc, nil
+}
+
+// UpdateSourceSchemaConfig updates the schema settings of a source. Only the fields named in paths,
+// such as "schema_config.allow_unplanned_track_events", are changed.
+func (c *Client) UpdateSourceSchemaConfig(srcName string, paths []string, config SourceSchemaConfig) (SourceSchemaConfig, error) {
+	var sc SourceSchemaConfig
+	configFullName := fmt.Sprintf("%s/%s/%s/%s/%s",
+		WorkspacesEndpoint, c.workspace, SourceEndpoint, srcName, SourceSchemaConfigEndpoint)
+	config.Name = configFullName
+	req := sourceSchemaConfigUpdateRequest{SchemaConfig: config, UpdateMask: UpdateMask{Paths: paths}}
+	data, err := c.doRequest(http.MethodPatch, configFullName, req)
+	if err != nil {
+		return sc, err
+	}
+	err = json.Unmarshal(data, &sc)
+	if err != nil {
+		return sc, errors.Wrap(err, "failed to unmarshal source schema config response")
+	}
+
+	return sc, nil
+}
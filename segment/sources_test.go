@@ -1,6 +1,7 @@
 package segment
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -177,3 +178,88 @@ func TestSources_DeleteSource(t *testing.T) {
 	err := client.DeleteSource(testSource)
 	assert.NoError(t, err)
 }
+
+func TestSources_GetSourceSchemaConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testSource := "js"
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s/%s",
+		apiVersion, WorkspacesEndpoint, testWorkspace, SourceEndpoint, testSource, SourceSchemaConfigEndpoint)
+
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"name": "workspaces/myworkspace/sources/js/schema-config",
+			"allow_unplanned_track_events": false,
+			"allow_unplanned_identify_traits": true,
+			"allow_unplanned_group_traits": true,
+			"allow_unplanned_track_event_properties": false,
+			"forwarding_blocked_events_to": "js-blocked",
+			"allow_track_event_on_violations": true,
+			"allow_track_properties_on_violations": true,
+			"allow_identify_traits_on_violations": true,
+			"allow_group_traits_on_violations": false,
+			"forwarding_violations_to": "js-violations",
+			"common_track_event_on_violations": "OMIT_PROPS",
+			"common_identify_event_on_violations": "ALLOW",
+			"common_group_event_on_violations": "BLOCK"
+		}`)
+	})
+
+	actual, err := client.GetSourceSchemaConfig(testSource)
+	assert.NoError(t, err)
+
+	expected := SourceSchemaConfig{
+		Name:                               "workspaces/myworkspace/sources/js/schema-config",
+		AllowUnplannedTrackEvents:          false,
+		AllowUnplannedIdentifyTraits:       true,
+		AllowUnplannedGroupTraits:          true,
+		AllowUnplannedTrackEventProperties: false,
+		ForwardingBlockedEventsTo:          "js-blocked",
+		AllowTrackEventOnViolations:        true,
+		AllowTrackPropertiesOnViolations:   true,
+		AllowIdentifyTraitsOnViolations:    true,
+		AllowGroupTraitsOnViolations:       false,
+		ForwardingViolationsTo:             "js-violations",
+		CommonTrackEventOnViolations:       SchemaViolationOmitProperties,
+		CommonIdentifyEventOnViolations:    SchemaViolationAllow,
+		CommonGroupEventOnViolations:       SchemaViolationBlock,
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestSources_UpdateSourceSchemaConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testSource := "js"
+	testPaths := []string{"schema_config.allow_unplanned_track_events", "schema_config.forwarding_blocked_events_to"}
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s/%s",
+		apiVersion, WorkspacesEndpoint, testWorkspace, SourceEndpoint, testSource, SourceSchemaConfigEndpoint)
+
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		var req sourceSchemaConfigUpdateRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, testPaths, req.UpdateMask.Paths)
+		assert.Equal(t, "workspaces/test-workspace/sources/js/schema-config", req.SchemaConfig.Name)
+		assert.False(t, req.SchemaConfig.AllowUnplannedTrackEvents)
+		fmt.Fprint(w, `{
+			"name": "workspaces/test-workspace/sources/js/schema-config",
+			"allow_unplanned_track_events": false,
+			"forwarding_blocked_events_to": "js-blocked"
+		}`)
+	})
+
+	expected := SourceSchemaConfig{
+		Name:                      "workspaces/test-workspace/sources/js/schema-config",
+		AllowUnplannedTrackEvents: false,
+		ForwardingBlockedEventsTo: "js-blocked",
+	}
+
+	actual, err := client.UpdateSourceSchemaConfig(testSource, testPaths,
+		SourceSchemaConfig{AllowUnplannedTrackEvents: false, ForwardingBlockedEventsTo: "js-blocked"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,170 @@
+package spec
+
+import (
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Apply makes the changes of a plan in the workspace of a client, in order. It stops at the first
+// change that fails and returns the changes applied until then.
+func (p Plan) Apply(c *segment.Client) ([]Change, error) {
+	var applied []Change
+	sourceNames := map[string]string{}
+	planNames := map[string]string{}
+	for _, ch := range p.Changes {
+		if err := applyChange(c, ch, sourceNames, planNames); err != nil {
+			return applied, errors.Wrapf(err, "failed to %s %s %s", ch.Action, ch.Kind, ch.describe())
+		}
+		applied = append(applied, ch)
+	}
+	return applied, nil
+}
+
+// applyChange makes one change. sourceNames and planNames record the names of the sources and
+// tracking plans created so far, for the changes that depend on them.
+func applyChange(c *segment.Client, ch Change, sourceNames, planNames map[string]string) error {
+	switch ch.Kind {
+	case KindSource:
+		switch ch.Action {
+		case ActionCreate:
+			src, err := c.CreateSource(ch.Name, ch.source.Catalog)
+			if err != nil {
+				return err
+			}
+			sourceNames[ch.Name] = src.Name
+			return nil
+		case ActionDelete:
+			return c.DeleteSource(ch.Name)
+		}
+
+	case KindDestination:
+		switch ch.Action {
+		case ActionCreate:
+			sourceName := ch.sourceName
+			if sourceName == "" {
+				sourceName = sourceNames[ch.Source]
+			}
+			enabled := true
+			if ch.destination.Enabled != nil {
+				enabled = *ch.destination.Enabled
+			}
+			destName := fmt.Sprintf("%s/%s/%s", sourceName, segment.DestinationEndpoint, ch.Name)
+			_, err := c.CreateDestination(ch.Source, ch.Name, ch.destination.ConnectionMode, enabled,
+				mergeConfig(destName, nil, ch.destination.Config))
+			return err
+		case ActionUpdate:
+			enabled := ch.live.Enabled
+			if ch.destination.Enabled != nil {
+				enabled = *ch.destination.Enabled
+			}
+			// Only the declared settings are resolved, the live ones are sent back as read
+			declared, err := c.ResolveSecrets(mergeConfig(ch.live.Name, nil, ch.destination.Config))
+			if err != nil {
+				return err
+			}
+			_, err = c.WithSecretResolver(nil).UpdateDestination(ch.Source, ch.Name, enabled,
+				mergeConfig(ch.live.Name, ch.live.Configs, configValues(declared)))
+			return err
+		case ActionDelete:
+			return c.DeleteDestination(ch.Source, ch.Name)
+		}
+
+	case KindTrackingPlan:
+		switch ch.Action {
+		case ActionCreate:
+			tp, err := c.CreateTrackingPlan(ch.Name, ch.rules)
+			if err != nil {
+				return err
+			}
+			planNames[ch.Name] = segment.Slug(tp.Name)
+			return nil
+		case ActionUpdate:
+			_, err := c.UpdateTrackingPlan(ch.TrackingPlan, ch.updateMask, segment.TrackingPlan{DisplayName: ch.Name, Rules: ch.rules})
+			return err
+		case ActionDelete:
+			return c.DeleteTrackingPlan(ch.TrackingPlan)
+		}
+
+	case KindConnection:
+		plan := ch.TrackingPlan
+		if plan == "" {
+			plan = planNames[ch.Name]
+		}
+		switch ch.Action {
+		case ActionCreate:
+			_, err := c.CreateTrackingPlanSourceConnection(plan, ch.Source)
+			return err
+		case ActionUpdate:
+			_, err := c.MoveTrackingPlanSourceConnection(ch.From, plan, ch.Source)
+			return err
+		case ActionDelete:
+			return c.DeleteTrackingPlanSourceConnection(plan, ch.Source)
+		}
+	}
+	return fmt.Errorf("unsupported change")
+}
+
+// describe names the resource of a change for error messages
+func (c Change) describe() string {
+	switch c.Kind {
+	case KindDestination:
+		return c.Source + "/" + c.Name
+	case KindTrackingPlan:
+		return fmt.Sprintf("%q", c.Name)
+	case KindConnection:
+		return fmt.Sprintf("%s to %q", c.Source, c.Name)
+	}
+	return c.Name
+}
+
+// mergeConfig returns the live settings of a destination with the declared values set. Declared
+// settings that do not exist yet are named after the destination and typed after their value.
+func mergeConfig(destName string, live []segment.DestinationConfig, declared map[string]interface{}) []segment.DestinationConfig {
+	var configs []segment.DestinationConfig
+	seen := map[string]bool{}
+	for _, c := range live {
+		key := segment.Slug(c.Name)
+		if v, ok := declared[key]; ok {
+			c.Value = v
+			seen[key] = true
+		}
+		configs = append(configs, c)
+	}
+	for _, key := range configKeys(declared) {
+		if seen[key] {
+			continue
+		}
+		configs = append(configs, segment.DestinationConfig{
+			Name:  fmt.Sprintf("%s/config/%s", destName, key),
+			Type:  configType(declared[key]),
+			Value: declared[key],
+		})
+	}
+	return configs
+}
+
+// configValues returns the values of settings by key
+func configValues(configs []segment.DestinationConfig) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, c := range configs {
+		values[segment.Slug(c.Name)] = c.Value
+	}
+	return values
+}
+
+// configType returns the Config API type of a setting value
+func configType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return "string"
+}
@@ -0,0 +1,101 @@
+package spec
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan_Apply(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	opts := Options{PruneSources: true, PruneDestinations: true, PruneTrackingPlans: true, PruneConnections: true}
+	p := testPlan(t, api, opts)
+	applied, err := p.Apply(api.Client("ws"))
+	assert.NoError(t, err)
+	assert.Equal(t, p.Changes, applied)
+
+	assert.Equal(t, []string{
+		"POST sources",
+		"PATCH sources/js/destinations/google-analytics",
+		"POST sources/js/destinations",
+		"PUT tracking-plans/rs_1",
+		"DELETE tracking-plans/rs_2/source-connections/js",
+		"POST tracking-plans/rs_1/source-connections",
+		"POST tracking-plans/rs_1/source-connections",
+		"DELETE tracking-plans/rs_1/source-connections/android",
+		"DELETE tracking-plans/rs_2",
+		"DELETE sources/js/destinations/mixpanel",
+		"DELETE sources/android",
+	}, api.Calls())
+
+	ga := api.Workspace("ws").Destinations["js"]
+	assert.Equal(t, []segment.DestinationConfig{
+		{Name: "workspaces/ws/sources/js/destinations/google-analytics/config/trackingId", Type: "string", Value: "UA-1234"},
+		{Name: "workspaces/ws/sources/js/destinations/google-analytics/config/sendUserId", Type: "boolean", Value: true},
+		{Name: "workspaces/ws/sources/js/destinations/google-analytics/config/anonymizeIp", Type: "boolean", Value: true},
+	}, ga[0].Configs)
+	assert.False(t, ga[0].Enabled)
+	assert.Equal(t, "workspaces/ws/sources/js/destinations/amplitude", ga[1].Name)
+	assert.False(t, ga[1].Enabled)
+
+	assert.True(t, testPlan(t, api, opts).IsEmpty())
+}
+
+func TestPlan_ApplyNewTrackingPlan(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	api.Update("ws", func(w *fakeapi.Workspace) { w.TrackingPlans = nil; w.Connections = map[string]string{} })
+
+	p := testPlan(t, api, Options{})
+	_, err := p.Apply(api.Client("ws"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"js": "rs_100", "ios": "rs_100"}, api.Workspace("ws").Connections)
+	assert.True(t, testPlan(t, api, Options{}).IsEmpty())
+}
+
+func TestPlan_ApplySecretReferences(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddDestination("ws", "js", "mixpanel", true,
+		segment.DestinationConfig{Name: "token", Type: "string", Value: "${env:SPEC_TEST_TOKEN}"})
+	os.Setenv("SPEC_TEST_TOKEN", "live")
+	defer os.Unsetenv("SPEC_TEST_TOKEN")
+	os.Setenv("SPEC_TEST_API_SECRET", "declared")
+	defer os.Unsetenv("SPEC_TEST_API_SECRET")
+
+	s := Spec{Sources: []Source{{Name: "js", Catalog: "catalog/sources/javascript", Destinations: []Destination{
+		{Name: "mixpanel", Config: map[string]interface{}{"apiSecret": "${env:SPEC_TEST_API_SECRET}"}},
+	}}}}
+	live, err := ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+	p, err := NewPlan(s, live, Options{})
+	assert.NoError(t, err)
+
+	// the declared settings are resolved, and the live ones are sent back as read
+	c := api.Client("ws")
+	c.SetSecretResolver(segment.DefaultSecretResolver)
+	_, err = p.Apply(c)
+	assert.NoError(t, err)
+	values := map[string]interface{}{}
+	for _, c := range api.Workspace("ws").Destinations["js"][0].Configs {
+		values[segment.Slug(c.Name)] = c.Value
+	}
+	assert.Equal(t, map[string]interface{}{"token": "${env:SPEC_TEST_TOKEN}", "apiSecret": "declared"}, values)
+}
+
+func TestPlan_ApplyStopsOnError(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	api.Fail("POST", "sources/js/destinations")
+
+	p := testPlan(t, api, Options{})
+	applied, err := p.Apply(api.Client("ws"))
+	assert.EqualError(t, err, "failed to create destination js/amplitude: the request is invalid")
+	assert.Len(t, applied, 2)
+}
@@ -0,0 +1,342 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+)
+
+// Action is what a change does to a resource
+type Action string
+
+// Actions
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Kind is the type of a resource
+type Kind string
+
+// Kinds of resources, in the order they are created
+const (
+	KindSource       Kind = "source"
+	KindDestination  Kind = "destination"
+	KindTrackingPlan Kind = "tracking-plan"
+	KindConnection   Kind = "connection"
+)
+
+// Options controls how a plan is computed. Resources that exist in the workspace but not in the
+// spec are only deleted when pruning is enabled for their kind.
+type Options struct {
+	PruneSources       bool
+	PruneDestinations  bool
+	PruneTrackingPlans bool
+	PruneConnections   bool
+}
+
+// Change is one create, update or delete of a resource
+type Change struct {
+	Action Action `json:"action"`
+	Kind   Kind   `json:"kind"`
+	// Source is the slug of the source of a destination or connection
+	Source string `json:"source,omitempty"`
+	// Name is the slug of a source or destination, or the display name of a tracking plan or the
+	// tracking plan of a connection
+	Name string `json:"name"`
+	// TrackingPlan is the name of an existing tracking plan, e.g. rs_123
+	TrackingPlan string `json:"tracking_plan,omitempty"`
+	// From is the name of the tracking plan a moved connection is removed from
+	From string `json:"from,omitempty"`
+	// Details describes the fields that change
+	Details []string `json:"details,omitempty"`
+
+	source      Source
+	destination Destination
+	live        segment.Destination
+	sourceName  string
+	rules       segment.Rules
+	updateMask  []string
+}
+
+// String renders the change as one line
+func (c Change) String() string {
+	sign := map[Action]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+	var s string
+	switch c.Kind {
+	case KindSource:
+		s = fmt.Sprintf("%s source %s", sign, c.Name)
+		if c.source.Catalog != "" {
+			s += fmt.Sprintf(" (%s)", c.source.Catalog)
+		}
+	case KindDestination:
+		s = fmt.Sprintf("%s destination %s/%s", sign, c.Source, c.Name)
+	case KindTrackingPlan:
+		s = fmt.Sprintf("%s tracking plan %q", sign, c.Name)
+		if c.TrackingPlan != "" {
+			s += fmt.Sprintf(" (%s)", c.TrackingPlan)
+		}
+	case KindConnection:
+		s = fmt.Sprintf("%s connection %s -> %q", sign, c.Source, c.Name)
+		if c.From != "" {
+			s += fmt.Sprintf(" (from %s)", c.From)
+		}
+	}
+	return s
+}
+
+// Plan is the ordered list of changes that reconcile a workspace with a spec. Sources are created
+// before their destinations and tracking plans before their connections, and deletes come last in
+// the reverse order.
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// IsEmpty reports whether the workspace already matches the spec
+func (p Plan) IsEmpty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan as human readable text
+func (p Plan) String() string {
+	if p.IsEmpty() {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String() + "\n")
+		for _, d := range c.Details {
+			fmt.Fprintf(&b, "    %s\n", d)
+		}
+	}
+	return b.String()
+}
+
+// NewPlan returns the changes that make the live workspace match a spec
+func NewPlan(s Spec, live State, opts Options) (Plan, error) {
+	if err := s.Validate(); err != nil {
+		return Plan{}, err
+	}
+	var sources, destinations, plans, connections []Change
+	var sourceDeletes, destinationDeletes, planDeletes, connectionDeletes []Change
+
+	declared := map[string]bool{}
+	for _, src := range s.Sources {
+		declared[src.Name] = true
+		liveSource, ok := live.Source(src.Name)
+		if !ok {
+			sources = append(sources, Change{Action: ActionCreate, Kind: KindSource, Name: src.Name, source: src})
+		} else if liveSource.CatalogName != "" && liveSource.CatalogName != src.Catalog {
+			return Plan{}, fmt.Errorf("source %s is a %s, which cannot be changed to %s", src.Name, liveSource.CatalogName, src.Catalog)
+		}
+
+		for _, d := range src.Destinations {
+			c, err := destinationChange(src.Name, liveSource.Name, d, live)
+			if err != nil {
+				return Plan{}, err
+			}
+			if c != nil {
+				destinations = append(destinations, *c)
+			}
+		}
+		if ok && opts.PruneDestinations {
+			for _, d := range live.Destinations[src.Name] {
+				if !hasDestination(src, segment.Slug(d.Name)) {
+					destinationDeletes = append(destinationDeletes, Change{Action: ActionDelete, Kind: KindDestination, Source: src.Name, Name: segment.Slug(d.Name)})
+				}
+			}
+		}
+	}
+
+	connected := map[string]string{}
+	planNames := map[string]bool{}
+	for _, p := range s.TrackingPlans {
+		planNames[p.DisplayName] = true
+		for _, src := range p.Sources {
+			connected[src] = p.DisplayName
+		}
+	}
+	for _, p := range s.TrackingPlans {
+		matches := live.TrackingPlansNamed(p.DisplayName)
+		if len(matches) > 1 {
+			return Plan{}, fmt.Errorf("several tracking plans are named %q", p.DisplayName)
+		}
+		target := ""
+		if len(matches) == 0 {
+			c := Change{Action: ActionCreate, Kind: KindTrackingPlan, Name: p.DisplayName}
+			if p.Rules != nil {
+				c.rules = *p.Rules
+			}
+			plans = append(plans, c)
+		} else {
+			target = segment.Slug(matches[0].Name)
+			if p.Rules != nil {
+				diff := segment.DiffTrackingPlans(matches[0], segment.TrackingPlan{DisplayName: p.DisplayName, Rules: *p.Rules})
+				if !diff.IsEmpty() {
+					plans = append(plans, Change{
+						Action:       ActionUpdate,
+						Kind:         KindTrackingPlan,
+						Name:         p.DisplayName,
+						TrackingPlan: target,
+						Details:      strings.Split(strings.TrimSuffix(diff.String(), "\n"), "\n"),
+						rules:        *p.Rules,
+						updateMask:   diff.UpdateMaskPaths(),
+					})
+				}
+			}
+		}
+
+		for _, src := range p.Sources {
+			if _, ok := live.Source(src); !ok && !declared[src] {
+				return Plan{}, fmt.Errorf("tracking plan %q connects unknown source %s", p.DisplayName, src)
+			}
+			current := live.Connections[src]
+			switch {
+			case current != "" && current == target:
+			case current != "":
+				connections = append(connections, Change{Action: ActionUpdate, Kind: KindConnection, Source: src, Name: p.DisplayName, TrackingPlan: target, From: current})
+			default:
+				connections = append(connections, Change{Action: ActionCreate, Kind: KindConnection, Source: src, Name: p.DisplayName, TrackingPlan: target})
+			}
+		}
+	}
+
+	for _, p := range live.TrackingPlans {
+		name := segment.Slug(p.Name)
+		pruned := !planNames[p.DisplayName]
+		if pruned && !opts.PruneTrackingPlans || !pruned && !opts.PruneConnections {
+			continue
+		}
+		// sources declared on another plan are moved rather than disconnected
+		for _, src := range connectedSources(live, name) {
+			if _, ok := connected[src]; !ok {
+				connectionDeletes = append(connectionDeletes, Change{Action: ActionDelete, Kind: KindConnection, Source: src, Name: p.DisplayName, TrackingPlan: name})
+			}
+		}
+		if pruned {
+			planDeletes = append(planDeletes, Change{Action: ActionDelete, Kind: KindTrackingPlan, Name: p.DisplayName, TrackingPlan: name})
+		}
+	}
+	if opts.PruneSources {
+		for _, src := range live.Sources {
+			if name := segment.Slug(src.Name); !declared[name] {
+				sourceDeletes = append(sourceDeletes, Change{Action: ActionDelete, Kind: KindSource, Name: name})
+			}
+		}
+	}
+
+	var p Plan
+	for _, changes := range [][]Change{sources, destinations, plans, connections,
+		connectionDeletes, planDeletes, destinationDeletes, sourceDeletes} {
+		p.Changes = append(p.Changes, changes...)
+	}
+	return p, nil
+}
+
+// destinationChange returns the change that makes a live destination match its declaration, or nil
+func destinationChange(source, sourceName string, d Destination, live State) (*Change, error) {
+	d.Config = managedConfig(d.Config)
+	liveDest, ok := live.Destination(source, d.Name)
+	if !ok {
+		return &Change{Action: ActionCreate, Kind: KindDestination, Source: source, Name: d.Name, destination: d, sourceName: sourceName}, nil
+	}
+	if d.ConnectionMode != "" && liveDest.ConnectionMode != "" && !strings.EqualFold(d.ConnectionMode, liveDest.ConnectionMode) {
+		return nil, fmt.Errorf("destination %s/%s has connection mode %s, which cannot be changed to %s",
+			source, d.Name, liveDest.ConnectionMode, d.ConnectionMode)
+	}
+
+	var details []string
+	if d.Enabled != nil && *d.Enabled != liveDest.Enabled {
+		details = append(details, fmt.Sprintf("enabled: %t -> %t", liveDest.Enabled, *d.Enabled))
+	}
+	for _, key := range configKeys(d.Config) {
+		liveConfig, ok := configNamed(liveDest, key)
+		declared := redactedValue(liveConfig, d.Config[key])
+		if !ok {
+			details = append(details, fmt.Sprintf("config.%s: (unset) -> %s", key, declared))
+		} else if !segment.IsSecretReference(d.Config[key]) && !reflect.DeepEqual(jsonvalue.Normalize(liveConfig.Value), d.Config[key]) {
+			details = append(details, fmt.Sprintf("config.%s: %s -> %s", key, redactedValue(liveConfig, liveConfig.Value), declared))
+		}
+	}
+	if len(details) == 0 {
+		return nil, nil
+	}
+	return &Change{Action: ActionUpdate, Kind: KindDestination, Source: source, Name: d.Name, Details: details,
+		destination: d, live: liveDest, sourceName: sourceName}, nil
+}
+
+func hasDestination(src Source, name string) bool {
+	for _, d := range src.Destinations {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// connectedSources returns the sorted slugs of the sources connected to a live tracking plan
+func connectedSources(live State, plan string) []string {
+	var sources []string
+	for src, p := range live.Connections {
+		if p == plan {
+			sources = append(sources, src)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func configKeys(config map[string]interface{}) []string {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configNamed returns a live destination setting, whose name ends in /config/<key>
+func configNamed(d segment.Destination, key string) (segment.DestinationConfig, bool) {
+	for _, c := range d.Configs {
+		if segment.Slug(c.Name) == key {
+			return c, true
+		}
+	}
+	return segment.DestinationConfig{Name: key}, false
+}
+
+// managedConfig returns the declared settings without the redacted ones, whose values are unknown.
+// Settings whose values are secret references are managed, but only set when they are missing as
+// the secrets they reference cannot be compared with the live values.
+func managedConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	managed := map[string]interface{}{}
+	for k, v := range config {
+		if v != segment.RedactedValue {
+			managed[k] = v
+		}
+	}
+	return managed
+}
+
+// redactedValue formats a value of a setting, redacted if the setting holds a credential
+func redactedValue(c segment.DestinationConfig, v interface{}) string {
+	c.Value = v
+	return formatValue(c.Redacted().Value)
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
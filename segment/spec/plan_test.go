@@ -0,0 +1,184 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupWorkspace serves a workspace that differs from testdata/workspace.yaml
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "android", "catalog/sources/android")
+	api.AddDestination("ws", "js", "google-analytics", false,
+		segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-1"},
+		segment.DestinationConfig{Name: "sendUserId", Type: "boolean", Value: true})
+	api.AddDestination("ws", "js", "mixpanel", true)
+	b := segment.NewRulesBuilder()
+	b.Event("Order Completed").Version(1).Prop("order_id", segment.String().Required())
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", b.Rules())
+	api.AddTrackingPlan("ws", "rs_2", "Legacy", segment.Rules{})
+	api.Connect("ws", "js", "rs_2")
+	api.Connect("ws", "android", "rs_1")
+	return api
+}
+
+func testPlan(t *testing.T, api *fakeapi.Server, opts Options) Plan {
+	s, err := Load("testdata/workspace.yaml")
+	assert.NoError(t, err)
+	live, err := ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+	p, err := NewPlan(s, live, opts)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestNewPlan(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	p := testPlan(t, api, Options{})
+	expected := `+ source ios (catalog/sources/ios)
+~ destination js/google-analytics
+    config.anonymizeIp: (unset) -> true
+    config.trackingId: "UA-1" -> "UA-1234"
++ destination js/amplitude
+~ tracking plan "Kicks App" (rs_1)
+    ~ event "Order Completed" (v1)
+        ~ description: "" -> "An order was placed"
+        + properties.total
+~ connection js -> "Kicks App" (from rs_2)
++ connection ios -> "Kicks App"
+`
+	assert.Equal(t, expected, p.String())
+}
+
+func TestNewPlan_Prune(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	p := testPlan(t, api, Options{PruneSources: true, PruneDestinations: true, PruneTrackingPlans: true, PruneConnections: true})
+	var deletes []string
+	for _, c := range p.Changes {
+		if c.Action == ActionDelete {
+			deletes = append(deletes, c.String())
+		}
+	}
+	assert.Equal(t, []string{
+		`- connection android -> "Kicks App"`,
+		`- tracking plan "Legacy" (rs_2)`,
+		`- destination js/mixpanel`,
+		`- source android`,
+	}, deletes)
+}
+
+func TestNewPlan_NoChanges(t *testing.T) {
+	s := Spec{
+		Sources:       []Source{{Name: "js", Catalog: "catalog/sources/javascript"}},
+		TrackingPlans: []TrackingPlan{{DisplayName: "Kicks App", Sources: []string{"js"}}},
+	}
+	live := State{
+		Sources:       []segment.Source{{Name: "workspaces/ws/sources/js", CatalogName: "catalog/sources/javascript"}},
+		TrackingPlans: []segment.TrackingPlan{{Name: "workspaces/ws/tracking-plans/rs_1", DisplayName: "Kicks App"}},
+		Connections:   map[string]string{"js": "rs_1"},
+	}
+	p, err := NewPlan(s, live, Options{PruneSources: true, PruneConnections: true})
+	assert.NoError(t, err)
+	assert.True(t, p.IsEmpty())
+	assert.Equal(t, "no changes\n", p.String())
+}
+
+func TestNewPlan_Conflicts(t *testing.T) {
+	live := State{
+		Sources: []segment.Source{{Name: "workspaces/ws/sources/js", CatalogName: "catalog/sources/javascript"}},
+		Destinations: map[string][]segment.Destination{
+			"js": {{Name: "workspaces/ws/sources/js/destinations/ga", ConnectionMode: "CLOUD"}},
+		},
+		TrackingPlans: []segment.TrackingPlan{
+			{Name: "workspaces/ws/tracking-plans/rs_1", DisplayName: "Kicks App"},
+			{Name: "workspaces/ws/tracking-plans/rs_2", DisplayName: "Kicks App"},
+		},
+	}
+	tests := []struct {
+		spec Spec
+		err  string
+	}{
+		{Spec{Sources: []Source{{Name: "js", Catalog: "catalog/sources/ios"}}},
+			"source js is a catalog/sources/javascript, which cannot be changed to catalog/sources/ios"},
+		{Spec{Sources: []Source{{Name: "js", Catalog: "catalog/sources/javascript", Destinations: []Destination{{Name: "ga", ConnectionMode: "DEVICE"}}}}},
+			"destination js/ga has connection mode CLOUD, which cannot be changed to DEVICE"},
+		{Spec{TrackingPlans: []TrackingPlan{{DisplayName: "Kicks App"}}},
+			`several tracking plans are named "Kicks App"`},
+		{Spec{TrackingPlans: []TrackingPlan{{DisplayName: "Other", Sources: []string{"ios"}}}},
+			`tracking plan "Other" connects unknown source ios`},
+	}
+	for _, tt := range tests {
+		_, err := NewPlan(tt.spec, live, Options{})
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestNewPlan_Secrets(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddDestination("ws", "js", "mixpanel", true,
+		segment.DestinationConfig{Name: "apiSecret", Type: "password", Value: "old-secret"},
+		segment.DestinationConfig{Name: "token", Type: "string", Value: "old-token"},
+		segment.DestinationConfig{Name: "people", Type: "boolean", Value: false})
+	live, err := ReadState(api.Client("ws"))
+	assert.NoError(t, err)
+
+	s, err := Parse([]byte(`
+sources:
+  - name: js
+    catalog: catalog/sources/javascript
+    destinations:
+      - name: mixpanel
+        config:
+          apiSecret: new-secret
+          token: ${env:MIXPANEL_TOKEN}
+          people: true
+`))
+	assert.NoError(t, err)
+	p, err := NewPlan(s, live, Options{})
+	assert.NoError(t, err)
+	expected := `~ destination js/mixpanel
+    config.apiSecret: "********" -> "********"
+    config.people: false -> true
+`
+	assert.Equal(t, expected, p.String(), "secret references are not compared with live values")
+
+	s.Sources[0].Destinations[0].Config = map[string]interface{}{"apiSecret": segment.RedactedValue, "people": false}
+	p, err = NewPlan(s, live, Options{})
+	assert.NoError(t, err)
+	assert.True(t, p.IsEmpty(), "redacted values are not managed")
+}
+
+func TestNewPlan_TrackingPlanRules(t *testing.T) {
+	version := func(v int) segment.Event {
+		return segment.NewRulesBuilder().Event("Order Completed").Version(v).Prop("order_id", segment.String()).Event()
+	}
+	live := State{TrackingPlans: []segment.TrackingPlan{{
+		Name:        "workspaces/ws/tracking-plans/rs_1",
+		DisplayName: "Kicks App",
+		Rules:       segment.Rules{Events: []segment.Event{version(1), version(2)}},
+	}}}
+	rules := segment.Rules{
+		Events:         []segment.Event{version(2)},
+		IdentifyTraits: []segment.Rule{segment.String().Rule()},
+	}
+	s := Spec{TrackingPlans: []TrackingPlan{{DisplayName: "Kicks App", Rules: &rules}}}
+
+	p, err := NewPlan(s, live, Options{})
+	assert.NoError(t, err)
+	expected := `~ tracking plan "Kicks App" (rs_1)
+    - event "Order Completed" (v1)
+    ~ identify traits
+`
+	assert.Equal(t, expected, p.String(), "removed event versions and trait changes are planned")
+	assert.Equal(t, []string{segment.TrackingPlanRulesEventsPath, segment.TrackingPlanRulesIdentifyTraitsPath}, p.Changes[0].updateMask)
+}
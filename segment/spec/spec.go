@@ -0,0 +1,155 @@
+// Package spec manages a Segment workspace as code. A Spec declares sources, their destinations,
+// tracking plans and source connections in YAML. NewPlan compares a spec with the live State of a
+// workspace and returns the creates, updates and deletes that reconcile them, and Plan.Apply makes
+// those changes through the Config API.
+package spec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+	"github.com/fenderdigital/segment-apis-go/segment/planfile"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Spec is the declared configuration of a workspace
+type Spec struct {
+	Sources       []Source       `yaml:"sources,omitempty" json:"sources,omitempty"`
+	TrackingPlans []TrackingPlan `yaml:"tracking_plans,omitempty" json:"tracking_plans,omitempty"`
+}
+
+// Source is a declared source and its destinations
+type Source struct {
+	// Name is the slug of the source, e.g. js
+	Name string `yaml:"name" json:"name"`
+	// Catalog is the catalog name of the source, e.g. catalog/sources/javascript
+	Catalog      string        `yaml:"catalog" json:"catalog"`
+	Destinations []Destination `yaml:"destinations,omitempty" json:"destinations,omitempty"`
+}
+
+// Destination is a declared destination of a source
+type Destination struct {
+	// Name is the slug of the destination, e.g. google-analytics
+	Name           string `yaml:"name" json:"name"`
+	ConnectionMode string `yaml:"connection_mode,omitempty" json:"connection_mode,omitempty"`
+	// Enabled defaults to true when the destination is created, and is left as is on updates when unset
+	Enabled *bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// Config holds the settings of the destination by name, e.g. trackingId. Settings that are not
+	// declared are left as is.
+	Config map[string]interface{} `yaml:"config,omitempty" json:"config,omitempty"`
+}
+
+// TrackingPlan is a declared tracking plan and the sources connected to it
+type TrackingPlan struct {
+	// DisplayName identifies the tracking plan in the workspace
+	DisplayName string `yaml:"display_name" json:"display_name"`
+	// File is a tracking plan file or directory written by the planfile package, relative to the spec.
+	// The rules of the plan are not managed when it is empty.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	// Sources are the slugs of the sources connected to the plan
+	Sources []string `yaml:"sources,omitempty" json:"sources,omitempty"`
+
+	// Rules are the rules loaded from File
+	Rules *segment.Rules `yaml:"-" json:"-"`
+}
+
+// Load reads a spec file and the tracking plan files it refers to
+func Load(path string) (Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Spec{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return s, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	dir := filepath.Dir(path)
+	for i := range s.TrackingPlans {
+		p := &s.TrackingPlans[i]
+		if p.File == "" {
+			continue
+		}
+		file := p.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		tp, err := planfile.Load(file)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to load tracking plan %q", p.DisplayName)
+		}
+		p.Rules = &tp.Rules
+	}
+	return s, nil
+}
+
+// Parse decodes and validates a spec. Tracking plan files are not loaded.
+func Parse(data []byte) (Spec, error) {
+	var s Spec
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return s, errors.Wrap(err, "failed to unmarshal spec")
+	}
+	for i := range s.Sources {
+		for j := range s.Sources[i].Destinations {
+			d := &s.Sources[i].Destinations[j]
+			for k, v := range d.Config {
+				d.Config[k] = jsonvalue.Normalize(v)
+			}
+		}
+	}
+	return s, s.Validate()
+}
+
+// Marshal encodes a spec as YAML
+func (s Spec) Marshal() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
+// Validate checks that every resource is named and declared once
+func (s Spec) Validate() error {
+	sources := map[string]bool{}
+	for _, src := range s.Sources {
+		if src.Name == "" {
+			return fmt.Errorf("source without a name")
+		}
+		if src.Catalog == "" {
+			return fmt.Errorf("source %s has no catalog name", src.Name)
+		}
+		if sources[src.Name] {
+			return fmt.Errorf("source %s is declared more than once", src.Name)
+		}
+		sources[src.Name] = true
+		destinations := map[string]bool{}
+		for _, d := range src.Destinations {
+			if d.Name == "" {
+				return fmt.Errorf("destination of source %s without a name", src.Name)
+			}
+			if destinations[d.Name] {
+				return fmt.Errorf("destination %s of source %s is declared more than once", d.Name, src.Name)
+			}
+			destinations[d.Name] = true
+		}
+	}
+
+	plans := map[string]bool{}
+	connected := map[string]string{}
+	for _, p := range s.TrackingPlans {
+		if p.DisplayName == "" {
+			return fmt.Errorf("tracking plan without a display name")
+		}
+		if plans[p.DisplayName] {
+			return fmt.Errorf("tracking plan %q is declared more than once", p.DisplayName)
+		}
+		plans[p.DisplayName] = true
+		for _, src := range p.Sources {
+			if other, ok := connected[src]; ok {
+				return fmt.Errorf("source %s is connected to tracking plans %q and %q", src, other, p.DisplayName)
+			}
+			connected[src] = p.DisplayName
+		}
+	}
+	return nil
+}
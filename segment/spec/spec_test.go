@@ -0,0 +1,89 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	s, err := Load("testdata/workspace.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, s.Sources, 2)
+	js := s.Sources[0]
+	assert.Equal(t, "js", js.Name)
+	assert.Equal(t, "catalog/sources/javascript", js.Catalog)
+	assert.Equal(t, map[string]interface{}{"trackingId": "UA-1234", "anonymizeIp": true}, js.Destinations[0].Config)
+	assert.Nil(t, js.Destinations[0].Enabled)
+	assert.Equal(t, false, *js.Destinations[1].Enabled)
+
+	assert.Len(t, s.TrackingPlans, 1)
+	p := s.TrackingPlans[0]
+	assert.Equal(t, []string{"js", "ios"}, p.Sources)
+	if assert.NotNil(t, p.Rules) {
+		assert.Equal(t, "Order Completed", p.Rules.Events[0].Name)
+	}
+}
+
+func TestLoad_MissingPlanFile(t *testing.T) {
+	_, err := Load("testdata/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestParse_Numbers(t *testing.T) {
+	s, err := Parse([]byte(`
+sources:
+  - name: js
+    catalog: catalog/sources/javascript
+    destinations:
+      - name: webhooks
+        config:
+          retries: 3
+          hooks: [{url: "https://example.com", headers: {a: 1}}]
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"retries": float64(3),
+		"hooks":   []interface{}{map[string]interface{}{"url": "https://example.com", "headers": map[string]interface{}{"a": float64(1)}}},
+	}, s.Sources[0].Destinations[0].Config)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"sources: [{catalog: catalog/sources/ios}]", "source without a name"},
+		{"sources: [{name: ios}]", "source ios has no catalog name"},
+		{"sources: [{name: ios, catalog: c}, {name: ios, catalog: c}]", "source ios is declared more than once"},
+		{"sources: [{name: ios, catalog: c, destinations: [{}]}]", "destination of source ios without a name"},
+		{"sources: [{name: ios, catalog: c, destinations: [{name: a}, {name: a}]}]", "destination a of source ios is declared more than once"},
+		{"tracking_plans: [{sources: [ios]}]", "tracking plan without a display name"},
+		{"tracking_plans: [{display_name: A}, {display_name: A}]", `tracking plan "A" is declared more than once`},
+		{"tracking_plans: [{display_name: A, sources: [ios]}, {display_name: B, sources: [ios]}]", `source ios is connected to tracking plans "A" and "B"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.spec))
+		assert.EqualError(t, err, tt.err, tt.spec)
+	}
+
+	_, err := Parse([]byte("sources: [{name: ios, catalog: c, unknown: true}]"))
+	assert.Error(t, err)
+}
+
+func TestSpec_Marshal(t *testing.T) {
+	s, err := Load("testdata/workspace.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := s.Marshal()
+	assert.NoError(t, err)
+
+	parsed, err := Parse(data)
+	assert.NoError(t, err)
+	s.TrackingPlans[0].Rules = nil
+	assert.Equal(t, s, parsed)
+}
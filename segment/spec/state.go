@@ -0,0 +1,128 @@
+package spec
+
+import (
+	"sort"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// State is the live configuration of a workspace
+type State struct {
+	Sources []segment.Source `json:"sources"`
+	// Destinations are the destinations of each source, by source slug
+	Destinations map[string][]segment.Destination `json:"destinations"`
+	// TrackingPlans are the tracking plans of the workspace, with their rules
+	TrackingPlans []segment.TrackingPlan `json:"tracking_plans"`
+	// Connections maps the slug of each connected source to the name of its tracking plan, e.g. rs_123
+	Connections map[string]string `json:"connections"`
+}
+
+// ReadState reads the live configuration of the workspace of a client
+func ReadState(c *segment.Client) (State, error) {
+	s := State{Destinations: map[string][]segment.Destination{}, Connections: map[string]string{}}
+
+	sources, err := c.ListSources()
+	if err != nil {
+		return s, errors.Wrap(err, "failed to list sources")
+	}
+	s.Sources = sources.Sources
+	sort.Slice(s.Sources, func(i, j int) bool { return s.Sources[i].Name < s.Sources[j].Name })
+	for _, src := range s.Sources {
+		name := segment.Slug(src.Name)
+		dests, err := c.ListDestinations(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to list destinations of source %s", name)
+		}
+		sort.Slice(dests.Destinations, func(i, j int) bool { return dests.Destinations[i].Name < dests.Destinations[j].Name })
+		s.Destinations[name] = dests.Destinations
+	}
+
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return s, errors.Wrap(err, "failed to list tracking plans")
+	}
+	for _, p := range plans.TrackingPlans {
+		name := segment.Slug(p.Name)
+		tp, err := c.GetTrackingPlan(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to get tracking plan %s", name)
+		}
+		s.TrackingPlans = append(s.TrackingPlans, tp)
+
+		conns, err := c.ListTrackingPlanSourceConnections(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to list source connections of tracking plan %s", name)
+		}
+		for _, conn := range conns.Connections {
+			s.Connections[segment.Slug(conn.SourceName)] = name
+		}
+	}
+	sort.Slice(s.TrackingPlans, func(i, j int) bool { return s.TrackingPlans[i].Name < s.TrackingPlans[j].Name })
+	return s, nil
+}
+
+// Source returns the live source with a slug
+func (s State) Source(name string) (segment.Source, bool) {
+	for _, src := range s.Sources {
+		if segment.Slug(src.Name) == name {
+			return src, true
+		}
+	}
+	return segment.Source{}, false
+}
+
+// Destination returns the live destination of a source with a slug
+func (s State) Destination(source, name string) (segment.Destination, bool) {
+	for _, d := range s.Destinations[source] {
+		if segment.Slug(d.Name) == name {
+			return d, true
+		}
+	}
+	return segment.Destination{}, false
+}
+
+// TrackingPlansNamed returns the live tracking plans with a display name
+func (s State) TrackingPlansNamed(displayName string) []segment.TrackingPlan {
+	var plans []segment.TrackingPlan
+	for _, p := range s.TrackingPlans {
+		if p.DisplayName == displayName {
+			plans = append(plans, p)
+		}
+	}
+	return plans
+}
+
+// Spec returns a spec that declares every resource of the state, with the full rules of its
+// tracking plans and every destination setting
+func (s State) Spec() Spec {
+	var sp Spec
+	for _, src := range s.Sources {
+		name := segment.Slug(src.Name)
+		source := Source{Name: name, Catalog: src.CatalogName}
+		for _, d := range s.Destinations[name] {
+			enabled := d.Enabled
+			dest := Destination{Name: segment.Slug(d.Name), ConnectionMode: d.ConnectionMode, Enabled: &enabled}
+			for _, c := range d.Configs {
+				if dest.Config == nil {
+					dest.Config = map[string]interface{}{}
+				}
+				dest.Config[segment.Slug(c.Name)] = c.Value
+			}
+			source.Destinations = append(source.Destinations, dest)
+		}
+		sp.Sources = append(sp.Sources, source)
+	}
+	for _, p := range s.TrackingPlans {
+		rules := p.Rules
+		plan := TrackingPlan{DisplayName: p.DisplayName, Rules: &rules}
+		for src, name := range s.Connections {
+			if name == segment.Slug(p.Name) {
+				plan.Sources = append(plan.Sources, src)
+			}
+		}
+		sort.Strings(plan.Sources)
+		sp.TrackingPlans = append(sp.TrackingPlans, plan)
+	}
+	return sp
+}
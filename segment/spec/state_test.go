@@ -0,0 +1,54 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadState(t *testing.T) {
+	api := fakeapi.New()
+	defer api.Close()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddDestination("ws", "js", "google-analytics", true, segment.DestinationConfig{Name: "trackingId", Type: "string", Value: "UA-1"})
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", segment.Rules{})
+	api.Connect("ws", "ios", "rs_1")
+
+	s, err := ReadState(api.Client("ws"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "workspaces/ws/sources/ios", s.Sources[0].Name)
+	assert.Equal(t, "workspaces/ws/sources/js", s.Sources[1].Name)
+	assert.Empty(t, s.Destinations["ios"])
+	d, ok := s.Destination("js", "google-analytics")
+	assert.True(t, ok)
+	assert.Equal(t, "workspaces/ws/sources/js/destinations/google-analytics/config/trackingId", d.Configs[0].Name)
+	assert.Len(t, s.TrackingPlansNamed("Kicks App"), 1)
+	assert.Equal(t, map[string]string{"ios": "rs_1"}, s.Connections)
+
+	_, ok = s.Source("android")
+	assert.False(t, ok)
+}
+
+func TestState_Spec(t *testing.T) {
+	enabled := true
+	s := State{
+		Sources: []segment.Source{{Name: "workspaces/ws/sources/js", CatalogName: "catalog/sources/javascript"}},
+		Destinations: map[string][]segment.Destination{"js": {{
+			Name: "workspaces/ws/sources/js/destinations/google-analytics", ConnectionMode: "CLOUD", Enabled: true,
+			Configs: []segment.DestinationConfig{{Name: "workspaces/ws/sources/js/destinations/google-analytics/config/trackingId", Value: "UA-1"}},
+		}}},
+		TrackingPlans: []segment.TrackingPlan{{Name: "workspaces/ws/tracking-plans/rs_1", DisplayName: "Kicks App"}},
+		Connections:   map[string]string{"js": "rs_1", "ios": "rs_1"},
+	}
+	sp := s.Spec()
+	assert.Equal(t, []Source{{Name: "js", Catalog: "catalog/sources/javascript", Destinations: []Destination{
+		{Name: "google-analytics", ConnectionMode: "CLOUD", Enabled: &enabled, Config: map[string]interface{}{"trackingId": "UA-1"}},
+	}}}, sp.Sources)
+	assert.Equal(t, []TrackingPlan{{DisplayName: "Kicks App", Sources: []string{"ios", "js"}, Rules: &segment.Rules{}}}, sp.TrackingPlans)
+}
@@ -0,0 +1,238 @@
+// Package topology describes how data flows in a Segment workspace as a graph: sources deliver
+// events to their destinations, and tracking plans govern the sources connected to them. Graphs
+// are rendered for Graphviz as DOT, for Mermaid, or as JSON.
+package topology
+
+import (
+	"sort"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/spec"
+	"github.com/pkg/errors"
+)
+
+// NodeKind is the kind of resource a node stands for
+type NodeKind string
+
+// Node kinds
+const (
+	KindSource       NodeKind = "source"
+	KindDestination  NodeKind = "destination"
+	KindTrackingPlan NodeKind = "tracking-plan"
+)
+
+// EdgeKind is the relation between the nodes of an edge
+type EdgeKind string
+
+// Edge kinds
+const (
+	// EdgeDelivers goes from a source to a destination it sends events to
+	EdgeDelivers EdgeKind = "delivers"
+	// EdgeGoverns goes from a tracking plan to a source connected to it
+	EdgeGoverns EdgeKind = "governs"
+)
+
+// Node is a source, destination or tracking plan of a workspace
+type Node struct {
+	// ID identifies the node in the graph, e.g. source:js or destination:js/google-analytics
+	ID   string   `json:"id"`
+	Kind NodeKind `json:"kind"`
+	// Name is the slug of the resource, e.g. js, google-analytics or rs_123
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// Source is the slug of the source of a destination
+	Source string `json:"source,omitempty"`
+	// Catalog is the catalog type of a source or destination, e.g. javascript or google-analytics
+	Catalog        string `json:"catalog,omitempty"`
+	ConnectionMode string `json:"connection_mode,omitempty"`
+	// Disabled is set on destinations that do not receive events
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Edge links two nodes by their ID
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Graph is the topology of a workspace. Tracking plans come first, then each source followed by
+// its destinations.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node returns the node with an ID
+func (g Graph) Node(id string) (Node, bool) {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// Filter selects the part of a workspace that is graphed. The zero Filter selects everything.
+type Filter struct {
+	// Sources are the slugs of the sources to keep
+	Sources []string
+	// Catalogs are the catalog types to keep, e.g. javascript or google-analytics. A source of one
+	// of them is kept with all its destinations, and other sources only with their destinations of
+	// one of them.
+	Catalogs []string
+	// Enabled keeps only the enabled destinations when true, and only the disabled ones when false
+	Enabled *bool
+}
+
+func (f Filter) isZero() bool {
+	return len(f.Sources) == 0 && len(f.Catalogs) == 0 && f.Enabled == nil
+}
+
+// Build reads the sources, destinations, tracking plans and source connections of the workspace of
+// a client and returns the graph of the resources selected by the filter
+func Build(c *segment.Client, f Filter) (Graph, error) {
+	s, err := readState(c)
+	if err != nil {
+		return Graph{}, err
+	}
+	return FromState(s, f), nil
+}
+
+// readState reads what the graph needs of a workspace, which leaves out the rules of tracking plans
+func readState(c *segment.Client) (spec.State, error) {
+	s := spec.State{Destinations: map[string][]segment.Destination{}, Connections: map[string]string{}}
+
+	sources, err := c.ListSources()
+	if err != nil {
+		return s, errors.Wrap(err, "failed to list sources")
+	}
+	s.Sources = sources.Sources
+	for _, src := range s.Sources {
+		name := segment.Slug(src.Name)
+		dests, err := c.ListDestinations(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to list destinations of source %s", name)
+		}
+		s.Destinations[name] = dests.Destinations
+	}
+
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return s, errors.Wrap(err, "failed to list tracking plans")
+	}
+	s.TrackingPlans = plans.TrackingPlans
+	for _, p := range s.TrackingPlans {
+		name := segment.Slug(p.Name)
+		conns, err := c.ListTrackingPlanSourceConnections(name)
+		if err != nil {
+			return s, errors.Wrapf(err, "failed to list source connections of tracking plan %s", name)
+		}
+		for _, conn := range conns.Connections {
+			s.Connections[segment.Slug(conn.SourceName)] = name
+		}
+	}
+	return s, nil
+}
+
+// FromState returns the graph of the resources of a workspace state selected by the filter, such
+// as the state of a snapshot
+func FromState(s spec.State, f Filter) Graph {
+	sources := append([]segment.Source(nil), s.Sources...)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+
+	var g Graph
+	var sourceNodes []Node
+	var edges []Edge
+	governed := map[string]bool{}
+	for _, src := range sources {
+		name := segment.Slug(src.Name)
+		if len(f.Sources) > 0 && !contains(f.Sources, name) {
+			continue
+		}
+		catalog := segment.Slug(src.CatalogName)
+		catalogMatch := len(f.Catalogs) > 0 && contains(f.Catalogs, catalog)
+		source := Node{ID: "source:" + name, Kind: KindSource, Name: name, Label: name, Catalog: catalog}
+
+		var dests []Node
+		for _, d := range sortedDestinations(s.Destinations[name]) {
+			dest := destinationNode(name, d)
+			if f.Enabled != nil && *f.Enabled == dest.Disabled {
+				continue
+			}
+			if len(f.Catalogs) > 0 && !catalogMatch && !contains(f.Catalogs, dest.Catalog) {
+				continue
+			}
+			dests = append(dests, dest)
+			edges = append(edges, Edge{From: source.ID, To: dest.ID, Kind: EdgeDelivers})
+		}
+		if len(dests) == 0 && !catalogMatch && (f.Enabled != nil || len(f.Catalogs) > 0) {
+			continue
+		}
+		sourceNodes = append(append(sourceNodes, source), dests...)
+		if plan, ok := s.Connections[name]; ok {
+			governed[plan] = true
+		}
+	}
+
+	plans := append([]segment.TrackingPlan(nil), s.TrackingPlans...)
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Name < plans[j].Name })
+	for _, p := range plans {
+		name := segment.Slug(p.Name)
+		if !governed[name] && !f.isZero() {
+			continue
+		}
+		label := p.DisplayName
+		if label == "" {
+			label = name
+		}
+		g.Nodes = append(g.Nodes, Node{ID: "tracking-plan:" + name, Kind: KindTrackingPlan, Name: name, Label: label})
+	}
+	for _, n := range sourceNodes {
+		if n.Kind != KindSource {
+			continue
+		}
+		if plan, ok := s.Connections[n.Name]; ok {
+			if _, known := g.Node("tracking-plan:" + plan); known {
+				g.Edges = append(g.Edges, Edge{From: "tracking-plan:" + plan, To: n.ID, Kind: EdgeGoverns})
+			}
+		}
+	}
+	g.Nodes = append(g.Nodes, sourceNodes...)
+	g.Edges = append(g.Edges, edges...)
+	return g
+}
+
+func destinationNode(source string, d segment.Destination) Node {
+	name := segment.Slug(d.Name)
+	label := d.DisplayName
+	if label == "" {
+		label = name
+	}
+	// The slug of a destination is its catalog type, as a source has one destination of each type
+	return Node{
+		ID:             "destination:" + source + "/" + name,
+		Kind:           KindDestination,
+		Name:           name,
+		Label:          label,
+		Source:         source,
+		Catalog:        name,
+		ConnectionMode: d.ConnectionMode,
+		Disabled:       !d.Enabled,
+	}
+}
+
+func sortedDestinations(dests []segment.Destination) []segment.Destination {
+	sorted := append([]segment.Destination(nil), dests...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
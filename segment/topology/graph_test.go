@@ -0,0 +1,107 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddSource("ws", "android", "catalog/sources/android")
+	api.AddDestination("ws", "js", "google-analytics", true)
+	api.AddDestination("ws", "js", "mixpanel", false)
+	api.AddDestination("ws", "ios", "mixpanel", true)
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", segment.Rules{})
+	api.AddTrackingPlan("ws", "rs_2", "Unused", segment.Rules{})
+	api.Connect("ws", "js", "rs_1")
+	api.Connect("ws", "ios", "rs_1")
+	return api
+}
+
+func nodeIDs(g Graph) []string {
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func edgeStrings(g Graph) []string {
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+" "+string(e.Kind)+" "+e.To)
+	}
+	return edges
+}
+
+func TestBuild(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	g, err := Build(api.Client("ws"), Filter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		"tracking-plan:rs_1",
+		"tracking-plan:rs_2",
+		"source:android",
+		"source:ios",
+		"destination:ios/mixpanel",
+		"source:js",
+		"destination:js/google-analytics",
+		"destination:js/mixpanel",
+	}, nodeIDs(g))
+	assert.Equal(t, []string{
+		"tracking-plan:rs_1 governs source:ios",
+		"tracking-plan:rs_1 governs source:js",
+		"source:ios delivers destination:ios/mixpanel",
+		"source:js delivers destination:js/google-analytics",
+		"source:js delivers destination:js/mixpanel",
+	}, edgeStrings(g))
+
+	n, ok := g.Node("destination:js/mixpanel")
+	assert.True(t, ok)
+	assert.Equal(t, Node{
+		ID: "destination:js/mixpanel", Kind: KindDestination, Name: "mixpanel", Label: "mixpanel",
+		Source: "js", Catalog: "mixpanel", ConnectionMode: n.ConnectionMode, Disabled: true,
+	}, n)
+	n, _ = g.Node("source:js")
+	assert.Equal(t, "javascript", n.Catalog)
+	n, _ = g.Node("tracking-plan:rs_1")
+	assert.Equal(t, "Kicks App", n.Label)
+}
+
+func TestFromState_Filter(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	s, err := readState(api.Client("ws"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	g := FromState(s, Filter{Sources: []string{"js"}})
+	assert.Equal(t, []string{"tracking-plan:rs_1", "source:js", "destination:js/google-analytics", "destination:js/mixpanel"}, nodeIDs(g))
+	assert.Equal(t, "tracking-plan:rs_1 governs source:js", edgeStrings(g)[0])
+
+	// a source catalog keeps every destination of the source
+	g = FromState(s, Filter{Catalogs: []string{"ios"}})
+	assert.Equal(t, []string{"tracking-plan:rs_1", "source:ios", "destination:ios/mixpanel"}, nodeIDs(g))
+
+	// a destination catalog keeps the sources that have such a destination
+	g = FromState(s, Filter{Catalogs: []string{"mixpanel"}})
+	assert.Equal(t, []string{"tracking-plan:rs_1", "source:ios", "destination:ios/mixpanel", "source:js", "destination:js/mixpanel"}, nodeIDs(g))
+
+	disabled := false
+	g = FromState(s, Filter{Enabled: &disabled})
+	assert.Equal(t, []string{"tracking-plan:rs_1", "source:js", "destination:js/mixpanel"}, nodeIDs(g))
+	assert.Equal(t, []string{"tracking-plan:rs_1 governs source:js", "source:js delivers destination:js/mixpanel"}, edgeStrings(g))
+
+	g = FromState(s, Filter{Sources: []string{"android"}, Enabled: &disabled})
+	assert.Empty(t, g.Nodes)
+}
@@ -0,0 +1,110 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSON returns the graph as indented JSON
+func (g Graph) JSON() ([]byte, error) {
+	if g.Nodes == nil {
+		g.Nodes = []Node{}
+	}
+	if g.Edges == nil {
+		g.Edges = []Edge{}
+	}
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT renders the graph in the Graphviz DOT language. Disabled destinations are dashed and gray.
+func (g Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph workspace {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(nodeLabel(n, `\n`))}
+		switch n.Kind {
+		case KindSource:
+			attrs = append(attrs, "shape=box")
+		case KindDestination:
+			attrs = append(attrs, "shape=ellipse")
+		case KindTrackingPlan:
+			attrs = append(attrs, "shape=note")
+		}
+		if n.Disabled {
+			attrs = append(attrs, "style=dashed", "color=gray", "fontcolor=gray")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		if e.Kind == EdgeGoverns {
+			attrs = ` [label="governs", style=dotted]`
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(e.From), dotQuote(e.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Disabled destinations are dashed.
+func (g Graph) Mermaid() string {
+	// Mermaid IDs cannot contain the punctuation of node IDs, so nodes are numbered
+	ids := map[string]string{}
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	var disabled []string
+	for _, n := range g.Nodes {
+		label := mermaidQuote(nodeLabel(n, "<br/>"))
+		switch n.Kind {
+		case KindSource:
+			fmt.Fprintf(&b, "  %s[%s]\n", ids[n.ID], label)
+		case KindDestination:
+			fmt.Fprintf(&b, "  %s([%s])\n", ids[n.ID], label)
+		default:
+			fmt.Fprintf(&b, "  %s[/%s/]\n", ids[n.ID], label)
+		}
+		if n.Disabled {
+			disabled = append(disabled, ids[n.ID])
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Kind == EdgeGoverns {
+			fmt.Fprintf(&b, "  %s -. governs .-> %s\n", ids[e.From], ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	if len(disabled) > 0 {
+		b.WriteString("  classDef disabled stroke-dasharray: 5 5,color:#999\n")
+		fmt.Fprintf(&b, "  class %s disabled\n", strings.Join(disabled, ","))
+	}
+	return b.String()
+}
+
+// nodeLabel returns the label of a node, with the catalog type of sources and the state of
+// disabled destinations on a second line
+func nodeLabel(n Node, newline string) string {
+	switch {
+	case n.Kind == KindSource && n.Catalog != "":
+		return n.Label + newline + n.Catalog
+	case n.Disabled:
+		return n.Label + newline + "(disabled)"
+	}
+	return n.Label
+}
+
+// dotQuote quotes a DOT identifier, keeping the \n escapes of labels
+func dotQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+// mermaidQuote quotes a Mermaid label, whose quotes are written as entities
+func mermaidQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
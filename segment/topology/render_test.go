@@ -0,0 +1,64 @@
+package topology
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testGraph = Graph{
+	Nodes: []Node{
+		{ID: "tracking-plan:rs_1", Kind: KindTrackingPlan, Name: "rs_1", Label: `Kicks "App"`},
+		{ID: "source:js", Kind: KindSource, Name: "js", Label: "js", Catalog: "javascript"},
+		{ID: "destination:js/google-analytics", Kind: KindDestination, Name: "google-analytics", Label: "Google Analytics", Source: "js", Catalog: "google-analytics"},
+		{ID: "destination:js/mixpanel", Kind: KindDestination, Name: "mixpanel", Label: "Mixpanel", Source: "js", Catalog: "mixpanel", Disabled: true},
+	},
+	Edges: []Edge{
+		{From: "tracking-plan:rs_1", To: "source:js", Kind: EdgeGoverns},
+		{From: "source:js", To: "destination:js/google-analytics", Kind: EdgeDelivers},
+		{From: "source:js", To: "destination:js/mixpanel", Kind: EdgeDelivers},
+	},
+}
+
+func TestGraph_DOT(t *testing.T) {
+	expected := `digraph workspace {
+  rankdir=LR;
+  "tracking-plan:rs_1" [label="Kicks \"App\"", shape=note];
+  "source:js" [label="js\njavascript", shape=box];
+  "destination:js/google-analytics" [label="Google Analytics", shape=ellipse];
+  "destination:js/mixpanel" [label="Mixpanel\n(disabled)", shape=ellipse, style=dashed, color=gray, fontcolor=gray];
+  "tracking-plan:rs_1" -> "source:js" [label="governs", style=dotted];
+  "source:js" -> "destination:js/google-analytics";
+  "source:js" -> "destination:js/mixpanel";
+}
+`
+	assert.Equal(t, expected, testGraph.DOT())
+}
+
+func TestGraph_Mermaid(t *testing.T) {
+	expected := `flowchart LR
+  n0[/"Kicks #quot;App#quot;"/]
+  n1["js<br/>javascript"]
+  n2(["Google Analytics"])
+  n3(["Mixpanel<br/>(disabled)"])
+  n0 -. governs .-> n1
+  n1 --> n2
+  n1 --> n3
+  classDef disabled stroke-dasharray: 5 5,color:#999
+  class n3 disabled
+`
+	assert.Equal(t, expected, testGraph.Mermaid())
+}
+
+func TestGraph_JSON(t *testing.T) {
+	data, err := testGraph.JSON()
+	assert.NoError(t, err)
+	var g Graph
+	assert.NoError(t, json.Unmarshal(data, &g))
+	assert.Equal(t, testGraph, g)
+
+	data, err = Graph{}.JSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"nodes": [], "edges": []}`, string(data))
+}
@@ -0,0 +1,477 @@
+package segment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+)
+
+// ChangeType describes how an element of a tracking plan changed
+type ChangeType string
+
+// Change types
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Rule fields compared by the tracking plan diff
+const (
+	FieldType        = "type"
+	FieldRequired    = "required"
+	FieldDescription = "description"
+	FieldEnum        = "enum"
+	FieldPattern     = "pattern"
+	FieldFormat      = "format"
+	FieldMinimum     = "minimum"
+	FieldMaximum     = "maximum"
+	FieldMinLength   = "min_length"
+	FieldMaxLength   = "max_length"
+	FieldRef         = "ref"
+	FieldLabels      = "labels"
+	// FieldSchema is the $schema keyword
+	FieldSchema               = "schema"
+	FieldAdditionalProperties = "additional_properties"
+	FieldDefinitions          = "definitions"
+	// FieldRule is set on changes of rules that differ in a way no other field describes
+	FieldRule = "rule"
+)
+
+// Update mask paths of a tracking plan
+const (
+	TrackingPlanDisplayNamePath   = "tracking_plan.display_name"
+	TrackingPlanRulesEventsPath   = "tracking_plan.rules.events"
+	TrackingPlanRulesGlobalPath   = "tracking_plan.rules.global"
+	TrackingPlanRulesIdentifyPath = "tracking_plan.rules.identify"
+	TrackingPlanRulesGroupPath    = "tracking_plan.rules.group"
+	// TrackingPlanRulesIdentifyTraitsPath and TrackingPlanRulesGroupTraitsPath cover the trait rules
+	// of the identify and group calls
+	TrackingPlanRulesIdentifyTraitsPath = "tracking_plan.rules.identify_traits"
+	TrackingPlanRulesGroupTraitsPath    = "tracking_plan.rules.group_traits"
+)
+
+// TrackingPlanDiff contains the structural differences between two tracking plans
+type TrackingPlanDiff struct {
+	DisplayName *ValueChange     `json:"display_name,omitempty"`
+	Events      []EventDiff      `json:"events,omitempty"`
+	Global      []PropertyChange `json:"global,omitempty"`
+	Identify    []PropertyChange `json:"identify,omitempty"`
+	Group       []PropertyChange `json:"group,omitempty"`
+	// IdentifyTraits and GroupTraits hold the old and new trait rules when they changed
+	IdentifyTraits *ValueChange `json:"identify_traits,omitempty"`
+	GroupTraits    *ValueChange `json:"group_traits,omitempty"`
+}
+
+// ValueChange contains the old and new value of a changed field
+type ValueChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// EventDiff contains the differences of a single event
+type EventDiff struct {
+	Name        string           `json:"name"`
+	Change      ChangeType       `json:"change"`
+	OldVersion  int              `json:"old_version,omitempty"`
+	NewVersion  int              `json:"new_version,omitempty"`
+	Description *ValueChange     `json:"description,omitempty"`
+	Properties  []PropertyChange `json:"properties,omitempty"`
+}
+
+// VersionBumped reports whether the event version changed
+func (e EventDiff) VersionBumped() bool {
+	return e.Change == ChangeChanged && e.OldVersion != e.NewVersion
+}
+
+// PropertyChange describes a property that was added or removed, or one field of a property that changed.
+// Paths are relative to the rule envelope, e.g. "properties.price". Required tells whether an added or
+// removed property is required.
+type PropertyChange struct {
+	Path     string      `json:"path"`
+	Change   ChangeType  `json:"change"`
+	Field    string      `json:"field,omitempty"`
+	Old      interface{} `json:"old,omitempty"`
+	New      interface{} `json:"new,omitempty"`
+	Required bool        `json:"required,omitempty"`
+}
+
+// DiffTrackingPlans returns the differences between an old and an updated tracking plan. An empty
+// display name in updated leaves the display name unchanged, as plans cannot be renamed to "".
+func DiffTrackingPlans(old, updated TrackingPlan) TrackingPlanDiff {
+	d := DiffRules(old.Rules, updated.Rules)
+	if updated.DisplayName != "" && old.DisplayName != updated.DisplayName {
+		d.DisplayName = &ValueChange{Old: old.DisplayName, New: updated.DisplayName}
+	}
+	return d
+}
+
+// DiffRules returns the differences between old and updated tracking plan rules. Events are matched by
+// name and version, except that an event with a single version on each side that differs is reported
+// as a version change. Every keyword of the rules is compared, and rules that differ in a way no field
+// describes are reported with FieldRule, so that a diff is only empty when the rules are the same.
+func DiffRules(old, updated Rules) TrackingPlanDiff {
+	var d TrackingPlanDiff
+	d.Global = diffSection("", old.Global, updated.Global)
+	d.Identify = diffSection("", old.Identify, updated.Identify)
+	d.Group = diffSection("", old.Group, updated.Group)
+	if !traitsEqual(old.IdentifyTraits, updated.IdentifyTraits) {
+		d.IdentifyTraits = &ValueChange{Old: old.IdentifyTraits, New: updated.IdentifyTraits}
+	}
+	if !traitsEqual(old.GroupTraits, updated.GroupTraits) {
+		d.GroupTraits = &ValueChange{Old: old.GroupTraits, New: updated.GroupTraits}
+	}
+
+	oldEvents := eventsByName(old.Events)
+	newEvents := eventsByName(updated.Events)
+	for _, name := range unionKeys(oldEvents, newEvents) {
+		oldVersions, newVersions := oldEvents[name], newEvents[name]
+		removed := missingVersions(oldVersions, newVersions)
+		added := missingVersions(newVersions, oldVersions)
+		if len(removed) == 1 && len(added) == 1 && len(oldVersions) == 1 && len(newVersions) == 1 {
+			// The only version of the event was replaced by another
+			if e, changed := diffEvent(oldVersions[removed[0]], newVersions[added[0]]); changed {
+				d.Events = append(d.Events, e)
+			}
+			continue
+		}
+		for _, v := range unionVersions(oldVersions, newVersions) {
+			o, inOld := oldVersions[v]
+			n, inNew := newVersions[v]
+			switch {
+			case !inOld:
+				d.Events = append(d.Events, EventDiff{Name: name, Change: ChangeAdded, NewVersion: v})
+			case !inNew:
+				d.Events = append(d.Events, EventDiff{Name: name, Change: ChangeRemoved, OldVersion: v})
+			default:
+				if e, changed := diffEvent(o, n); changed {
+					d.Events = append(d.Events, e)
+				}
+			}
+		}
+	}
+	return d
+}
+
+// diffEvent compares two versions of an event, and reports whether they differ
+func diffEvent(o, n Event) (EventDiff, bool) {
+	e := EventDiff{Name: n.Name, Change: ChangeChanged, OldVersion: o.Version, NewVersion: n.Version}
+	if o.Description != n.Description {
+		e.Description = &ValueChange{Old: o.Description, New: n.Description}
+	}
+	e.Properties = diffSection("", o.Rules, n.Rules)
+	return e, e.Description != nil || e.Properties != nil || o.Version != n.Version
+}
+
+// diffSection compares the rules of a section or an event. Rules whose differences no field
+// describes are reported as a whole with FieldRule.
+func diffSection(path string, old, updated Rule) []PropertyChange {
+	changes := diffRule(path, old, updated, false, false)
+	if len(changes) == 0 && !jsonEqual(old, updated) {
+		changes = append(changes, PropertyChange{Path: path, Change: ChangeChanged, Field: FieldRule, Old: old, New: updated})
+	}
+	return changes
+}
+
+// IsEmpty reports whether the two tracking plans are identical
+func (d TrackingPlanDiff) IsEmpty() bool {
+	return d.DisplayName == nil && len(d.Events) == 0 && len(d.Global) == 0 &&
+		len(d.Identify) == 0 && len(d.Group) == 0 && d.IdentifyTraits == nil && d.GroupTraits == nil
+}
+
+// UpdateMaskPaths returns the update mask paths that cover everything that changed
+func (d TrackingPlanDiff) UpdateMaskPaths() []string {
+	var paths []string
+	if d.DisplayName != nil {
+		paths = append(paths, TrackingPlanDisplayNamePath)
+	}
+	if len(d.Events) > 0 {
+		paths = append(paths, TrackingPlanRulesEventsPath)
+	}
+	if len(d.Global) > 0 {
+		paths = append(paths, TrackingPlanRulesGlobalPath)
+	}
+	if len(d.Identify) > 0 {
+		paths = append(paths, TrackingPlanRulesIdentifyPath)
+	}
+	if len(d.Group) > 0 {
+		paths = append(paths, TrackingPlanRulesGroupPath)
+	}
+	if d.IdentifyTraits != nil {
+		paths = append(paths, TrackingPlanRulesIdentifyTraitsPath)
+	}
+	if d.GroupTraits != nil {
+		paths = append(paths, TrackingPlanRulesGroupTraitsPath)
+	}
+	return paths
+}
+
+// JSON returns the diff as indented JSON
+func (d TrackingPlanDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// String renders the diff as human readable text
+func (d TrackingPlanDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	if d.DisplayName != nil {
+		fmt.Fprintf(&b, "~ display name: %q -> %q\n", d.DisplayName.Old, d.DisplayName.New)
+	}
+	for _, e := range d.Events {
+		switch e.Change {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+ event %q (v%d)\n", e.Name, e.NewVersion)
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- event %q (v%d)\n", e.Name, e.OldVersion)
+		default:
+			if e.VersionBumped() {
+				fmt.Fprintf(&b, "~ event %q v%d -> v%d\n", e.Name, e.OldVersion, e.NewVersion)
+			} else {
+				fmt.Fprintf(&b, "~ event %q (v%d)\n", e.Name, e.NewVersion)
+			}
+		}
+		if e.Description != nil {
+			fmt.Fprintf(&b, "    ~ description: %q -> %q\n", e.Description.Old, e.Description.New)
+		}
+		writePropertyChanges(&b, e.Properties)
+	}
+	for _, section := range []struct {
+		name    string
+		changes []PropertyChange
+	}{{"global", d.Global}, {"identify", d.Identify}, {"group", d.Group}} {
+		if len(section.changes) > 0 {
+			fmt.Fprintf(&b, "~ %s\n", section.name)
+			writePropertyChanges(&b, section.changes)
+		}
+	}
+	if d.IdentifyTraits != nil {
+		b.WriteString("~ identify traits\n")
+	}
+	if d.GroupTraits != nil {
+		b.WriteString("~ group traits\n")
+	}
+	return b.String()
+}
+
+func writePropertyChanges(b *strings.Builder, changes []PropertyChange) {
+	for _, c := range changes {
+		switch c.Change {
+		case ChangeAdded:
+			fmt.Fprintf(b, "    + %s%s\n", c.Path, requiredSuffix(c.Required))
+		case ChangeRemoved:
+			fmt.Fprintf(b, "    - %s%s\n", c.Path, requiredSuffix(c.Required))
+		case ChangeChanged:
+			if c.Field == FieldRule {
+				fmt.Fprintf(b, "    ~ %s\n", rulePath(c.Path))
+			} else {
+				fmt.Fprintf(b, "    ~ %s %s: %s -> %s\n", rulePath(c.Path), c.Field, diffValue(c.Old), diffValue(c.New))
+			}
+		}
+	}
+}
+
+// rulePath returns the path of a change for display, which is empty at the root of the rules
+func rulePath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+func requiredSuffix(required bool) string {
+	if required {
+		return " (required)"
+	}
+	return ""
+}
+
+func diffValue(v interface{}) string {
+	if v == nil {
+		return "none"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// diffRule compares two rules at path, including their required-ness in their parents
+func diffRule(path string, old, updated Rule, oldRequired, newRequired bool) []PropertyChange {
+	var changes []PropertyChange
+	field := func(name string, o, n interface{}) {
+		changes = append(changes, PropertyChange{Path: path, Change: ChangeChanged, Field: name, Old: o, New: n})
+	}
+
+	if !jsonvalue.StringsEqual(old.Type.Names, updated.Type.Names) {
+		field(FieldType, typeNames(old.Type), typeNames(updated.Type))
+	}
+	if oldRequired != newRequired {
+		field(FieldRequired, oldRequired, newRequired)
+	}
+	if old.Description != updated.Description {
+		field(FieldDescription, old.Description, updated.Description)
+	}
+	if !enumEqual(old.Enum, updated.Enum) {
+		field(FieldEnum, nilIfEmpty(old.Enum), nilIfEmpty(updated.Enum))
+	}
+	if old.Pattern != updated.Pattern {
+		field(FieldPattern, old.Pattern, updated.Pattern)
+	}
+	if old.Format != updated.Format {
+		field(FieldFormat, old.Format, updated.Format)
+	}
+	if !reflect.DeepEqual(old.Minimum, updated.Minimum) {
+		field(FieldMinimum, old.Minimum, updated.Minimum)
+	}
+	if !reflect.DeepEqual(old.Maximum, updated.Maximum) {
+		field(FieldMaximum, old.Maximum, updated.Maximum)
+	}
+	if !reflect.DeepEqual(old.MinLength, updated.MinLength) {
+		field(FieldMinLength, old.MinLength, updated.MinLength)
+	}
+	if !reflect.DeepEqual(old.MaxLength, updated.MaxLength) {
+		field(FieldMaxLength, old.MaxLength, updated.MaxLength)
+	}
+	if old.Ref != updated.Ref {
+		field(FieldRef, old.Ref, updated.Ref)
+	}
+	if old.Schema != updated.Schema {
+		field(FieldSchema, old.Schema, updated.Schema)
+	}
+	if !jsonEqual(old.AdditionalProperties, updated.AdditionalProperties) {
+		field(FieldAdditionalProperties, old.AdditionalProperties, updated.AdditionalProperties)
+	}
+	if !jsonEqual(old.Definitions, updated.Definitions) && (len(old.Definitions) > 0 || len(updated.Definitions) > 0) {
+		field(FieldDefinitions, old.Definitions, updated.Definitions)
+	}
+	if !reflect.DeepEqual(old.Labels, updated.Labels) && (len(old.Labels) > 0 || len(updated.Labels) > 0) {
+		field(FieldLabels, old.Labels, updated.Labels)
+	}
+
+	for _, name := range unionKeys(old.Properties, updated.Properties) {
+		o, inOld := old.Properties[name]
+		n, inNew := updated.Properties[name]
+		p := name
+		if path != "" {
+			p = path + "." + name
+		}
+		switch {
+		case !inOld:
+			changes = append(changes, PropertyChange{Path: p, Change: ChangeAdded, Required: updated.IsRequired(name)})
+		case !inNew:
+			changes = append(changes, PropertyChange{Path: p, Change: ChangeRemoved, Required: old.IsRequired(name)})
+		default:
+			changes = append(changes, diffRule(p, o, n, old.IsRequired(name), updated.IsRequired(name))...)
+		}
+	}
+
+	if old.Items != nil || updated.Items != nil {
+		var o, n Rule
+		if old.Items != nil {
+			o = *old.Items
+		}
+		if updated.Items != nil {
+			n = *updated.Items
+		}
+		changes = append(changes, diffRule(path+"[]", o, n, false, false)...)
+	}
+	return changes
+}
+
+func typeNames(t Type) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Names
+}
+
+func nilIfEmpty(e Enum) interface{} {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func enumEqual(a, b Enum) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !enumValueEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// eventsByName indexes events by name and version
+func eventsByName(events []Event) map[string]map[int]Event {
+	m := make(map[string]map[int]Event, len(events))
+	for _, e := range events {
+		if m[e.Name] == nil {
+			m[e.Name] = map[int]Event{}
+		}
+		m[e.Name][e.Version] = e
+	}
+	return m
+}
+
+// missingVersions returns the sorted versions of a that b does not have
+func missingVersions(a, b map[int]Event) []int {
+	var versions []int
+	for v := range a {
+		if _, ok := b[v]; !ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// unionVersions returns the sorted versions of a and b
+func unionVersions(a, b map[int]Event) []int {
+	versions := missingVersions(a, b)
+	for v := range b {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// traitsEqual compares trait rules, which are the same when both are empty whether they are nil or not
+func traitsEqual(a, b []Rule) bool {
+	return (len(a) == 0 && len(b) == 0) || jsonEqual(a, b)
+}
+
+// jsonEqual reports whether a and b encode to the same JSON. Maps are encoded with sorted keys, so
+// this compares rules regardless of the order they were built in.
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// unionKeys returns the sorted keys of two maps with string keys
+func unionKeys(a, b interface{}) []string {
+	seen := map[string]bool{}
+	for _, m := range []interface{}{a, b} {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			seen[k.String()] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
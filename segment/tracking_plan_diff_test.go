@@ -0,0 +1,270 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDiffPlans() (TrackingPlan, TrackingPlan) {
+	old := NewRulesBuilder()
+	old.Event("Order Completed").Version(1).Description("Who bought what").
+		Prop("product", String().Required()).
+		Prop("price", Number()).
+		Prop("color", String())
+	old.Event("Cart Viewed").Version(1)
+	old.IdentifyTrait("name", String())
+
+	updated := NewRulesBuilder()
+	updated.Event("Order Completed").Version(2).Description("Who bought what and when").
+		Prop("product", String().Required()).
+		Prop("price", String().Required()).
+		Prop("coupon", String())
+	updated.Event("Product Viewed").Version(1)
+	updated.IdentifyTrait("name", String())
+
+	return old.TrackingPlan("Kicks App"), updated.TrackingPlan("Kicks App v2")
+}
+
+func TestTrackingPlanDiff_DiffTrackingPlans(t *testing.T) {
+	old, updated := testDiffPlans()
+
+	d := DiffTrackingPlans(old, updated)
+
+	expected := TrackingPlanDiff{
+		DisplayName: &ValueChange{Old: "Kicks App", New: "Kicks App v2"},
+		Events: []EventDiff{
+			{Name: "Cart Viewed", Change: ChangeRemoved, OldVersion: 1},
+			{
+				Name: "Order Completed", Change: ChangeChanged, OldVersion: 1, NewVersion: 2,
+				Description: &ValueChange{Old: "Who bought what", New: "Who bought what and when"},
+				Properties: []PropertyChange{
+					{Path: "properties.color", Change: ChangeRemoved},
+					{Path: "properties.coupon", Change: ChangeAdded},
+					{Path: "properties.price", Change: ChangeChanged, Field: FieldType, Old: []string{TypeNumber}, New: []string{TypeString}},
+					{Path: "properties.price", Change: ChangeChanged, Field: FieldRequired, Old: false, New: true},
+				},
+			},
+			{Name: "Product Viewed", Change: ChangeAdded, NewVersion: 1},
+		},
+	}
+	assert.Equal(t, expected, d)
+	assert.True(t, d.Events[1].VersionBumped())
+	assert.Equal(t, []string{TrackingPlanDisplayNamePath, TrackingPlanRulesEventsPath}, d.UpdateMaskPaths())
+
+	assert.Equal(t, `~ display name: "Kicks App" -> "Kicks App v2"
+- event "Cart Viewed" (v1)
+~ event "Order Completed" v1 -> v2
+    ~ description: "Who bought what" -> "Who bought what and when"
+    - properties.color
+    + properties.coupon
+    ~ properties.price type: ["number"] -> ["string"]
+    ~ properties.price required: false -> true
++ event "Product Viewed" (v1)
+`, d.String())
+
+	js, err := d.JSON()
+	assert.NoError(t, err)
+	var decoded TrackingPlanDiff
+	assert.NoError(t, json.Unmarshal(js, &decoded))
+	assert.Len(t, decoded.Events, 3)
+}
+
+func TestTrackingPlanDiff_NoChanges(t *testing.T) {
+	old, _ := testDiffPlans()
+
+	d := DiffTrackingPlans(old, old)
+	assert.True(t, d.IsEmpty())
+	assert.Nil(t, d.UpdateMaskPaths())
+	assert.Equal(t, "no changes\n", d.String())
+}
+
+func TestTrackingPlanDiff_GlobalChanges(t *testing.T) {
+	old := NewRulesBuilder()
+	old.Global().Context("library", Object())
+	updated := NewRulesBuilder()
+	updated.Global().Context("library", Object().Required()).Context("ip", String().Required())
+
+	d := DiffRules(old.Rules(), updated.Rules())
+	assert.Equal(t, []string{TrackingPlanRulesGlobalPath}, d.UpdateMaskPaths())
+	assert.Equal(t, []PropertyChange{
+		{Path: "context", Change: ChangeChanged, Field: FieldRequired, Old: false, New: true},
+		{Path: "context.ip", Change: ChangeAdded, Required: true},
+		{Path: "context.library", Change: ChangeChanged, Field: FieldRequired, Old: false, New: true},
+	}, d.Global)
+}
+
+func TestTrackingPlanDiff_ApplyTrackingPlanChanges(t *testing.T) {
+	setup()
+	defer teardown()
+
+	old, updated := testDiffPlans()
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint, "rs_123")
+
+	var mask []string
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.NoError(t, json.NewEncoder(w).Encode(old))
+		case http.MethodPut:
+			var req trackingPlanUpdateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mask = req.UpdateMask.Paths
+			assert.NoError(t, json.NewEncoder(w).Encode(req.TrackingPlan))
+		}
+	})
+
+	actual, d, err := client.ApplyTrackingPlanChanges("rs_123", updated)
+	assert.NoError(t, err)
+	assert.False(t, d.IsEmpty())
+	assert.Equal(t, []string{TrackingPlanDisplayNamePath, TrackingPlanRulesEventsPath}, mask)
+	assert.Equal(t, updated.DisplayName, actual.DisplayName)
+
+	mask = nil
+	_, d, err = client.ApplyTrackingPlanChanges("rs_123", old)
+	assert.NoError(t, err)
+	assert.True(t, d.IsEmpty())
+	assert.Nil(t, mask)
+}
+
+func TestTrackingPlanDiff_EmptyDisplayName(t *testing.T) {
+	setup()
+	defer teardown()
+
+	old, updated := testDiffPlans()
+	updated.DisplayName = ""
+	d := DiffTrackingPlans(old, updated)
+	assert.Nil(t, d.DisplayName, "an empty display name is not a rename")
+	assert.NotContains(t, d.String(), "display name")
+
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint, "rs_123")
+	var mask []string
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.NoError(t, json.NewEncoder(w).Encode(old))
+		case http.MethodPut:
+			var req trackingPlanUpdateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mask = req.UpdateMask.Paths
+			assert.NoError(t, json.NewEncoder(w).Encode(req.TrackingPlan))
+		}
+	})
+
+	_, _, err := client.ApplyTrackingPlanChanges("rs_123", updated)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{TrackingPlanRulesEventsPath}, mask)
+}
+
+func TestTrackingPlanDiff_EventVersions(t *testing.T) {
+	version := func(v int, p *PropertyBuilder) Event {
+		return NewRulesBuilder().Event("Order Completed").Version(v).Prop("order_id", p).Event()
+	}
+	old := Rules{Events: []Event{version(1, String()), version(2, String().Required())}}
+	updated := Rules{Events: []Event{version(2, String().Required()), version(3, Integer().Required())}}
+
+	d := DiffRules(old, updated)
+	assert.Equal(t, []EventDiff{
+		{Name: "Order Completed", Change: ChangeRemoved, OldVersion: 1},
+		{Name: "Order Completed", Change: ChangeAdded, NewVersion: 3},
+	}, d.Events, "versions are matched when an event has several")
+	assert.Equal(t, []string{TrackingPlanRulesEventsPath}, d.UpdateMaskPaths())
+
+	// removing one version of an event that keeps another is a change
+	kept := Rules{Events: []Event{version(2, String().Required())}}
+	d = DiffRules(old, kept)
+	assert.False(t, d.IsEmpty())
+	assert.Equal(t, []EventDiff{{Name: "Order Completed", Change: ChangeRemoved, OldVersion: 1}}, d.Events)
+	assert.Equal(t, "- event \"Order Completed\" (v1)\n", d.String())
+}
+
+func TestTrackingPlanDiff_Keywords(t *testing.T) {
+	old := NewRulesBuilder()
+	old.Event("Order Completed").Version(1).Prop("order_id", String())
+	updated := NewRulesBuilder()
+	updated.Event("Order Completed").Version(1).Prop("order_id", String())
+	oldRules, newRules := old.Rules(), updated.Rules()
+
+	props := newRules.Events[0].Rules.Properties["properties"]
+	props.AdditionalProperties = &AdditionalProperties{Allowed: false}
+	newRules.Events[0].Rules.Properties["properties"] = props
+	newRules.Events[0].Rules.Schema = ""
+	newRules.Events[0].Rules.Definitions = map[string]Rule{"id": String().Rule()}
+
+	d := DiffRules(oldRules, newRules)
+	if !assert.Len(t, d.Events, 1) {
+		return
+	}
+	assert.Equal(t, []PropertyChange{
+		{Path: "", Change: ChangeChanged, Field: FieldSchema, Old: SchemaDraft07, New: ""},
+		{Path: "", Change: ChangeChanged, Field: FieldDefinitions, Old: map[string]Rule(nil), New: map[string]Rule{"id": String().Rule()}},
+		{Path: "properties", Change: ChangeChanged, Field: FieldAdditionalProperties, Old: (*AdditionalProperties)(nil), New: &AdditionalProperties{Allowed: false}},
+	}, d.Events[0].Properties)
+	assert.Contains(t, d.String(), "    ~ properties additional_properties: null -> false\n")
+	assert.Contains(t, d.String(), "    ~ (root) schema: ")
+}
+
+func TestTrackingPlanDiff_Traits(t *testing.T) {
+	old := NewRulesBuilder().Rules()
+	updated := NewRulesBuilder().Rules()
+	updated.IdentifyTraits = []Rule{String().Rule()}
+
+	d := DiffRules(old, updated)
+	assert.False(t, d.IsEmpty())
+	assert.Equal(t, &ValueChange{Old: old.IdentifyTraits, New: updated.IdentifyTraits}, d.IdentifyTraits)
+	assert.Nil(t, d.GroupTraits)
+	assert.Equal(t, []string{TrackingPlanRulesIdentifyTraitsPath}, d.UpdateMaskPaths())
+	assert.Equal(t, "~ identify traits\n", d.String())
+
+	// nil and empty trait rules are the same
+	updated.IdentifyTraits = []Rule{}
+	old.IdentifyTraits = nil
+	assert.True(t, DiffRules(old, updated).IsEmpty())
+}
+
+func TestTrackingPlanDiff_RuleFallback(t *testing.T) {
+	old := NewRulesBuilder().Rules()
+	updated := NewRulesBuilder().Rules()
+	// a required property without rule is not a property change, but the rules differ
+	updated.Global.Required = []string{"context"}
+	updated.Global.Properties = nil
+
+	d := DiffRules(old, updated)
+	assert.False(t, d.IsEmpty())
+	if assert.Len(t, d.Global, 1) {
+		assert.Equal(t, FieldRule, d.Global[0].Field)
+	}
+	assert.Equal(t, []string{TrackingPlanRulesGlobalPath}, d.UpdateMaskPaths())
+	assert.Equal(t, "~ global\n    ~ (root)\n", d.String())
+}
+
+func TestTrackingPlanDiff_ApplyTrackingPlanChanges_Traits(t *testing.T) {
+	setup()
+	defer teardown()
+
+	old, _ := testDiffPlans()
+	updated := old
+	updated.Rules.GroupTraits = []Rule{String().Rule()}
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint, "rs_123")
+
+	var mask []string
+	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.NoError(t, json.NewEncoder(w).Encode(old))
+		case http.MethodPut:
+			var req trackingPlanUpdateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mask = req.UpdateMask.Paths
+			assert.NoError(t, json.NewEncoder(w).Encode(req.TrackingPlan))
+		}
+	})
+
+	_, d, err := client.ApplyTrackingPlanChanges("rs_123", updated)
+	assert.NoError(t, err)
+	assert.False(t, d.IsEmpty())
+	assert.Equal(t, []string{TrackingPlanRulesGroupTraitsPath}, mask, "trait-only changes are sent")
+}
@@ -0,0 +1,261 @@
+package segment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PromotionAction describes what a promotion did to a target tracking plan
+type PromotionAction string
+
+// Promotion actions
+const (
+	PromotionCreated   PromotionAction = "created"
+	PromotionUpdated   PromotionAction = "updated"
+	PromotionUnchanged PromotionAction = "unchanged"
+)
+
+// PromotionOptions controls which tracking plans are promoted and how
+type PromotionOptions struct {
+	// Plans restricts the promotion to the source plans with these names or display names, e.g. rs_123
+	// or "Kicks App". All plans of the source workspace are promoted when it is empty.
+	Plans []string
+	// Mapping maps source plan names to target plan names, e.g. rs_123 to rs_456. Mapped plans keep
+	// their display name in the target workspace. Other plans are matched by display name.
+	Mapping map[string]string
+	// Connections recreates the source connections of each source plan on its target plan. Sources are
+	// matched by slug, and a source connected to another plan in the target workspace is moved.
+	Connections bool
+	// DryRun reports what would change without changing the target workspace
+	DryRun bool
+}
+
+// PromotedConnection is a source connection created on a target tracking plan
+type PromotedConnection struct {
+	Source string `json:"source"`
+	// MovedFrom is the target workspace plan the source was connected to before, if any
+	MovedFrom string `json:"moved_from,omitempty"`
+}
+
+// PlanPromotion reports the promotion of one tracking plan
+type PlanPromotion struct {
+	DisplayName string               `json:"display_name"`
+	Source      string               `json:"source"`
+	Target      string               `json:"target,omitempty"`
+	Action      PromotionAction      `json:"action"`
+	Diff        TrackingPlanDiff     `json:"diff"`
+	Connections []PromotedConnection `json:"connections,omitempty"`
+}
+
+// PromotionResult reports the promotion of tracking plans from one workspace to another
+type PromotionResult struct {
+	DryRun bool            `json:"dry_run,omitempty"`
+	Plans  []PlanPromotion `json:"plans"`
+}
+
+// Changed reports whether the promotion created, updated or connected anything
+func (r PromotionResult) Changed() bool {
+	for _, p := range r.Plans {
+		if p.Action != PromotionUnchanged || len(p.Connections) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the result as human readable text
+func (r PromotionResult) String() string {
+	var b strings.Builder
+	for _, p := range r.Plans {
+		target := p.Target
+		if target == "" {
+			target = "new plan"
+		}
+		fmt.Fprintf(&b, "%s %q (%s -> %s)\n", p.Action, p.DisplayName, p.Source, target)
+		if p.Action != PromotionUnchanged {
+			for _, line := range strings.Split(strings.TrimSuffix(p.Diff.String(), "\n"), "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+		for _, c := range p.Connections {
+			if c.MovedFrom != "" {
+				fmt.Fprintf(&b, "  + source %s (moved from %s)\n", c.Source, c.MovedFrom)
+			} else {
+				fmt.Fprintf(&b, "  + source %s\n", c.Source)
+			}
+		}
+	}
+	if len(r.Plans) == 0 {
+		b.WriteString("no tracking plans to promote\n")
+	}
+	return b.String()
+}
+
+// PromoteTrackingPlans copies tracking plans from the workspace of one client to the workspace of another.
+// Each source plan is matched to a target plan through opts.Mapping or by display name, and the target is
+// created or updated to have the same rules. Promotion stops at the first error, and the result reports
+// the plans promoted until then.
+func PromoteTrackingPlans(from *Client, to *Client, opts PromotionOptions) (PromotionResult, error) {
+	result := PromotionResult{DryRun: opts.DryRun}
+
+	sources, err := from.ListTrackingPlans()
+	if err != nil {
+		return result, errors.Wrap(err, "failed to list source tracking plans")
+	}
+	targets, err := to.ListTrackingPlans()
+	if err != nil {
+		return result, errors.Wrap(err, "failed to list target tracking plans")
+	}
+	targetsByName := map[string]string{}
+	for _, p := range targets.TrackingPlans {
+		if _, ok := targetsByName[p.DisplayName]; ok {
+			targetsByName[p.DisplayName] = ""
+			continue
+		}
+		targetsByName[p.DisplayName] = Slug(p.Name)
+	}
+
+	selected, err := selectTrackingPlans(sources.TrackingPlans, opts.Plans)
+	if err != nil {
+		return result, err
+	}
+
+	var targetConns map[string]string
+	if opts.Connections {
+		all, err := to.ListAllTrackingPlanSourceConnections()
+		if err != nil {
+			return result, errors.Wrap(err, "failed to list target source connections")
+		}
+		targetConns = map[string]string{}
+		for _, c := range all.Connections {
+			targetConns[Slug(c.SourceName)] = c.TrackingPlanID
+		}
+	}
+
+	for _, src := range selected {
+		srcName := Slug(src.Name)
+		target, mapped := opts.Mapping[srcName]
+		if !mapped {
+			var ok bool
+			target, ok = targetsByName[src.DisplayName]
+			if ok && target == "" {
+				return result, fmt.Errorf("several target tracking plans are named %q, map %s explicitly", src.DisplayName, srcName)
+			}
+		}
+		p, err := promoteTrackingPlan(from, to, srcName, target, mapped, opts.DryRun)
+		if err != nil {
+			return result, err
+		}
+		if opts.Connections {
+			p.Connections, err = promoteSourceConnections(from, to, srcName, p.Target, targetConns, opts.DryRun)
+			if err != nil {
+				result.Plans = append(result.Plans, p)
+				return result, err
+			}
+		}
+		result.Plans = append(result.Plans, p)
+	}
+	return result, nil
+}
+
+// selectTrackingPlans returns the plans matching names by name or display name, sorted by display name
+func selectTrackingPlans(plans []TrackingPlan, names []string) ([]TrackingPlan, error) {
+	var selected []TrackingPlan
+	if len(names) == 0 {
+		selected = append(selected, plans...)
+	}
+	for _, name := range names {
+		found := false
+		for _, p := range plans {
+			if Slug(p.Name) == Slug(name) || p.DisplayName == name {
+				selected = append(selected, p)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("source tracking plan %s not found", name)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].DisplayName < selected[j].DisplayName })
+	return selected, nil
+}
+
+func promoteTrackingPlan(from *Client, to *Client, srcName, target string, keepDisplayName, dryRun bool) (PlanPromotion, error) {
+	src, err := from.GetTrackingPlan(srcName)
+	if err != nil {
+		return PlanPromotion{}, errors.Wrapf(err, "failed to get source tracking plan %s", srcName)
+	}
+	promoted := TrackingPlan{DisplayName: src.DisplayName, Rules: src.Rules}
+	p := PlanPromotion{DisplayName: src.DisplayName, Source: srcName, Target: target}
+
+	if target == "" {
+		p.Action = PromotionCreated
+		p.Diff = DiffTrackingPlans(TrackingPlan{DisplayName: promoted.DisplayName}, promoted)
+		if dryRun {
+			return p, nil
+		}
+		created, err := to.CreateTrackingPlan(promoted.DisplayName, promoted.Rules)
+		if err != nil {
+			return p, errors.Wrapf(err, "failed to create tracking plan %q", promoted.DisplayName)
+		}
+		p.Target = Slug(created.Name)
+		return p, nil
+	}
+
+	current, err := to.GetTrackingPlan(target)
+	if err != nil {
+		return p, errors.Wrapf(err, "failed to get target tracking plan %s", target)
+	}
+	if keepDisplayName {
+		promoted.DisplayName = current.DisplayName
+		p.DisplayName = current.DisplayName
+	}
+	p.Diff = DiffTrackingPlans(current, promoted)
+	if p.Diff.IsEmpty() {
+		p.Action = PromotionUnchanged
+		return p, nil
+	}
+	p.Action = PromotionUpdated
+	if dryRun {
+		return p, nil
+	}
+	if _, err := to.UpdateTrackingPlan(target, p.Diff.UpdateMaskPaths(), promoted); err != nil {
+		return p, errors.Wrapf(err, "failed to update tracking plan %s", target)
+	}
+	return p, nil
+}
+
+// promoteSourceConnections connects the sources of a source plan to its target plan. targetConns maps
+// the sources of the target workspace to the plan they are connected to, and is updated as sources
+// are connected.
+func promoteSourceConnections(from *Client, to *Client, srcName, target string, targetConns map[string]string, dryRun bool) ([]PromotedConnection, error) {
+	conns, err := from.ListTrackingPlanSourceConnections(srcName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list source connections of tracking plan %s", srcName)
+	}
+	var promoted []PromotedConnection
+	for _, c := range conns.Connections {
+		source := Slug(c.SourceName)
+		current := targetConns[source]
+		if target != "" && current == target {
+			continue
+		}
+		promoted = append(promoted, PromotedConnection{Source: source, MovedFrom: current})
+		if dryRun {
+			continue
+		}
+		if current != "" {
+			_, err = to.MoveTrackingPlanSourceConnection(current, target, source)
+		} else {
+			_, err = to.CreateTrackingPlanSourceConnection(target, source)
+		}
+		if err != nil {
+			return promoted[:len(promoted)-1], errors.Wrapf(err, "failed to connect source %s to tracking plan %s", source, target)
+		}
+		targetConns[source] = target
+	}
+	return promoted, nil
+}
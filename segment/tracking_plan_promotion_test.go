@@ -0,0 +1,191 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTargetWorkspace = "prod-workspace"
+
+// setupPromotion serves two workspaces with tracking plans and source connections, and returns the
+// client of the target workspace and a log of the write calls made to it. The rules of the staging
+// plan, which is promoted to an identical plan, can be changed with edits.
+func setupPromotion(edits ...func(staging *Rules)) (*Client, *[]string) {
+	to := NewClient(testToken, testTargetWorkspace)
+	to.baseURL = server.URL
+
+	b := NewRulesBuilder()
+	b.Event("Order Completed").Version(2).Prop("order_id", String().Required())
+	kicks := b.Rules()
+	b = NewRulesBuilder()
+	b.Event("Order Completed").Version(1).Prop("order_id", String())
+	oldKicks := b.Rules()
+	b = NewRulesBuilder()
+	b.Event("Page Viewed")
+	web := b.Rules()
+	staging := NewRulesBuilder()
+	staging.Event("Order Completed").Version(2).Prop("order_id", String().Required())
+	stagingKicks := staging.Rules()
+	for _, edit := range edits {
+		edit(&stagingKicks)
+	}
+
+	plan := func(workspace, name, displayName string, rules Rules) TrackingPlan {
+		return TrackingPlan{Name: fmt.Sprintf("workspaces/%s/tracking-plans/%s", workspace, name), DisplayName: displayName, Rules: rules}
+	}
+
+	// writes to the target workspace are logged, relative to its tracking plans endpoint
+	var calls []string
+	targetEndpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testTargetWorkspace, TrackingPlanEndpoint)
+	handle := func(path string, get interface{}) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				json.NewEncoder(w).Encode(get)
+				return
+			}
+			call := r.Method + " " + strings.TrimPrefix(r.URL.Path, targetEndpoint)
+			var req trackingPlanUpdateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.UpdateMask.Paths) > 0 {
+				call += fmt.Sprintf(" %v", req.UpdateMask.Paths)
+			}
+			calls = append(calls, call)
+			if r.Method == http.MethodPost && path == targetEndpoint {
+				fmt.Fprintf(w, `{"name": "workspaces/%s/tracking-plans/rs_8"}`, testTargetWorkspace)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		})
+	}
+	serve := func(workspace string, plans []TrackingPlan, conns map[string][]string) {
+		endpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, workspace, TrackingPlanEndpoint)
+		handle(endpoint, TrackingPlans{TrackingPlans: plans})
+		for _, p := range plans {
+			name := Slug(p.Name)
+			handle(endpoint+"/"+name, p)
+			var c TrackingPlanSourceConnections
+			for _, src := range conns[name] {
+				c.Connections = append(c.Connections, TrackingPlanSourceConnection{
+					SourceName: fmt.Sprintf("workspaces/%s/sources/%s", workspace, src), TrackingPlanID: name})
+				handle(endpoint+"/"+name+"/"+TrackingPlanSourceConnectionEndpoint+"/"+src, nil)
+			}
+			handle(endpoint+"/"+name+"/"+TrackingPlanSourceConnectionEndpoint, c)
+		}
+	}
+	serve(testWorkspace, []TrackingPlan{
+		plan(testWorkspace, "rs_1", "Kicks App", kicks),
+		plan(testWorkspace, "rs_2", "Kicks Web", web),
+		plan(testWorkspace, "rs_3", "Kicks Mobile (staging)", stagingKicks),
+	}, map[string][]string{"rs_1": {"js"}, "rs_2": {"web"}, "rs_3": {"ios"}})
+	serve(testTargetWorkspace, []TrackingPlan{
+		plan(testTargetWorkspace, "rs_7", "Kicks App", oldKicks),
+		plan(testTargetWorkspace, "rs_9", "Kicks Mobile", kicks),
+	}, map[string][]string{"rs_9": {"ios", "js"}})
+	handle(targetEndpoint+"/rs_8/"+TrackingPlanSourceConnectionEndpoint, nil)
+	return to, &calls
+}
+
+func TestPromoteTrackingPlans(t *testing.T) {
+	setup()
+	defer teardown()
+	to, calls := setupPromotion()
+
+	actual, err := PromoteTrackingPlans(client, to, PromotionOptions{
+		Mapping:     map[string]string{"rs_3": "rs_9"},
+		Connections: true,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"PUT /rs_7 [tracking_plan.rules.events]",
+		"DELETE /rs_9/source-connections/js",
+		"POST /rs_7/source-connections",
+		"POST ",
+		"POST /rs_8/source-connections",
+	}, *calls)
+
+	assert.Len(t, actual.Plans, 3)
+	app := actual.Plans[0]
+	assert.Equal(t, "Kicks App", app.DisplayName)
+	assert.Equal(t, PromotionUpdated, app.Action)
+	assert.Equal(t, "rs_7", app.Target)
+	assert.Equal(t, []PromotedConnection{{Source: "js", MovedFrom: "rs_9"}}, app.Connections)
+
+	mobile := actual.Plans[1]
+	assert.Equal(t, PlanPromotion{DisplayName: "Kicks Mobile", Source: "rs_3", Target: "rs_9", Action: PromotionUnchanged}, mobile)
+
+	web := actual.Plans[2]
+	assert.Equal(t, PromotionCreated, web.Action)
+	assert.Equal(t, "rs_8", web.Target)
+	assert.Equal(t, []PromotedConnection{{Source: "web"}}, web.Connections)
+	assert.True(t, actual.Changed())
+
+	expected := "updated \"Kicks App\" (rs_1 -> rs_7)\n" +
+		"  ~ event \"Order Completed\" v1 -> v2\n" +
+		"      ~ properties required: false -> true\n" +
+		"      ~ properties.order_id required: false -> true\n" +
+		"  + source js (moved from rs_9)\n" +
+		"unchanged \"Kicks Mobile\" (rs_3 -> rs_9)\n" +
+		"created \"Kicks Web\" (rs_2 -> rs_8)\n" +
+		"  + event \"Page Viewed\" (v0)\n" +
+		"  + source web\n"
+	assert.Equal(t, expected, actual.String())
+}
+
+func TestPromoteTrackingPlans_DryRun(t *testing.T) {
+	setup()
+	defer teardown()
+	to, calls := setupPromotion()
+
+	actual, err := PromoteTrackingPlans(client, to, PromotionOptions{
+		Plans:       []string{"Kicks Web", "rs_1"},
+		Connections: true,
+		DryRun:      true,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, *calls)
+
+	assert.Len(t, actual.Plans, 2)
+	assert.Equal(t, PromotionUpdated, actual.Plans[0].Action)
+	assert.Equal(t, []PromotedConnection{{Source: "js", MovedFrom: "rs_9"}}, actual.Plans[0].Connections)
+	assert.Equal(t, PromotionCreated, actual.Plans[1].Action)
+	assert.Equal(t, "", actual.Plans[1].Target)
+	assert.Equal(t, []PromotedConnection{{Source: "web"}}, actual.Plans[1].Connections)
+}
+
+func TestPromoteTrackingPlans_UnknownPlan(t *testing.T) {
+	setup()
+	defer teardown()
+	to, _ := setupPromotion()
+
+	_, err := PromoteTrackingPlans(client, to, PromotionOptions{Plans: []string{"rs_404"}})
+	assert.EqualError(t, err, "source tracking plan rs_404 not found")
+}
+
+func TestPromoteTrackingPlans_TraitsAndVersions(t *testing.T) {
+	setup()
+	defer teardown()
+	to, calls := setupPromotion(func(staging *Rules) {
+		staging.IdentifyTraits = []Rule{String().Rule()}
+		v1 := NewRulesBuilder().Event("Order Completed").Version(1).Prop("order_id", String()).Event()
+		staging.Events = append([]Event{v1}, staging.Events...)
+	})
+
+	actual, err := PromoteTrackingPlans(client, to, PromotionOptions{
+		Plans:   []string{"rs_3"},
+		Mapping: map[string]string{"rs_3": "rs_9"},
+	})
+	assert.NoError(t, err)
+
+	mobile := actual.Plans[0]
+	assert.Equal(t, PromotionUpdated, mobile.Action, "changes to traits and other event versions are promoted")
+	assert.Equal(t, []string{"PUT /rs_9 [tracking_plan.rules.events tracking_plan.rules.identify_traits]"}, *calls)
+	assert.Equal(t, "updated \"Kicks Mobile\" (rs_3 -> rs_9)\n"+
+		"  + event \"Order Completed\" (v1)\n"+
+		"  ~ identify traits\n", actual.String())
+}
@@ -81,9 +81,9 @@ func (c *Client) UpdateTrackingPlan(planName string, paths []string, updatedPlan
 }
 
 // CreateTrackingPlanSourceConnection connects a source to a tracking plan
-func (c *Client) CreateTrackingPlanSourceConnection(planName string, srcName string) (trackingPlanSourceConnection, error) {
-	var p trackingPlanSourceConnection
-	req := trackingPlanSourceConnection{SourceName: srcName}
+func (c *Client) CreateTrackingPlanSourceConnection(planName string, srcName string) (TrackingPlanSourceConnection, error) {
+	var p TrackingPlanSourceConnection
+	req := TrackingPlanSourceConnection{SourceName: srcName}
 	endpoint := fmt.Sprintf("%s/%s/%s/%s/%s/", WorkspacesEndpoint, c.workspace, TrackingPlanEndpoint,
 		planName, TrackingPlanSourceConnectionEndpoint)
 	data, err := c.doRequest(http.MethodPost, endpoint, req)
@@ -99,8 +99,8 @@ func (c *Client) CreateTrackingPlanSourceConnection(planName string, srcName str
 }
 
 // ListTrackingPlanSourceConnections lists the source connections for a tracking plan
-func (c *Client) ListTrackingPlanSourceConnections(planName string) (trackingPlanSourceConnections, error) {
-	var p trackingPlanSourceConnections
+func (c *Client) ListTrackingPlanSourceConnections(planName string) (TrackingPlanSourceConnections, error) {
+	var p TrackingPlanSourceConnections
 	data, err := c.doRequest(http.MethodGet, fmt.Sprintf("%s/%s/%s/%s/%s/", WorkspacesEndpoint, c.workspace, TrackingPlanEndpoint, planName, TrackingPlanSourceConnectionEndpoint), nil)
 	if err != nil {
 		return p, err
@@ -132,3 +132,76 @@ func (c *Client) DeleteTrackingPlanSourceConnection(planName string, srcName str
 
 	return nil
 }
+
+// ListAllTrackingPlanSourceConnections lists the source connections of every tracking plan in the workspace
+func (c *Client) ListAllTrackingPlanSourceConnections() (TrackingPlanSourceConnections, error) {
+	var all TrackingPlanSourceConnections
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return all, err
+	}
+	for _, plan := range plans.TrackingPlans {
+		conns, err := c.ListTrackingPlanSourceConnections(Slug(plan.Name))
+		if err != nil {
+			return all, errors.Wrapf(err, "failed to list source connections for tracking plan %s", plan.Name)
+		}
+		all.Connections = append(all.Connections, conns.Connections...)
+	}
+
+	return all, nil
+}
+
+// FindTrackingPlanSourceConnection returns the tracking plan connection of a source. The returned bool
+// is false if the source is not connected to any tracking plan.
+func (c *Client) FindTrackingPlanSourceConnection(srcName string) (TrackingPlanSourceConnection, bool, error) {
+	conns, err := c.ListAllTrackingPlanSourceConnections()
+	if err != nil {
+		return TrackingPlanSourceConnection{}, false, err
+	}
+	for _, conn := range conns.Connections {
+		if Slug(conn.SourceName) == Slug(srcName) {
+			return conn, true, nil
+		}
+	}
+
+	return TrackingPlanSourceConnection{}, false, nil
+}
+
+// MoveTrackingPlanSourceConnection disconnects a source from one tracking plan and connects it to another.
+// If the new connection cannot be created the source is reconnected to its original tracking plan.
+func (c *Client) MoveTrackingPlanSourceConnection(fromPlanName string, toPlanName string, srcName string) (TrackingPlanSourceConnection, error) {
+	err := c.DeleteTrackingPlanSourceConnection(fromPlanName, srcName)
+	if err != nil {
+		return TrackingPlanSourceConnection{}, errors.Wrapf(err, "failed to disconnect source %s from tracking plan %s", srcName, fromPlanName)
+	}
+	conn, err := c.CreateTrackingPlanSourceConnection(toPlanName, srcName)
+	if err != nil {
+		if _, restoreErr := c.CreateTrackingPlanSourceConnection(fromPlanName, srcName); restoreErr != nil {
+			return conn, errors.Wrapf(err, "failed to connect source %s to tracking plan %s and to restore its connection to %s (%v)",
+				srcName, toPlanName, fromPlanName, restoreErr)
+		}
+		return conn, errors.Wrapf(err, "failed to connect source %s to tracking plan %s", srcName, toPlanName)
+	}
+
+	return conn, nil
+}
+
+// ApplyTrackingPlanChanges updates a tracking plan to match updatedPlan. The current plan is fetched and
+// diffed against updatedPlan, and only the parts that changed are sent in the update mask, so an empty
+// display name keeps the current one. If nothing changed the current plan is returned without an update.
+func (c *Client) ApplyTrackingPlanChanges(planName string, updatedPlan TrackingPlan) (TrackingPlan, TrackingPlanDiff, error) {
+	current, err := c.GetTrackingPlan(planName)
+	if err != nil {
+		return current, TrackingPlanDiff{}, err
+	}
+	diff := DiffTrackingPlans(current, updatedPlan)
+	if diff.IsEmpty() {
+		return current, diff, nil
+	}
+	p, err := c.UpdateTrackingPlan(planName, diff.UpdateMaskPaths(), updatedPlan)
+	if err != nil {
+		return p, diff, err
+	}
+
+	return p, diff, nil
+}
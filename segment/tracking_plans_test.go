@@ -205,22 +205,22 @@ func TestTrackingPlans_GetTrackingPlan(t *testing.T) {
 					Version:     1,
 					Rules: Rule{
 						Schema: "http://json-schema.org/draft-07/schema#",
-						Type:   "object",
+						Type:   SingleType(TypeObject),
 						Properties: map[string]Rule{
 							"context": Rule{},
 							"traits":  Rule{},
 							"properties": Rule{
 								Required: []string{"product", "price", "amount"},
-								Type:     "object",
+								Type:     SingleType(TypeObject),
 								Properties: map[string]Rule{
 									"product": Rule{
-										Type: []interface{}{"string"},
+										Type: TypeList(TypeString),
 									},
 									"amount": Rule{
-										Type: []interface{}{"number"},
+										Type: TypeList(TypeNumber),
 									},
 									"price": Rule{
-										Type: []interface{}{"number"},
+										Type: TypeList(TypeNumber),
 									},
 								},
 							},
@@ -230,14 +230,14 @@ func TestTrackingPlans_GetTrackingPlan(t *testing.T) {
 			},
 			Global: Rule{
 				Schema: "http://json-schema.org/draft-07/schema#",
-				Type:   "object",
+				Type:   SingleType(TypeObject),
 				Properties: map[string]Rule{
 					"context": Rule{
 						Required: []string{"library"},
-						Type:     "object",
+						Type:     SingleType(TypeObject),
 						Properties: map[string]Rule{
 							"library": Rule{
-								Type: []interface{}{"object"},
+								Type: TypeList(TypeObject),
 							},
 						},
 					},
@@ -247,19 +247,19 @@ func TestTrackingPlans_GetTrackingPlan(t *testing.T) {
 			},
 			Identify: Rule{
 				Schema: "http://json-schema.org/draft-07/schema#",
-				Type:   "object",
+				Type:   SingleType(TypeObject),
 				Properties: map[string]Rule{
 					"traits": Rule{
-						Type: "object",
+						Type: SingleType(TypeObject),
 						Properties: map[string]Rule{
 							"occupation": Rule{
-								Type: []interface{}{"string"},
+								Type: TypeList(TypeString),
 							},
 							"age": Rule{
-								Type: []interface{}{"number"},
+								Type: TypeList(TypeNumber),
 							},
 							"name": Rule{
-								Type: []interface{}{"string"},
+								Type: TypeList(TypeString),
 							},
 						},
 						Required: []string{"name"},
@@ -270,18 +270,18 @@ func TestTrackingPlans_GetTrackingPlan(t *testing.T) {
 			},
 			Group: Rule{
 				Schema: "http://json-schema.org/draft-07/schema#",
-				Type:   "object",
+				Type:   SingleType(TypeObject),
 				Properties: map[string]Rule{
 					"properties": Rule{},
 					"context":    Rule{},
 					"traits": Rule{
 						Properties: map[string]Rule{
 							"company": Rule{
-								Type: []interface{}{"object"},
+								Type: TypeList(TypeObject),
 							},
 						},
 						Required: []string{"company"},
-						Type:     "object",
+						Type:     SingleType(TypeObject),
 					},
 				},
 			},
@@ -306,22 +306,22 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 				Version:     1,
 				Rules: Rule{
 					Schema: "http://json-schema.org/draft-07/schema#",
-					Type:   "object",
+					Type:   SingleType(TypeObject),
 					Properties: map[string]Rule{
 						"context": Rule{},
 						"traits":  Rule{},
 						"properties": Rule{
 							Required: []string{"product", "price", "amount"},
-							Type:     "object",
+							Type:     SingleType(TypeObject),
 							Properties: map[string]Rule{
 								"product": Rule{
-									Type: []interface{}{"string"},
+									Type: TypeList(TypeString),
 								},
 								"amount": Rule{
-									Type: []interface{}{"number"},
+									Type: TypeList(TypeNumber),
 								},
 								"price": Rule{
-									Type: []interface{}{"number"},
+									Type: TypeList(TypeNumber),
 								},
 							},
 						},
@@ -331,14 +331,14 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 		},
 		Global: Rule{
 			Schema: "http://json-schema.org/draft-07/schema#",
-			Type:   "object",
+			Type:   SingleType(TypeObject),
 			Properties: map[string]Rule{
 				"context": Rule{
 					Required: []string{"library"},
-					Type:     "object",
+					Type:     SingleType(TypeObject),
 					Properties: map[string]Rule{
 						"library": Rule{
-							Type: []interface{}{"object"},
+							Type: TypeList(TypeObject),
 						},
 					},
 				},
@@ -348,19 +348,19 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 		},
 		Identify: Rule{
 			Schema: "http://json-schema.org/draft-07/schema#",
-			Type:   "object",
+			Type:   SingleType(TypeObject),
 			Properties: map[string]Rule{
 				"traits": Rule{
-					Type: "object",
+					Type: SingleType(TypeObject),
 					Properties: map[string]Rule{
 						"occupation": Rule{
-							Type: []interface{}{"string"},
+							Type: TypeList(TypeString),
 						},
 						"age": Rule{
-							Type: []interface{}{"number"},
+							Type: TypeList(TypeNumber),
 						},
 						"name": Rule{
-							Type: []interface{}{"string"},
+							Type: TypeList(TypeString),
 						},
 					},
 					Required: []string{"name"},
@@ -371,18 +371,18 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 		},
 		Group: Rule{
 			Schema: "http://json-schema.org/draft-07/schema#",
-			Type:   "object",
+			Type:   SingleType(TypeObject),
 			Properties: map[string]Rule{
 				"properties": Rule{},
 				"context":    Rule{},
 				"traits": Rule{
 					Properties: map[string]Rule{
 						"company": Rule{
-							Type: []interface{}{"object"},
+							Type: TypeList(TypeObject),
 						},
 					},
 					Required: []string{"company"},
-					Type:     "object",
+					Type:     SingleType(TypeObject),
 				},
 			},
 		},
@@ -393,7 +393,6 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 
 	mux.HandleFunc(endpoint, func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, `{
-			"tracking_plan": {
 			"display_name": "Kicks App",
 			"rules": {
 				"events": [
@@ -506,14 +505,12 @@ func TestTrackingPlans_CreateTrackingPlan(t *testing.T) {
 					}
 				}
 			}
-		  }
 		}`)
 	})
 
-	expected := trackingPlanCreateRequest{
-		TrackingPlan: TrackingPlan{
-			DisplayName: testDisplayName,
-			Rules:       testRules},
+	expected := TrackingPlan{
+		DisplayName: testDisplayName,
+		Rules:       testRules,
 	}
 
 	actual, err := client.CreateTrackingPlan(testDisplayName, testRules)
@@ -544,14 +541,14 @@ func TestTrackingPlan_UpdateTrackingPlan(t *testing.T) {
 					Description: "Who checked out what",
 					Rules: Rule{
 						Schema: "http://json-schema.org/draft-04/schema#",
-						Type:   "object",
+						Type:   SingleType(TypeObject),
 						Properties: map[string]Rule{
 							"traits": Rule{},
 							"properties": Rule{
-								Type: "object",
+								Type: SingleType(TypeObject),
 								Properties: map[string]Rule{
 									"product": Rule{
-										Type: []interface{}{"string"},
+										Type: TypeList(TypeString),
 									},
 								},
 								Required: []string{"product"},
@@ -564,10 +561,10 @@ func TestTrackingPlan_UpdateTrackingPlan(t *testing.T) {
 			},
 			Global: Rule{
 				Schema: "http://json-schema.org/draft-04/schema#",
-				Type:   "object",
+				Type:   SingleType(TypeObject),
 				Properties: map[string]Rule{
 					"context": Rule{
-						Type: "object",
+						Type: SingleType(TypeObject),
 						Properties: map[string]Rule{
 							"userAgent": {},
 						},
@@ -675,7 +672,7 @@ func TestTrackingPlan_CreateTrackingPlanSourceConnection(t *testing.T) {
 	})
 
 	sourcePath := fmt.Sprintf("%s/%s/%s/%s", WorkspacesEndpoint, testWorkspace, SourceEndpoint, testSrcName)
-	expected := trackingPlanSourceConnection{
+	expected := TrackingPlanSourceConnection{
 		SourceName:     sourcePath,
 		TrackingPlanID: testPlanID,
 	}
@@ -708,8 +705,8 @@ func TestTrackingPlan_ListTrackingPlanSourceConnections(t *testing.T) {
 	})
 
 	sourcePath := fmt.Sprintf("%s/%s/%s/%s", WorkspacesEndpoint, testWorkspace, SourceEndpoint, testSrcName)
-	expected := trackingPlanSourceConnections{
-		Connections: []trackingPlanSourceConnection{
+	expected := TrackingPlanSourceConnections{
+		Connections: []TrackingPlanSourceConnection{
 			{
 				SourceName:     sourcePath,
 				TrackingPlanID: testPlanID,
@@ -752,3 +749,93 @@ func TestTrackingPlan_DeleteTrackingPlanSourceConnection(t *testing.T) {
 	err := client.DeleteTrackingPlanSourceConnection(testPlanName, srcName)
 	assert.NoError(t, err)
 }
+
+func TestTrackingPlan_ListAllTrackingPlanSourceConnections(t *testing.T) {
+	setup()
+	defer teardown()
+
+	plansEndpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint)
+	mux.HandleFunc(plansEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"tracking_plans": [
+				{"name": "workspaces/test-workspace/tracking-plans/rs_123"},
+				{"name": "workspaces/test-workspace/tracking-plans/rs_456"}
+			]
+		}`)
+	})
+	mux.HandleFunc(plansEndpoint+"/rs_123/"+TrackingPlanSourceConnectionEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"connections": [{"source_name": "workspaces/test-workspace/sources/js", "tracking_plan_id": "rs_123"}]}`)
+	})
+	mux.HandleFunc(plansEndpoint+"/rs_456/"+TrackingPlanSourceConnectionEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"connections": [{"source_name": "workspaces/test-workspace/sources/ios", "tracking_plan_id": "rs_456"}]}`)
+	})
+
+	actual, err := client.ListAllTrackingPlanSourceConnections()
+	assert.NoError(t, err)
+
+	expected := TrackingPlanSourceConnections{
+		Connections: []TrackingPlanSourceConnection{
+			{SourceName: "workspaces/test-workspace/sources/js", TrackingPlanID: "rs_123"},
+			{SourceName: "workspaces/test-workspace/sources/ios", TrackingPlanID: "rs_456"},
+		},
+	}
+	assert.Equal(t, expected, actual)
+
+	conn, found, err := client.FindTrackingPlanSourceConnection("ios")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "rs_456", conn.TrackingPlanID)
+
+	_, found, err = client.FindTrackingPlanSourceConnection("android")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestTrackingPlan_MoveTrackingPlanSourceConnection(t *testing.T) {
+	setup()
+	defer teardown()
+
+	srcName := "js"
+	plansEndpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint)
+
+	var calls []string
+	mux.HandleFunc(plansEndpoint+"/rs_123/"+TrackingPlanSourceConnectionEndpoint+"/"+srcName, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" rs_123")
+	})
+	mux.HandleFunc(plansEndpoint+"/rs_456/"+TrackingPlanSourceConnectionEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" rs_456")
+		fmt.Fprint(w, `{"source_name": "workspaces/test-workspace/sources/js", "tracking_plan_id": "rs_456"}`)
+	})
+
+	actual, err := client.MoveTrackingPlanSourceConnection("rs_123", "rs_456", srcName)
+	assert.NoError(t, err)
+
+	expected := TrackingPlanSourceConnection{SourceName: "workspaces/test-workspace/sources/js", TrackingPlanID: "rs_456"}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, []string{"DELETE rs_123", "POST rs_456"}, calls)
+}
+
+func TestTrackingPlan_MoveTrackingPlanSourceConnectionRestoresOnFailure(t *testing.T) {
+	setup()
+	defer teardown()
+
+	srcName := "js"
+	plansEndpoint := fmt.Sprintf("/%s/%s/%s/%s", apiVersion, WorkspacesEndpoint, testWorkspace, TrackingPlanEndpoint)
+
+	var calls []string
+	mux.HandleFunc(plansEndpoint+"/rs_123/"+TrackingPlanSourceConnectionEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" rs_123")
+		fmt.Fprint(w, `{"source_name": "workspaces/test-workspace/sources/js", "tracking_plan_id": "rs_123"}`)
+	})
+	mux.HandleFunc(plansEndpoint+"/rs_123/"+TrackingPlanSourceConnectionEndpoint+"/"+srcName, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" rs_123")
+	})
+	mux.HandleFunc(plansEndpoint+"/rs_456/"+TrackingPlanSourceConnectionEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" rs_456")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+	})
+
+	_, err := client.MoveTrackingPlanSourceConnection("rs_123", "rs_456", srcName)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"DELETE rs_123", "POST rs_456", "POST rs_123"}, calls)
+}
@@ -0,0 +1,188 @@
+package segment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Transaction makes changes to a workspace in several steps, and records with each completed step
+// how to undo it. The Config API has no transactions, so when a step fails the completed steps are
+// undone in reverse order, leaving the workspace as it was.
+type Transaction struct {
+	client *Client
+	steps  []transactionStep
+}
+
+// transactionStep is a completed step with its compensating action
+type transactionStep struct {
+	description string
+	undo        func() error
+}
+
+// TransactionError is returned when a step of a transaction fails. It holds the error of the step
+// and the errors of the steps that could not be undone, if any.
+type TransactionError struct {
+	Err error
+	// RollbackErrors are the errors of the compensating actions that failed, in the order they ran.
+	// The resources of these steps are left in the workspace.
+	RollbackErrors []error
+}
+
+func (e *TransactionError) Error() string {
+	if len(e.RollbackErrors) == 0 {
+		return fmt.Sprintf("%v (rolled back)", e.Err)
+	}
+	msgs := make([]string, len(e.RollbackErrors))
+	for i, err := range e.RollbackErrors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%v (rollback failed: %s)", e.Err, strings.Join(msgs, "; "))
+}
+
+// Cause returns the error of the step that failed, for errors.Cause
+func (e *TransactionError) Cause() error {
+	return e.Err
+}
+
+// RunTransaction runs fn with a new transaction. If fn returns an error, the steps it completed are
+// rolled back and a *TransactionError is returned.
+//
+// For example, to provision a source with its destinations and tracking plan:
+//
+//	err := c.RunTransaction(func(tx *segment.Transaction) error {
+//		if _, err := tx.CreateSource("ios", "catalog/sources/ios"); err != nil {
+//			return err
+//		}
+//		if _, err := tx.CreateDestination("ios", "mixpanel", "CLOUD", true, configs); err != nil {
+//			return err
+//		}
+//		plan, err := tx.CreateTrackingPlan("Kicks iOS", rules)
+//		if err != nil {
+//			return err
+//		}
+//		_, err = tx.CreateTrackingPlanSourceConnection(plan.Name, "ios")
+//		return err
+//	})
+func (c *Client) RunTransaction(fn func(tx *Transaction) error) error {
+	tx := &Transaction{client: c}
+	if err := fn(tx); err != nil {
+		return &TransactionError{Err: err, RollbackErrors: tx.rollback()}
+	}
+	return nil
+}
+
+// Steps describes the steps completed so far, in order
+func (t *Transaction) Steps() []string {
+	steps := make([]string, len(t.steps))
+	for i, s := range t.steps {
+		steps[i] = s.description
+	}
+	return steps
+}
+
+// Do runs a step that is not covered by the other methods of the transaction. If action succeeds,
+// undo is recorded to compensate for it on rollback. undo is nil for steps that change nothing.
+func (t *Transaction) Do(description string, action func() error, undo func() error) error {
+	if err := action(); err != nil {
+		return errors.Wrapf(err, "failed to %s", description)
+	}
+	if undo == nil {
+		undo = func() error { return nil }
+	}
+	t.record(description, undo)
+	return nil
+}
+
+func (t *Transaction) record(description string, undo func() error) {
+	t.steps = append(t.steps, transactionStep{description: description, undo: undo})
+}
+
+// rollback undoes the completed steps in reverse order, and returns the errors of the steps that
+// could not be undone
+func (t *Transaction) rollback() []error {
+	var errs []error
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		s := t.steps[i]
+		if err := s.undo(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to undo %s", s.description))
+		}
+	}
+	t.steps = nil
+	return errs
+}
+
+// CreateSource creates a source, which is deleted on rollback
+func (t *Transaction) CreateSource(srcName string, catName string) (Source, error) {
+	description := "create source " + srcName
+	src, err := t.client.CreateSource(srcName, catName)
+	if err != nil {
+		return src, errors.Wrapf(err, "failed to %s", description)
+	}
+	t.record(description, func() error {
+		return t.client.DeleteSource(srcName)
+	})
+	return src, nil
+}
+
+// CreateDestination creates a destination, which is deleted on rollback
+func (t *Transaction) CreateDestination(srcName string, destName string, connMode string, enabled bool, configs []DestinationConfig) (Destination, error) {
+	description := fmt.Sprintf("create destination %s of source %s", destName, srcName)
+	d, err := t.client.CreateDestination(srcName, destName, connMode, enabled, configs)
+	if err != nil {
+		return d, errors.Wrapf(err, "failed to %s", description)
+	}
+	t.record(description, func() error {
+		return t.client.DeleteDestination(srcName, destName)
+	})
+	return d, nil
+}
+
+// UpdateDestination updates a destination, whose previous state and settings are restored on
+// rollback. The previous settings are restored as read, without resolving secret references.
+func (t *Transaction) UpdateDestination(srcName string, destName string, enabled bool, configs []DestinationConfig) (Destination, error) {
+	description := fmt.Sprintf("update destination %s of source %s", destName, srcName)
+	prev, err := t.client.GetDestination(srcName, destName)
+	if err != nil {
+		return prev, errors.Wrapf(err, "failed to %s", description)
+	}
+	d, err := t.client.UpdateDestination(srcName, destName, enabled, configs)
+	if err != nil {
+		return d, errors.Wrapf(err, "failed to %s", description)
+	}
+	t.record(description, func() error {
+		_, err := t.client.WithSecretResolver(nil).UpdateDestination(srcName, destName, prev.Enabled, prev.Configs)
+		return err
+	})
+	return d, nil
+}
+
+// CreateTrackingPlan creates a tracking plan, which is deleted on rollback
+func (t *Transaction) CreateTrackingPlan(displayName string, rules Rules) (TrackingPlan, error) {
+	description := fmt.Sprintf("create tracking plan %q", displayName)
+	p, err := t.client.CreateTrackingPlan(displayName, rules)
+	if err != nil {
+		return p, errors.Wrapf(err, "failed to %s", description)
+	}
+	planName := Slug(p.Name)
+	t.record(fmt.Sprintf("create tracking plan %q (%s)", displayName, planName), func() error {
+		return t.client.DeleteTrackingPlan(planName)
+	})
+	return p, nil
+}
+
+// CreateTrackingPlanSourceConnection connects a source to a tracking plan, from which it is
+// disconnected on rollback
+func (t *Transaction) CreateTrackingPlanSourceConnection(planName string, srcName string) (TrackingPlanSourceConnection, error) {
+	planName = Slug(planName)
+	description := fmt.Sprintf("connect source %s to tracking plan %s", srcName, planName)
+	conn, err := t.client.CreateTrackingPlanSourceConnection(planName, srcName)
+	if err != nil {
+		return conn, errors.Wrapf(err, "failed to %s", description)
+	}
+	t.record(description, func() error {
+		return t.client.DeleteTrackingPlanSourceConnection(planName, srcName)
+	})
+	return conn, nil
+}
@@ -0,0 +1,207 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// updates are the destination updates received by the provisioning server
+var updates []destinationUpdateRequest
+
+// setupProvisioning serves the requests of a provisioning transaction, failing those in fail, and
+// returns the requests it received as the method and the path relative to the workspace
+func setupProvisioning(fail ...string) *[]string {
+	setup()
+	updates = nil
+	calls := &[]string{}
+	prefix := fmt.Sprintf("/%s/%s/%s/", apiVersion, WorkspacesEndpoint, testWorkspace)
+	mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		call := r.Method + " " + strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		*calls = append(*calls, call)
+		for _, f := range fail {
+			if f == call {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch call {
+		case "POST sources":
+			fmt.Fprint(w, `{"name": "workspaces/test-workspace/sources/ios", "catalog_name": "catalog/sources/ios"}`)
+		case "POST sources/ios/destinations":
+			fmt.Fprint(w, `{"name": "workspaces/test-workspace/sources/ios/destinations/mixpanel", "enabled": true}`)
+		case "GET sources/js/destinations/google-analytics":
+			fmt.Fprint(w, `{"name": "workspaces/test-workspace/sources/js/destinations/google-analytics", "enabled": true,
+				"config": [{"name": "workspaces/test-workspace/sources/js/destinations/google-analytics/config/trackingId", "value": "UA-1", "type": "string"}]}`)
+		case "PATCH sources/js/destinations/google-analytics":
+			var req destinationUpdateRequest
+			json.Unmarshal(body, &req)
+			updates = append(updates, req)
+			json.NewEncoder(w).Encode(req.Destination)
+		case "GET sources/js/destinations/mixpanel":
+			fmt.Fprint(w, `{"name": "workspaces/test-workspace/sources/js/destinations/mixpanel", "enabled": true,
+				"config": [{"name": "workspaces/test-workspace/sources/js/destinations/mixpanel/config/token", "value": "${env:SEGMENT_TEST_PREV_TOKEN}", "type": "string"}]}`)
+		case "PATCH sources/js/destinations/mixpanel":
+			var req destinationUpdateRequest
+			json.Unmarshal(body, &req)
+			updates = append(updates, req)
+			json.NewEncoder(w).Encode(req.Destination)
+		case "POST tracking-plans":
+			fmt.Fprint(w, `{"name": "workspaces/test-workspace/tracking-plans/rs_1", "display_name": "Kicks iOS"}`)
+		case "POST tracking-plans/rs_1/source-connections":
+			fmt.Fprint(w, `{"source_name": "workspaces/test-workspace/sources/ios", "tracking_plan_id": "rs_1"}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	return calls
+}
+
+// provision creates a source with a destination and a tracking plan, and reconfigures another
+// destination
+func provision(tx *Transaction) error {
+	if _, err := tx.CreateSource("ios", "catalog/sources/ios"); err != nil {
+		return err
+	}
+	if _, err := tx.CreateDestination("ios", "mixpanel", "CLOUD", true, nil); err != nil {
+		return err
+	}
+	configs := []DestinationConfig{{Name: "workspaces/test-workspace/sources/js/destinations/google-analytics/config/trackingId", Value: "UA-2"}}
+	if _, err := tx.UpdateDestination("js", "google-analytics", false, configs); err != nil {
+		return err
+	}
+	plan, err := tx.CreateTrackingPlan("Kicks iOS", Rules{})
+	if err != nil {
+		return err
+	}
+	_, err = tx.CreateTrackingPlanSourceConnection(plan.Name, "ios")
+	return err
+}
+
+func TestClient_RunTransaction(t *testing.T) {
+	calls := setupProvisioning()
+	defer teardown()
+
+	var steps []string
+	err := client.RunTransaction(func(tx *Transaction) error {
+		err := provision(tx)
+		steps = tx.Steps()
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"create source ios",
+		"create destination mixpanel of source ios",
+		"update destination google-analytics of source js",
+		`create tracking plan "Kicks iOS" (rs_1)`,
+		"connect source ios to tracking plan rs_1",
+	}, steps)
+	for _, call := range *calls {
+		assert.False(t, strings.HasPrefix(call, "DELETE"), "nothing is rolled back")
+	}
+}
+
+func TestClient_RunTransaction_Rollback(t *testing.T) {
+	calls := setupProvisioning("POST tracking-plans/rs_1/source-connections")
+	defer teardown()
+
+	err := client.RunTransaction(provision)
+	if !assert.Error(t, err) {
+		return
+	}
+	txErr, ok := err.(*TransactionError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Empty(t, txErr.RollbackErrors)
+	assert.EqualError(t, errors.Cause(err), "the request is invalid")
+	assert.Equal(t, "failed to connect source ios to tracking plan rs_1: the request is invalid (rolled back)", err.Error())
+
+	assert.Equal(t, []string{
+		"DELETE tracking-plans/rs_1",
+		"PATCH sources/js/destinations/google-analytics",
+		"DELETE sources/ios/destinations/mixpanel",
+		"DELETE sources/ios",
+	}, (*calls)[6:], "the completed steps are undone in reverse order")
+}
+
+func TestClient_RunTransaction_RollbackRestoresDestination(t *testing.T) {
+	setupProvisioning()
+	defer teardown()
+
+	err := client.RunTransaction(func(tx *Transaction) error {
+		configs := []DestinationConfig{{Name: "workspaces/test-workspace/sources/js/destinations/google-analytics/config/trackingId", Value: "UA-2"}}
+		if _, err := tx.UpdateDestination("js", "google-analytics", false, configs); err != nil {
+			return err
+		}
+		if err := tx.Do("log update", func() error { return nil }, nil); err != nil {
+			return err
+		}
+		return tx.Do("check destination", func() error { return fmt.Errorf("check failed") }, nil)
+	})
+	assert.EqualError(t, err, "failed to check destination: check failed (rolled back)")
+
+	if !assert.Len(t, updates, 2) {
+		return
+	}
+	assert.False(t, updates[0].Destination.Enabled)
+	assert.Equal(t, "UA-2", updates[0].Destination.Configs[0].Value)
+	assert.True(t, updates[1].Destination.Enabled, "the destination is enabled again")
+	assert.Equal(t, "UA-1", updates[1].Destination.Configs[0].Value, "the previous settings are restored")
+}
+
+func TestClient_RunTransaction_RollbackKeepsSecretReferences(t *testing.T) {
+	setupProvisioning()
+	defer teardown()
+	client.SetSecretResolver(DefaultSecretResolver)
+	os.Setenv("SEGMENT_TEST_TOKEN", "mp-token")
+	defer os.Unsetenv("SEGMENT_TEST_TOKEN")
+
+	err := client.RunTransaction(func(tx *Transaction) error {
+		configs := []DestinationConfig{{Name: "workspaces/test-workspace/sources/js/destinations/mixpanel/config/token", Value: "${env:SEGMENT_TEST_TOKEN}"}}
+		if _, err := tx.UpdateDestination("js", "mixpanel", true, configs); err != nil {
+			return err
+		}
+		return fmt.Errorf("check failed")
+	})
+	assert.EqualError(t, err, "check failed (rolled back)")
+
+	if !assert.Len(t, updates, 2) {
+		return
+	}
+	assert.Equal(t, "mp-token", updates[0].Destination.Configs[0].Value)
+	assert.Equal(t, "${env:SEGMENT_TEST_PREV_TOKEN}", updates[1].Destination.Configs[0].Value, "the previous settings are restored as read")
+}
+
+func TestClient_RunTransaction_RollbackFailure(t *testing.T) {
+	setupProvisioning("POST tracking-plans", "DELETE sources/ios", "PATCH sources/js/destinations/google-analytics")
+	defer teardown()
+
+	err := client.RunTransaction(func(tx *Transaction) error {
+		if _, err := tx.CreateSource("ios", "catalog/sources/ios"); err != nil {
+			return err
+		}
+		if _, err := tx.CreateDestination("ios", "mixpanel", "CLOUD", true, nil); err != nil {
+			return err
+		}
+		_, err := tx.CreateTrackingPlan("Kicks iOS", Rules{})
+		return err
+	})
+	txErr, ok := err.(*TransactionError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.EqualError(t, txErr.Err, `failed to create tracking plan "Kicks iOS": the request is invalid`)
+	if assert.Len(t, txErr.RollbackErrors, 1) {
+		assert.EqualError(t, txErr.RollbackErrors[0], "failed to undo create source ios: the request is invalid")
+	}
+	assert.Equal(t, `failed to create tracking plan "Kicks iOS": the request is invalid (rollback failed: failed to undo create source ios: the request is invalid)`, err.Error())
+}
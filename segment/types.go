@@ -33,6 +33,25 @@ type LibraryConfig struct {
 	APIHost              string `json:"api_host,omitempty"`
 }
 
+// SourceSchemaConfig contains the schema settings of a source, which control how events that violate
+// its tracking plan are handled
+type SourceSchemaConfig struct {
+	Name                               string `json:"name,omitempty"`
+	AllowUnplannedTrackEvents          bool   `json:"allow_unplanned_track_events"`
+	AllowUnplannedIdentifyTraits       bool   `json:"allow_unplanned_identify_traits"`
+	AllowUnplannedGroupTraits          bool   `json:"allow_unplanned_group_traits"`
+	AllowUnplannedTrackEventProperties bool   `json:"allow_unplanned_track_event_properties"`
+	ForwardingBlockedEventsTo          string `json:"forwarding_blocked_events_to"`
+	AllowTrackEventOnViolations        bool   `json:"allow_track_event_on_violations"`
+	AllowTrackPropertiesOnViolations   bool   `json:"allow_track_properties_on_violations"`
+	AllowIdentifyTraitsOnViolations    bool   `json:"allow_identify_traits_on_violations"`
+	AllowGroupTraitsOnViolations       bool   `json:"allow_group_traits_on_violations"`
+	ForwardingViolationsTo             string `json:"forwarding_violations_to"`
+	CommonTrackEventOnViolations       string `json:"common_track_event_on_violations,omitempty"`
+	CommonIdentifyEventOnViolations    string `json:"common_identify_event_on_violations,omitempty"`
+	CommonGroupEventOnViolations       string `json:"common_group_event_on_violations,omitempty"`
+}
+
 // Destinations defines the struct for the destination object
 type Destinations struct {
 	Destinations []Destination `json:"destinations,omitempty"`
@@ -64,26 +83,47 @@ type TrackingPlan struct {
 	UpdateTime  *time.Time `json:"update_time,omitempty"`
 }
 
+// TrackingPlanSourceConnection contains the information about a source connected to a tracking plan
+type TrackingPlanSourceConnection struct {
+	SourceName     string `json:"source_name,omitempty"`
+	TrackingPlanID string `json:"tracking_plan_id,omitempty"`
+}
+
+// TrackingPlanSourceConnections defines the struct for the tracking plan source connections object
+type TrackingPlanSourceConnections struct {
+	Connections []TrackingPlanSourceConnection `json:"connections,omitempty"`
+}
+
 // Rules contains the information about all the rules of a tracking plan
 type Rules struct {
-	Global         Rule          `json:"global,omitempty"`
-	Events         []Event       `json:"events,omitempty"`
-	Identify       Rule          `json:"identify,omitempty"`
-	Group          Rule          `json:"group,omitempty"`
-	IdentifyTraits []interface{} `json:"identify_traits"`
-	GroupTraits    []interface{} `json:"group_traits"`
+	Global         Rule    `json:"global,omitempty"`
+	Events         []Event `json:"events,omitempty"`
+	Identify       Rule    `json:"identify,omitempty"`
+	Group          Rule    `json:"group,omitempty"`
+	IdentifyTraits []Rule  `json:"identify_traits"`
+	GroupTraits    []Rule  `json:"group_traits"`
 }
 
-// Rule contains the information about the rule definition
+// Rule contains the information about the rule definition. It models the subset of JSON Schema
+// draft-07 that Segment uses for tracking plans and is encoded by Rule.MarshalJSON.
 type Rule struct {
-	Description string                 `json:"description,omitempty"`
-	Enum        []interface{}          `json:"enum,omitempty"`
-	Labels      map[string]interface{} `json:"labels,omitempty"`
-	Pattern     interface{}            `json:"pattern,omitempty"`
-	Properties  map[string]Rule        `json:"properties,omitempty"`
-	Required    []string               `json:"required,omitempty"`
-	Type        interface{}            `json:"type,omitempty"`
-	Schema      string                 `json:"$schema,omitempty"`
+	Schema               string
+	Ref                  string
+	Description          string
+	Type                 Type
+	Format               string
+	Enum                 Enum
+	Pattern              string
+	Minimum              *float64
+	Maximum              *float64
+	MinLength            *int
+	MaxLength            *int
+	Properties           map[string]Rule
+	Required             []string
+	Items                *Rule
+	AdditionalProperties *AdditionalProperties
+	Definitions          map[string]Rule
+	Labels               map[string]interface{}
 }
 
 // Event contains the rules for each tracking event
@@ -102,6 +142,51 @@ type DestinationConfig struct {
 	Type        string      `json:"type,omitempty"`
 }
 
+// EventDeliveryMetrics contains the event delivery counts of a destination over a time window
+type EventDeliveryMetrics struct {
+	Name           string                       `json:"name,omitempty"`
+	Granularity    string                       `json:"granularity,omitempty"`
+	StartTime      *time.Time                   `json:"start_time,omitempty"`
+	EndTime        *time.Time                   `json:"end_time,omitempty"`
+	Delivered      int64                        `json:"delivered,omitempty"`
+	Failed         int64                        `json:"failed,omitempty"`
+	Retried        int64                        `json:"retried,omitempty"`
+	Discarded      int64                        `json:"discarded,omitempty"`
+	FailureReasons []EventDeliveryFailureReason `json:"failure_reasons,omitempty"`
+	Datapoints     []EventDeliveryDatapoint     `json:"datapoints,omitempty"`
+}
+
+// EventDeliveryFailureReason contains the number of events that failed for a given reason
+type EventDeliveryFailureReason struct {
+	Reason string `json:"reason,omitempty"`
+	Count  int64  `json:"count,omitempty"`
+}
+
+// EventDeliveryDatapoint contains the event delivery counts for a single granularity bucket
+type EventDeliveryDatapoint struct {
+	Time      *time.Time `json:"time,omitempty"`
+	Delivered int64      `json:"delivered,omitempty"`
+	Failed    int64      `json:"failed,omitempty"`
+	Retried   int64      `json:"retried,omitempty"`
+	Discarded int64      `json:"discarded,omitempty"`
+}
+
+// EventDeliverySummary contains the delivery health of every destination in a workspace
+type EventDeliverySummary struct {
+	Threshold    float64                     `json:"threshold"`
+	Destinations []DestinationDeliveryHealth `json:"destinations,omitempty"`
+}
+
+// DestinationDeliveryHealth contains the event delivery metrics of a single destination
+// and whether its success rate fell below the summary threshold
+type DestinationDeliveryHealth struct {
+	Source         string               `json:"source,omitempty"`
+	Destination    string               `json:"destination,omitempty"`
+	Metrics        EventDeliveryMetrics `json:"metrics"`
+	SuccessRate    float64              `json:"success_rate"`
+	BelowThreshold bool                 `json:"below_threshold"`
+}
+
 // UpdateMask contains information for updating Destinations
 type UpdateMask struct {
 	Paths []string `json:"paths,omitempty"`
@@ -111,6 +196,11 @@ type sourceCreateRequest struct {
 	Source Source `json:"source,omitempty"`
 }
 
+type sourceSchemaConfigUpdateRequest struct {
+	SchemaConfig SourceSchemaConfig `json:"schema_config"`
+	UpdateMask   UpdateMask         `json:"update_mask,omitempty"`
+}
+
 type destinationCreateRequest struct {
 	Destination Destination `json:"destination,omitempty"`
 }
@@ -128,12 +218,3 @@ type trackingPlanUpdateRequest struct {
 	TrackingPlan TrackingPlan `json:"tracking_plan,omitempty"`
 	UpdateMask   UpdateMask   `json:"update_mask,omitempty"`
 }
-
-type trackingPlanSourceConnection struct {
-	SourceName     string `json:"source_name,omitempty"`
-	TrackingPlanID string `json:"tracking_plan_id,omitempty"`
-}
-
-type trackingPlanSourceConnections struct {
-	Connections []trackingPlanSourceConnection `json:"connections,omitempty"`
-}
@@ -0,0 +1,346 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// maxLineErrors caps how many malformed lines a report keeps details for
+const maxLineErrors = 20
+
+// BatchOptions controls a batch validation run
+type BatchOptions struct {
+	Options
+	// Workers is the number of events validated concurrently. It defaults to the number of CPUs.
+	Workers int
+	// TopSources is the number of most offending sources kept in the report. It defaults to 10.
+	TopSources int
+	// SourceOf returns the source an event was sent from. It defaults to the event's projectId,
+	// falling back to its writeKey.
+	SourceOf func(event map[string]interface{}) string
+}
+
+// Report aggregates the violations found in a batch of events
+type Report struct {
+	Events          int              `json:"events"`
+	ValidEvents     int              `json:"valid_events"`
+	InvalidEvents   int              `json:"invalid_events"`
+	MalformedEvents int              `json:"malformed_events"`
+	Violations      int              `json:"violations"`
+	ByEvent         []EventStats     `json:"by_event,omitempty"`
+	TopSources      []SourceStats    `json:"top_sources,omitempty"`
+	UnplannedEvents []UnplannedEvent `json:"unplanned_events,omitempty"`
+	Errors          []LineError      `json:"errors,omitempty"`
+}
+
+// EventStats contains the violations found for one event name, or one call type for calls other than track
+type EventStats struct {
+	Event      string          `json:"event"`
+	Events     int             `json:"events"`
+	Invalid    int             `json:"invalid"`
+	Violations int             `json:"violations"`
+	Properties []PropertyStats `json:"properties,omitempty"`
+}
+
+// PropertyStats counts the violations of one kind at one path
+type PropertyStats struct {
+	Path  string `json:"path"`
+	Kind  Kind   `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// SourceStats counts the events and violations sent from one source
+type SourceStats struct {
+	Source     string `json:"source"`
+	Events     int    `json:"events"`
+	Invalid    int    `json:"invalid"`
+	Violations int    `json:"violations"`
+}
+
+// UnplannedEvent counts how often an event missing from the tracking plan was seen
+type UnplannedEvent struct {
+	Event string `json:"event"`
+	Count int    `json:"count"`
+}
+
+// LineError describes an input line that could not be validated
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type batchLine struct {
+	n    int
+	data []byte
+}
+
+type batchResult struct {
+	n      int
+	source string
+	res    Result
+	err    error
+}
+
+// ValidateFile validates a file of newline-delimited events, which may be gzipped
+func (v *Validator) ValidateFile(path string, opts BatchOptions) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+
+	return v.ValidateStream(f, opts)
+}
+
+// ValidateStream validates newline-delimited events read from r, which may be gzipped. Events are
+// streamed through a bounded pool of workers, so the input never has to fit in memory.
+func (v *Validator) ValidateStream(r io.Reader, opts BatchOptions) (*Report, error) {
+	v = &Validator{rules: v.rules, events: v.events, opts: opts.Options}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.TopSources <= 0 {
+		opts.TopSources = 10
+	}
+	if opts.SourceOf == nil {
+		opts.SourceOf = defaultSourceOf
+	}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read gzip header")
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	lines := make(chan batchLine, opts.Workers*2)
+	results := make(chan batchResult, opts.Workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range lines {
+				results <- v.validateLine(l, opts.SourceOf)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	agg := newAggregator()
+	done := make(chan struct{})
+	go func() {
+		for res := range results {
+			agg.add(res)
+		}
+		close(done)
+	}()
+
+	readErr := readLines(br, lines)
+	close(lines)
+	<-done
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "failed to read events")
+	}
+
+	return agg.finish(opts.TopSources), nil
+}
+
+func readLines(br *bufio.Reader, lines chan<- batchLine) error {
+	n := 0
+	for {
+		data, err := br.ReadBytes('\n')
+		if len(data) > 0 {
+			n++
+			if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+				lines <- batchLine{n: n, data: trimmed}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (v *Validator) validateLine(l batchLine, sourceOf func(map[string]interface{}) string) batchResult {
+	dec := json.NewDecoder(bytes.NewReader(l.data))
+	dec.UseNumber()
+	var event map[string]interface{}
+	if err := dec.Decode(&event); err != nil {
+		return batchResult{n: l.n, err: errors.Wrap(err, "failed to unmarshal event")}
+	}
+	res, err := v.ValidateEvent(event)
+	return batchResult{n: l.n, source: sourceOf(event), res: res, err: err}
+}
+
+func defaultSourceOf(event map[string]interface{}) string {
+	for _, key := range []string{"projectId", "writeKey"} {
+		if s, ok := event[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return "unknown"
+}
+
+// firstLineErrors keeps the errors of the first lines, since lines are validated out of order
+func firstLineErrors(errs []LineError) []LineError {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	if len(errs) > maxLineErrors {
+		errs = errs[:maxLineErrors]
+	}
+	return errs
+}
+
+type propertyKey struct {
+	path string
+	kind Kind
+}
+
+type eventAgg struct {
+	events     int
+	invalid    int
+	violations int
+	properties map[propertyKey]int
+}
+
+type aggregator struct {
+	report    Report
+	events    map[string]*eventAgg
+	sources   map[string]*SourceStats
+	unplanned map[string]int
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		events:    map[string]*eventAgg{},
+		sources:   map[string]*SourceStats{},
+		unplanned: map[string]int{},
+	}
+}
+
+func (a *aggregator) add(r batchResult) {
+	a.report.Events++
+	if r.err != nil {
+		a.report.MalformedEvents++
+		a.report.Errors = append(a.report.Errors, LineError{Line: r.n, Error: r.err.Error()})
+		if len(a.report.Errors) > 2*maxLineErrors {
+			a.report.Errors = firstLineErrors(a.report.Errors)
+		}
+		return
+	}
+
+	name := r.res.Event
+	if r.res.Type != CallTrack {
+		name = r.res.Type
+	}
+	e, ok := a.events[name]
+	if !ok {
+		e = &eventAgg{properties: map[propertyKey]int{}}
+		a.events[name] = e
+	}
+	src, ok := a.sources[r.source]
+	if !ok {
+		src = &SourceStats{Source: r.source}
+		a.sources[r.source] = src
+	}
+
+	e.events++
+	src.Events++
+	if r.res.Unplanned {
+		a.unplanned[name]++
+	}
+	if r.res.Valid() {
+		a.report.ValidEvents++
+		return
+	}
+	a.report.InvalidEvents++
+	e.invalid++
+	src.Invalid++
+	for _, vi := range r.res.Violations {
+		a.report.Violations++
+		e.violations++
+		src.Violations++
+		if vi.Kind == KindUnplannedEvent {
+			continue
+		}
+		e.properties[propertyKey{vi.Path, vi.Kind}]++
+	}
+}
+
+func (a *aggregator) finish(topSources int) *Report {
+	r := a.report
+	r.Errors = firstLineErrors(r.Errors)
+
+	for name, e := range a.events {
+		if e.violations == 0 {
+			continue
+		}
+		stats := EventStats{Event: name, Events: e.events, Invalid: e.invalid, Violations: e.violations}
+		for k, count := range e.properties {
+			stats.Properties = append(stats.Properties, PropertyStats{Path: k.path, Kind: k.kind, Count: count})
+		}
+		sort.Slice(stats.Properties, func(i, j int) bool {
+			pi, pj := stats.Properties[i], stats.Properties[j]
+			if pi.Count != pj.Count {
+				return pi.Count > pj.Count
+			}
+			if pi.Path != pj.Path {
+				return pi.Path < pj.Path
+			}
+			return pi.Kind < pj.Kind
+		})
+		r.ByEvent = append(r.ByEvent, stats)
+	}
+	sort.Slice(r.ByEvent, func(i, j int) bool {
+		if r.ByEvent[i].Violations != r.ByEvent[j].Violations {
+			return r.ByEvent[i].Violations > r.ByEvent[j].Violations
+		}
+		return r.ByEvent[i].Event < r.ByEvent[j].Event
+	})
+
+	for _, s := range a.sources {
+		if s.Violations > 0 {
+			r.TopSources = append(r.TopSources, *s)
+		}
+	}
+	sort.Slice(r.TopSources, func(i, j int) bool {
+		if r.TopSources[i].Violations != r.TopSources[j].Violations {
+			return r.TopSources[i].Violations > r.TopSources[j].Violations
+		}
+		return r.TopSources[i].Source < r.TopSources[j].Source
+	})
+	if len(r.TopSources) > topSources {
+		r.TopSources = r.TopSources[:topSources]
+	}
+
+	for name, count := range a.unplanned {
+		r.UnplannedEvents = append(r.UnplannedEvents, UnplannedEvent{Event: name, Count: count})
+	}
+	sort.Slice(r.UnplannedEvents, func(i, j int) bool {
+		if r.UnplannedEvents[i].Count != r.UnplannedEvents[j].Count {
+			return r.UnplannedEvents[i].Count > r.UnplannedEvents[j].Count
+		}
+		return r.UnplannedEvents[i].Event < r.UnplannedEvents[j].Event
+	})
+
+	return &r
+}
@@ -0,0 +1,105 @@
+package validate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEvents = `{"type": "track", "event": "Order Completed", "projectId": "web", "context": {"library": {}}, "properties": {"product": "kicks", "price": 10}}
+{"type": "track", "event": "Order Completed", "projectId": "web", "context": {"library": {}}, "properties": {"price": "10"}}
+{"type": "track", "event": "Order Completed", "projectId": "ios", "context": {"library": {}}, "properties": {"product": "kicks"}}
+
+{"type": "track", "event": "Cart Viewed", "projectId": "ios", "context": {"library": {}}}
+{"type": "track", "event": "Cart Viewed", "projectId": "ios", "context": {"library": {}}}
+{"type": "identify", "writeKey": "android", "context": {"library": {}}, "traits": {"email": "jane@example.com"}}
+not json
+`
+
+func TestBatch_ValidateStream(t *testing.T) {
+	v := New(testPlan(), Options{})
+
+	report, err := v.ValidateStream(strings.NewReader(testEvents), BatchOptions{Workers: 3})
+	assert.NoError(t, err)
+
+	expected := &Report{
+		Events:          7,
+		ValidEvents:     2,
+		InvalidEvents:   4,
+		MalformedEvents: 1,
+		Violations:      5,
+		ByEvent: []EventStats{
+			{
+				Event: "Order Completed", Events: 3, Invalid: 2, Violations: 3,
+				Properties: []PropertyStats{
+					{Path: "properties.price", Kind: KindRequired, Count: 1},
+					{Path: "properties.price", Kind: KindType, Count: 1},
+					{Path: "properties.product", Kind: KindRequired, Count: 1},
+				},
+			},
+			{Event: "Cart Viewed", Events: 2, Invalid: 2, Violations: 2},
+		},
+		TopSources: []SourceStats{
+			{Source: "ios", Events: 3, Invalid: 3, Violations: 3},
+			{Source: "web", Events: 2, Invalid: 1, Violations: 2},
+		},
+		UnplannedEvents: []UnplannedEvent{{Event: "Cart Viewed", Count: 2}},
+		Errors: []LineError{
+			{Line: 8, Error: "failed to unmarshal event: invalid character 'o' in literal null (expecting 'u')"},
+		},
+	}
+	assert.Equal(t, expected, report)
+}
+
+func TestBatch_ValidateStreamAllowUnplannedEvents(t *testing.T) {
+	opts := BatchOptions{Options: Options{AllowUnplannedEvents: true}}
+	report, err := New(testPlan(), Options{}).ValidateStream(strings.NewReader(testEvents), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.ValidEvents)
+	assert.Equal(t, []UnplannedEvent{{Event: "Cart Viewed", Count: 2}}, report.UnplannedEvents, "unplanned events are counted even when allowed")
+}
+
+func TestBatch_ValidateStreamGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(testEvents))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	report, err := New(testPlan(), Options{}).ValidateStream(&buf, BatchOptions{TopSources: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, report.Events)
+	assert.Len(t, report.TopSources, 1)
+	assert.Equal(t, "ios", report.TopSources[0].Source)
+}
+
+func TestBatch_ReportFormats(t *testing.T) {
+	report, err := New(testPlan(), Options{}).ValidateStream(strings.NewReader(testEvents), BatchOptions{})
+	assert.NoError(t, err)
+
+	var csv bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&csv))
+	assert.Equal(t, `section,event,path,kind,source,count
+violation,Order Completed,properties.price,required,,1
+violation,Order Completed,properties.price,type,,1
+violation,Order Completed,properties.product,required,,1
+source,,,,ios,3
+source,,,,web,2
+unplanned_event,Cart Viewed,,unplanned_event,,2
+`, csv.String())
+
+	var md bytes.Buffer
+	assert.NoError(t, report.WriteMarkdown(&md))
+	assert.Contains(t, md.String(), "| 7 | 2 | 4 | 1 | 5 |")
+	assert.Contains(t, md.String(), "### Order Completed")
+	assert.Contains(t, md.String(), "| `properties.price` | type | 1 |")
+	assert.Contains(t, md.String(), "| Cart Viewed | 2 |")
+	assert.Contains(t, md.String(), "- line 8: failed to unmarshal event")
+
+	var js bytes.Buffer
+	assert.NoError(t, report.WriteJSON(&js))
+	assert.Contains(t, js.String(), `"unplanned_events": [`)
+}
@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Report sections used in the CSV rows
+const (
+	csvSectionViolation = "violation"
+	csvSectionSource    = "source"
+	csvSectionUnplanned = "unplanned_event"
+)
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the report as a single CSV table. Each row is a property violation count,
+// an offending source or an unplanned event, as named by its section column.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"section", "event", "path", "kind", "source", "count"}}
+	for _, e := range r.ByEvent {
+		for _, p := range e.Properties {
+			rows = append(rows, []string{csvSectionViolation, e.Event, p.Path, string(p.Kind), "", strconv.Itoa(p.Count)})
+		}
+	}
+	for _, s := range r.TopSources {
+		rows = append(rows, []string{csvSectionSource, "", "", "", s.Source, strconv.Itoa(s.Violations)})
+	}
+	for _, u := range r.UnplannedEvents {
+		rows = append(rows, []string{csvSectionUnplanned, u.Event, "", string(KindUnplannedEvent), "", strconv.Itoa(u.Count)})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteMarkdown writes the report as a Markdown document
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Tracking plan violation report\n\n")
+	b.WriteString("| Events | Valid | Invalid | Malformed | Violations |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d |\n", r.Events, r.ValidEvents, r.InvalidEvents, r.MalformedEvents, r.Violations)
+
+	if len(r.ByEvent) > 0 {
+		b.WriteString("\n## Violations by event\n")
+		for _, e := range r.ByEvent {
+			fmt.Fprintf(&b, "\n### %s\n\n", markdownEscape(e.Event))
+			fmt.Fprintf(&b, "%d of %d events invalid, %d violations.\n", e.Invalid, e.Events, e.Violations)
+			if len(e.Properties) == 0 {
+				continue
+			}
+			b.WriteString("\n| Path | Kind | Count |\n| --- | --- | ---: |\n")
+			for _, p := range e.Properties {
+				fmt.Fprintf(&b, "| `%s` | %s | %d |\n", p.Path, p.Kind, p.Count)
+			}
+		}
+	}
+
+	if len(r.TopSources) > 0 {
+		b.WriteString("\n## Top offending sources\n\n")
+		b.WriteString("| Source | Events | Invalid | Violations |\n| --- | ---: | ---: | ---: |\n")
+		for _, s := range r.TopSources {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", markdownEscape(s.Source), s.Events, s.Invalid, s.Violations)
+		}
+	}
+
+	if len(r.UnplannedEvents) > 0 {
+		b.WriteString("\n## Unplanned events\n\n")
+		b.WriteString("| Event | Count |\n| --- | ---: |\n")
+		for _, u := range r.UnplannedEvents {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownEscape(u.Event), u.Count)
+		}
+	}
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\n## Malformed lines\n\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "- line %d: %s\n", e.Line, markdownEscape(e.Error))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
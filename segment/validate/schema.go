@@ -0,0 +1,251 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/jsonvalue"
+)
+
+// definitionsRefPrefix is the only $ref form tracking plans use
+const definitionsRefPrefix = "#/definitions/"
+
+var (
+	patternsMu sync.RWMutex
+	patterns   = map[string]*regexp.Regexp{}
+)
+
+// compilePattern compiles and caches the regular expression of a pattern keyword
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternsMu.RLock()
+	re, ok := patterns[pattern]
+	patternsMu.RUnlock()
+	if ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternsMu.Lock()
+	patterns[pattern] = re
+	patternsMu.Unlock()
+	return re, nil
+}
+
+// Value validates a decoded JSON value against a rule and returns the violations found under path.
+// Numbers may be float64 or json.Number. References are resolved against the rule's own definitions.
+func Value(rule segment.Rule, v interface{}, path string) []Violation {
+	s := schemaValidator{root: rule}
+	s.validate(rule, v, path)
+	return s.violations
+}
+
+type schemaValidator struct {
+	root       segment.Rule
+	violations []Violation
+	// unplanned reports properties that are not declared by an object rule. It is
+	// only set for the properties and traits sections of an event.
+	unplanned map[string]bool
+}
+
+func (s *schemaValidator) add(path string, kind Kind, format string, args ...interface{}) {
+	s.violations = append(s.violations, Violation{Path: path, Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *schemaValidator) validate(rule segment.Rule, v interface{}, path string) {
+	if rule.Ref != "" {
+		ref, ok := s.resolve(rule.Ref)
+		if !ok {
+			s.add(path, KindRef, "unresolved reference %q", rule.Ref)
+			return
+		}
+		rule = ref
+	}
+
+	if !rule.Type.IsZero() && !matchesType(rule.Type, v) {
+		s.add(path, KindType, "expected %s, got %s", strings.Join(rule.Type.Names, " or "), jsonvalue.Type(v))
+		return
+	}
+	if len(rule.Enum) > 0 && !rule.Enum.Contains(v) {
+		s.add(path, KindEnum, "value %s is not one of %s", encode(v), encode(rule.Enum))
+	}
+
+	switch val := v.(type) {
+	case string:
+		s.validateString(rule, val, path)
+	case float64, json.Number:
+		f, _ := jsonvalue.Float(val)
+		if rule.Minimum != nil && f < *rule.Minimum {
+			s.add(path, KindMinimum, "value %v is less than the minimum %v", f, *rule.Minimum)
+		}
+		if rule.Maximum != nil && f > *rule.Maximum {
+			s.add(path, KindMaximum, "value %v is greater than the maximum %v", f, *rule.Maximum)
+		}
+	case map[string]interface{}:
+		s.validateObject(rule, val, path)
+	case []interface{}:
+		if rule.Items != nil {
+			for i, item := range val {
+				s.validate(*rule.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (s *schemaValidator) validateString(rule segment.Rule, val string, path string) {
+	n := utf8.RuneCountInString(val)
+	if rule.MinLength != nil && n < *rule.MinLength {
+		s.add(path, KindMinLength, "length %d is less than the minimum length %d", n, *rule.MinLength)
+	}
+	if rule.MaxLength != nil && n > *rule.MaxLength {
+		s.add(path, KindMaxLength, "length %d is greater than the maximum length %d", n, *rule.MaxLength)
+	}
+	if rule.Pattern != "" {
+		re, err := compilePattern(rule.Pattern)
+		if err != nil {
+			s.add(path, KindPattern, "invalid pattern %q: %v", rule.Pattern, err)
+		} else if !re.MatchString(val) {
+			s.add(path, KindPattern, "value %q does not match pattern %q", val, rule.Pattern)
+		}
+	}
+	if rule.Format != "" && !matchesFormat(rule.Format, val) {
+		s.add(path, KindFormat, "value %q is not a valid %s", val, rule.Format)
+	}
+}
+
+func (s *schemaValidator) validateObject(rule segment.Rule, val map[string]interface{}, path string) {
+	for _, name := range rule.Required {
+		if _, ok := val[name]; !ok {
+			s.add(joinPath(path, name), KindRequired, "required property %q is missing", name)
+		}
+	}
+
+	names := make([]string, 0, len(val))
+	for name := range val {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := joinPath(path, name)
+		if child, ok := rule.Properties[name]; ok {
+			s.validate(child, val[name], p)
+			continue
+		}
+		if s.unplanned[path] {
+			s.add(p, KindUnplannedProperty, "property %q is not in the tracking plan", name)
+			continue
+		}
+		if ap := rule.AdditionalProperties; ap != nil {
+			if ap.Schema != nil {
+				s.validate(*ap.Schema, val[name], p)
+			} else if !ap.Allowed {
+				s.add(p, KindAdditionalProperties, "additional property %q is not allowed", name)
+			}
+		}
+	}
+}
+
+func (s *schemaValidator) resolve(ref string) (segment.Rule, bool) {
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return segment.Rule{}, false
+	}
+	r, ok := s.root.Definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+	return r, ok
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func matchesType(t segment.Type, v interface{}) bool {
+	for _, name := range t.Names {
+		switch name {
+		case segment.TypeNull:
+			if v == nil {
+				return true
+			}
+		case segment.TypeBoolean:
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case segment.TypeString:
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case segment.TypeNumber:
+			if _, ok := jsonvalue.Float(v); ok {
+				return true
+			}
+		case segment.TypeInteger:
+			if f, ok := jsonvalue.Float(v); ok && f == math.Trunc(f) {
+				return true
+			}
+		case segment.TypeObject:
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		case segment.TypeArray:
+			if _, ok := v.([]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// matchesFormat checks the draft-07 formats tracking plans use. Unknown formats always match.
+func matchesFormat(format, val string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, val)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", val)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", val)
+		return err == nil
+	case "email":
+		return emailPattern.MatchString(val)
+	case "uri":
+		u, err := url.Parse(val)
+		return err == nil && u.IsAbs()
+	case "ipv4":
+		ip := net.ParseIP(val)
+		return ip != nil && ip.To4() != nil && !strings.Contains(val, ":")
+	case "ipv6":
+		ip := net.ParseIP(val)
+		return ip != nil && strings.Contains(val, ":")
+	case "uuid":
+		return uuidPattern.MatchString(val)
+	}
+	return true
+}
+
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	var v interface{}
+	assert.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestSchema_Value(t *testing.T) {
+	var rule segment.Rule
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"address": {"$ref": "#/definitions/address"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"name": {"type": "string", "minLength": 2, "maxLength": 5},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"missing": {"$ref": "#/definitions/missing"},
+			"when": {"type": "string", "format": "date-time"}
+		},
+		"additionalProperties": false,
+		"definitions": {
+			"address": {"type": "object", "required": ["city"]}
+		}
+	}`), &rule))
+
+	actual := Value(rule, decode(t, `{
+		"address": {},
+		"age": 151.5,
+		"name": "a",
+		"tags": ["ok", 1],
+		"missing": 1,
+		"when": "yesterday",
+		"extra": true
+	}`), "")
+
+	expected := []Violation{
+		{Path: "address.city", Kind: KindRequired, Message: `required property "city" is missing`},
+		{Path: "age", Kind: KindType, Message: "expected integer, got number"},
+		{Path: "extra", Kind: KindAdditionalProperties, Message: `additional property "extra" is not allowed`},
+		{Path: "missing", Kind: KindRef, Message: `unresolved reference "#/definitions/missing"`},
+		{Path: "name", Kind: KindMinLength, Message: "length 1 is less than the minimum length 2"},
+		{Path: "tags[1]", Kind: KindType, Message: "expected string, got integer"},
+		{Path: "when", Kind: KindFormat, Message: `value "yesterday" is not a valid date-time`},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestSchema_Bounds(t *testing.T) {
+	rule := segment.Number().Min(1).Max(10).Rule()
+
+	assert.Len(t, Value(rule, json.Number("5"), "n"), 0)
+	assert.Equal(t, KindMinimum, Value(rule, 0.5, "n")[0].Kind)
+	assert.Equal(t, KindMaximum, Value(rule, json.Number("11"), "n")[0].Kind)
+}
+
+func TestSchema_Formats(t *testing.T) {
+	valid := map[string]string{
+		"date":  "2019-02-05",
+		"email": "jane@example.com",
+		"uri":   "https://example.com/a",
+		"ipv4":  "10.0.0.1",
+		"ipv6":  "::1",
+		"uuid":  "123e4567-e89b-12d3-a456-426614174000",
+	}
+	for format, val := range valid {
+		assert.True(t, matchesFormat(format, val), format)
+		assert.False(t, matchesFormat(format, "nope"), format)
+	}
+	assert.True(t, matchesFormat("hostname", "anything"))
+}
@@ -0,0 +1,189 @@
+// Package validate checks Segment events against a tracking plan locally, so analytics payloads
+// can be verified in unit tests and CI before they reach Segment.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// Kind identifies the rule an event violated
+type Kind string
+
+// Violation kinds
+const (
+	KindType                 Kind = "type"
+	KindRequired             Kind = "required"
+	KindEnum                 Kind = "enum"
+	KindPattern              Kind = "pattern"
+	KindFormat               Kind = "format"
+	KindMinimum              Kind = "minimum"
+	KindMaximum              Kind = "maximum"
+	KindMinLength            Kind = "min_length"
+	KindMaxLength            Kind = "max_length"
+	KindAdditionalProperties Kind = "additional_properties"
+	KindRef                  Kind = "ref"
+	KindUnplannedEvent       Kind = "unplanned_event"
+	KindUnplannedProperty    Kind = "unplanned_property"
+)
+
+// Segment call types
+const (
+	CallTrack    = "track"
+	CallIdentify = "identify"
+	CallGroup    = "group"
+	CallPage     = "page"
+	CallScreen   = "screen"
+)
+
+// Violation describes a single way an event does not match its tracking plan
+type Violation struct {
+	Path    string `json:"path"`
+	Kind    Kind   `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return fmt.Sprintf("%s: %s", v.Kind, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Path, v.Kind, v.Message)
+}
+
+// Result contains the outcome of validating one event
+type Result struct {
+	Type       string      `json:"type"`
+	Event      string      `json:"event,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+	// Unplanned is set for track events missing from the tracking plan, even when
+	// AllowUnplannedEvents keeps them from being reported as violations
+	Unplanned bool `json:"unplanned,omitempty"`
+}
+
+// Valid reports whether the event had no violations
+func (r Result) Valid() bool {
+	return len(r.Violations) == 0
+}
+
+// Options controls which unplanned data is reported
+type Options struct {
+	// AllowUnplannedEvents stops track events missing from the plan from being reported
+	AllowUnplannedEvents bool
+	// AllowUnplannedProperties stops properties and traits missing from the plan from being reported
+	AllowUnplannedProperties bool
+}
+
+// Validator validates events against a tracking plan
+type Validator struct {
+	rules  segment.Rules
+	events map[string]segment.Event
+	opts   Options
+}
+
+// New creates a validator for a tracking plan as returned by GetTrackingPlan
+func New(plan segment.TrackingPlan, opts Options) *Validator {
+	return NewFromRules(plan.Rules, opts)
+}
+
+// NewFromRules creates a validator for tracking plan rules
+func NewFromRules(rules segment.Rules, opts Options) *Validator {
+	v := &Validator{
+		rules:  rules,
+		events: make(map[string]segment.Event, len(rules.Events)),
+		opts:   opts,
+	}
+	for _, e := range rules.Events {
+		// Keep the highest version of each event
+		if cur, ok := v.events[e.Name]; !ok || e.Version > cur.Version {
+			v.events[e.Name] = e
+		}
+	}
+	return v
+}
+
+// Validate validates a Segment event given as JSON
+func (v *Validator) Validate(data []byte) (Result, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var event map[string]interface{}
+	if err := dec.Decode(&event); err != nil {
+		return Result{}, errors.Wrap(err, "failed to unmarshal event")
+	}
+	return v.ValidateEvent(event)
+}
+
+// ValidateEvent validates a decoded Segment event
+func (v *Validator) ValidateEvent(event map[string]interface{}) (Result, error) {
+	callType, _ := event["type"].(string)
+	if callType == "" {
+		if _, ok := event["event"]; ok {
+			callType = CallTrack
+		}
+	}
+	res := Result{Type: callType}
+
+	var rule segment.Rule
+	var checked []string
+	switch callType {
+	case CallTrack:
+		name, _ := event["event"].(string)
+		res.Event = name
+		e, ok := v.events[name]
+		if !ok {
+			res.Unplanned = true
+			if !v.opts.AllowUnplannedEvents {
+				res.Violations = append(res.Violations, Violation{
+					Path:    "event",
+					Kind:    KindUnplannedEvent,
+					Message: fmt.Sprintf("event %q is not in the tracking plan", name),
+				})
+			}
+			break
+		}
+		rule = e.Rules
+		checked = []string{"properties"}
+	case CallIdentify:
+		rule = v.rules.Identify
+		checked = []string{"traits"}
+	case CallGroup:
+		rule = v.rules.Group
+		checked = []string{"traits"}
+	case CallPage, CallScreen:
+	default:
+		return res, fmt.Errorf("unsupported event type %q", callType)
+	}
+
+	res.Violations = append(res.Violations, Value(v.rules.Global, event, "")...)
+
+	s := schemaValidator{root: rule}
+	if !v.opts.AllowUnplannedProperties {
+		s.unplanned = map[string]bool{}
+		for _, section := range checked {
+			// Only sections the plan describes can have unplanned fields
+			if _, ok := rule.Properties[section]; ok {
+				s.unplanned[section] = true
+			}
+		}
+		if callType != CallTrack && !hasProperties(rule, checked) {
+			s.unplanned = nil
+		}
+	}
+	s.validate(rule, event, "")
+	res.Violations = append(res.Violations, s.violations...)
+
+	return res, nil
+}
+
+// hasProperties reports whether any of the sections of an envelope rule declare properties
+func hasProperties(rule segment.Rule, sections []string) bool {
+	for _, section := range sections {
+		if len(rule.Properties[section].Properties) > 0 {
+			return true
+		}
+	}
+	return false
+}
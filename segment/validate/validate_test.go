@@ -0,0 +1,115 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPlan() segment.TrackingPlan {
+	tp := segment.NewRulesBuilder()
+	tp.Event("Order Completed").Version(1).
+		Prop("product", segment.String().Required()).
+		Prop("price", segment.Number().Required().Min(0)).
+		Prop("currency", segment.String().Enum("USD", "EUR")).
+		Prop("coupon", segment.String().Pattern("^[A-Z0-9]+$").Nullable())
+	tp.Global().Context("library", segment.Object().Required())
+	tp.IdentifyTrait("email", segment.String().Required().Format("email"))
+	return tp.TrackingPlan("Kicks App")
+}
+
+func TestValidate_ValidTrackEvent(t *testing.T) {
+	v := New(testPlan(), Options{})
+
+	res, err := v.Validate([]byte(`{
+		"type": "track",
+		"event": "Order Completed",
+		"context": {"library": {"name": "analytics.js"}},
+		"properties": {"product": "kicks", "price": 99.5, "currency": "USD", "coupon": null}
+	}`))
+	assert.NoError(t, err)
+	assert.True(t, res.Valid(), "%v", res.Violations)
+	assert.Equal(t, CallTrack, res.Type)
+	assert.Equal(t, "Order Completed", res.Event)
+}
+
+func TestValidate_TrackEventViolations(t *testing.T) {
+	v := New(testPlan(), Options{})
+
+	res, err := v.Validate([]byte(`{
+		"type": "track",
+		"event": "Order Completed",
+		"context": {},
+		"properties": {"price": "free", "currency": "GBP", "coupon": "summer sale", "color": "red"}
+	}`))
+	assert.NoError(t, err)
+
+	expected := []Violation{
+		{Path: "context.library", Kind: KindRequired, Message: `required property "library" is missing`},
+		{Path: "properties.product", Kind: KindRequired, Message: `required property "product" is missing`},
+		{Path: "properties.color", Kind: KindUnplannedProperty, Message: `property "color" is not in the tracking plan`},
+		{Path: "properties.coupon", Kind: KindPattern, Message: `value "summer sale" does not match pattern "^[A-Z0-9]+$"`},
+		{Path: "properties.currency", Kind: KindEnum, Message: `value "GBP" is not one of ["USD","EUR"]`},
+		{Path: "properties.price", Kind: KindType, Message: "expected number, got string"},
+	}
+	assert.Equal(t, expected, res.Violations)
+}
+
+func TestValidate_UnplannedEvent(t *testing.T) {
+	res, err := New(testPlan(), Options{}).Validate([]byte(`{"event": "Cart Viewed", "context": {"library": {}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Violation{{Path: "event", Kind: KindUnplannedEvent, Message: `event "Cart Viewed" is not in the tracking plan`}}, res.Violations)
+	assert.True(t, res.Unplanned)
+
+	res, err = New(testPlan(), Options{AllowUnplannedEvents: true}).Validate([]byte(`{"event": "Cart Viewed", "context": {"library": {}}}`))
+	assert.NoError(t, err)
+	assert.True(t, res.Valid())
+	assert.True(t, res.Unplanned, "allowed unplanned events are still flagged")
+
+	res, err = New(testPlan(), Options{}).Validate([]byte(`{"event": "Order Completed", "context": {"library": {}}, "properties": {"product": "kicks", "price": 10}}`))
+	assert.NoError(t, err)
+	assert.False(t, res.Unplanned)
+}
+
+func TestValidate_AllowUnplannedProperties(t *testing.T) {
+	v := New(testPlan(), Options{AllowUnplannedProperties: true})
+
+	res, err := v.Validate([]byte(`{
+		"type": "track",
+		"event": "Order Completed",
+		"context": {"library": {}},
+		"properties": {"product": "kicks", "price": 1, "color": "red"}
+	}`))
+	assert.NoError(t, err)
+	assert.True(t, res.Valid(), "%v", res.Violations)
+}
+
+func TestValidate_Identify(t *testing.T) {
+	v := New(testPlan(), Options{})
+
+	res, err := v.Validate([]byte(`{"type": "identify", "context": {"library": {}}, "traits": {"email": "not-an-email", "age": 30}}`))
+	assert.NoError(t, err)
+
+	expected := []Violation{
+		{Path: "traits.age", Kind: KindUnplannedProperty, Message: `property "age" is not in the tracking plan`},
+		{Path: "traits.email", Kind: KindFormat, Message: `value "not-an-email" is not a valid email`},
+	}
+	assert.Equal(t, expected, res.Violations)
+}
+
+func TestValidate_PageOnlyChecksGlobal(t *testing.T) {
+	res, err := New(testPlan(), Options{}).Validate([]byte(`{"type": "page", "context": {}, "properties": {"any": 1}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Violation{{Path: "context.library", Kind: KindRequired, Message: `required property "library" is missing`}}, res.Violations)
+}
+
+func TestValidate_Errors(t *testing.T) {
+	v := New(testPlan(), Options{})
+
+	_, err := v.Validate([]byte(`{`))
+	assert.Error(t, err)
+
+	_, err = v.Validate([]byte(`{"type": "alias"}`))
+	assert.Error(t, err)
+}
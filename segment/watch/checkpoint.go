@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint records the resources of a workspace at a poll, so that a watcher can resume from it
+type Checkpoint struct {
+	// Time is when the poll started
+	Time time.Time `json:"time"`
+	// Versions are the update times of the resources, by kind and name, e.g.
+	// "destination:workspaces/myworkspace/sources/js/destinations/mixpanel"
+	Versions map[string]string `json:"versions"`
+}
+
+func key(kind Kind, name string) string {
+	return string(kind) + ":" + name
+}
+
+func parseKey(k string) (Kind, string) {
+	i := strings.Index(k, ":")
+	return Kind(k[:i]), k[i+1:]
+}
+
+// LoadCheckpoint reads a checkpoint from a JSON file. It returns a zero checkpoint if the file does
+// not exist, so that a watcher starts from scratch the first time.
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	var c Checkpoint
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, errors.Wrapf(err, "failed to read checkpoint %s", path)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.Wrapf(err, "failed to parse checkpoint %s", path)
+	}
+	return c, nil
+}
+
+// Save writes the checkpoint to a JSON file. The file is replaced at once, so that it is never
+// left half written.
+func (c Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write checkpoint %s", path)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to write checkpoint %s", path)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to write checkpoint %s", path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "failed to write checkpoint %s", path)
+}
@@ -0,0 +1,47 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	c, err := LoadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.True(t, c.Time.IsZero(), "a missing checkpoint is zero")
+
+	saved := Checkpoint{
+		Time:     time.Date(2019, 2, 5, 0, 28, 31, 0, time.UTC),
+		Versions: map[string]string{key(KindSource, "workspaces/ws/sources/js"): "2019-02-05T00:28:31Z"},
+	}
+	assert.NoError(t, saved.Save(path))
+	c, err = LoadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.Equal(t, saved, c)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary file is left")
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	_, err = LoadCheckpoint(path)
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	kind, name := parseKey(key(KindTrackingPlan, "workspaces/ws/tracking-plans/rs_1"))
+	assert.Equal(t, KindTrackingPlan, kind)
+	assert.Equal(t, "workspaces/ws/tracking-plans/rs_1", name)
+}
@@ -0,0 +1,305 @@
+// Package watch reports the changes made to a Segment workspace as they happen. The Config API has
+// no webhooks, so a Watcher polls the sources, destinations and tracking plans of the workspace and
+// compares the update times of consecutive polls.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/pkg/errors"
+)
+
+// DefaultInterval is the time between polls unless Options.Interval is set
+const DefaultInterval = time.Minute
+
+// Kind is the kind of resource an event is about
+type Kind string
+
+// Resource kinds
+const (
+	KindSource       Kind = "source"
+	KindDestination  Kind = "destination"
+	KindTrackingPlan Kind = "tracking-plan"
+)
+
+// kindOrder is the order events are emitted in within a poll
+var kindOrder = map[Kind]int{KindSource: 0, KindDestination: 1, KindTrackingPlan: 2}
+
+// Op is what happened to a resource
+type Op string
+
+// Operations
+const (
+	Added    Op = "added"
+	Modified Op = "modified"
+	Removed  Op = "removed"
+)
+
+// Event is a change of a resource. Events of added and modified resources carry the resource,
+// while events of removed resources only have its name.
+type Event struct {
+	Op   Op   `json:"op"`
+	Kind Kind `json:"kind"`
+	// Name is the full name of the resource, e.g. workspaces/myworkspace/sources/js
+	Name         string                `json:"name"`
+	Source       *segment.Source       `json:"source,omitempty"`
+	Destination  *segment.Destination  `json:"destination,omitempty"`
+	TrackingPlan *segment.TrackingPlan `json:"tracking_plan,omitempty"`
+}
+
+// String describes the event, e.g. "destination js/mixpanel added"
+func (e Event) String() string {
+	name := segment.Slug(e.Name)
+	if i := strings.LastIndex(e.Name, "/destinations/"); e.Kind == KindDestination && i >= 0 {
+		name = segment.Slug(e.Name[:i]) + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", e.Kind, name, e.Op)
+}
+
+// Options configures a Watcher
+type Options struct {
+	// Interval is the time between polls, DefaultInterval when zero
+	Interval time.Duration
+	// Jitter is the maximum random time added to each interval, so that watchers started together
+	// do not poll together
+	Jitter time.Duration
+	// Checkpoint resumes watching from a previous checkpoint, so that the changes made while the
+	// watcher was stopped are reported by its first poll. Without a checkpoint, the first poll
+	// records the workspace without reporting anything.
+	Checkpoint Checkpoint
+	// OnCheckpoint is called with the checkpoint of each poll once its events were handled, e.g. to
+	// save it
+	OnCheckpoint func(Checkpoint) error
+	// OnError is called with the errors of polls, after which the watcher keeps polling. Without
+	// it, the watcher stops at the first error.
+	OnError func(error)
+}
+
+// Watcher polls a workspace for changes
+type Watcher struct {
+	client *segment.Client
+	opts   Options
+	rand   *rand.Rand
+
+	mu         sync.Mutex
+	checkpoint Checkpoint
+	err        error
+}
+
+// NewWatcher returns a watcher of the workspace of a client
+func NewWatcher(c *segment.Client, opts Options) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	return &Watcher{
+		client:     c,
+		opts:       opts,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		checkpoint: opts.Checkpoint,
+	}
+}
+
+// Checkpoint returns the checkpoint of the last poll whose events were handled
+func (w *Watcher) Checkpoint() Checkpoint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.checkpoint
+}
+
+// Err returns the error the channel of Events was closed after, or nil if it was not closed yet
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Poll polls the workspace once and returns the changes since the previous poll
+func (w *Watcher) Poll() ([]Event, error) {
+	events, next, err := w.poll()
+	if err != nil {
+		return nil, err
+	}
+	return events, w.commit(next)
+}
+
+// Run polls the workspace until the context is done, and calls handle with each change. It
+// returns the error of handle or, unless Options.OnError is set, of a poll. When the context is
+// done it returns the error of the context.
+//
+// The checkpoint only moves past a poll once handle returned without error for all its events, so
+// a watcher resumed from it reports the changes whose handling failed again.
+func (w *Watcher) Run(ctx context.Context, handle func(Event) error) error {
+	for {
+		events, next, err := w.poll()
+		if err != nil && w.opts.OnError == nil {
+			return err
+		}
+		if err != nil {
+			w.opts.OnError(err)
+		} else {
+			for _, e := range events {
+				if err := handle(e); err != nil {
+					return err
+				}
+			}
+			if err := w.commit(next); err != nil {
+				return err
+			}
+		}
+
+		t := time.NewTimer(w.delay())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Events runs the watcher in the background and sends the changes on the returned channel. The
+// channel is closed when the context is done or the watcher stops on an error, which Err returns.
+func (w *Watcher) Events(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		err := w.Run(ctx, func(e Event) error {
+			select {
+			case events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+	return events
+}
+
+// delay returns the time until the next poll
+func (w *Watcher) delay() time.Duration {
+	d := w.opts.Interval
+	if w.opts.Jitter > 0 {
+		d += time.Duration(w.rand.Int63n(int64(w.opts.Jitter)))
+	}
+	return d
+}
+
+func (w *Watcher) commit(next Checkpoint) error {
+	w.mu.Lock()
+	w.checkpoint = next
+	w.mu.Unlock()
+	if w.opts.OnCheckpoint != nil {
+		return w.opts.OnCheckpoint(next)
+	}
+	return nil
+}
+
+// poll reads the workspace and returns its changes since the checkpoint, along with the checkpoint
+// of this poll
+func (w *Watcher) poll() ([]Event, Checkpoint, error) {
+	started := time.Now()
+	resources, err := read(w.client)
+	if err != nil {
+		return nil, Checkpoint{}, err
+	}
+	next := Checkpoint{Time: started, Versions: map[string]string{}}
+	for key, e := range resources {
+		next.Versions[key] = version(e)
+	}
+
+	prev := w.Checkpoint()
+	if prev.Time.IsZero() {
+		return nil, next, nil
+	}
+	var events []Event
+	for key, e := range resources {
+		v, ok := prev.Versions[key]
+		switch {
+		case !ok:
+			e.Op = Added
+		case v != next.Versions[key]:
+			e.Op = Modified
+		default:
+			continue
+		}
+		events = append(events, e)
+	}
+	for key := range prev.Versions {
+		if _, ok := resources[key]; !ok {
+			kind, name := parseKey(key)
+			events = append(events, Event{Op: Removed, Kind: kind, Name: name})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Kind != events[j].Kind {
+			return kindOrder[events[i].Kind] < kindOrder[events[j].Kind]
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events, next, nil
+}
+
+// read lists the resources of a workspace as events without operation, by checkpoint key
+func read(c *segment.Client) (map[string]Event, error) {
+	resources := map[string]Event{}
+	add := func(e Event) {
+		resources[key(e.Kind, e.Name)] = e
+	}
+
+	sources, err := c.ListSources()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sources")
+	}
+	for i := range sources.Sources {
+		src := &sources.Sources[i]
+		add(Event{Kind: KindSource, Name: src.Name, Source: src})
+
+		name := segment.Slug(src.Name)
+		dests, err := c.ListDestinations(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list destinations of source %s", name)
+		}
+		for j := range dests.Destinations {
+			d := &dests.Destinations[j]
+			add(Event{Kind: KindDestination, Name: d.Name, Destination: d})
+		}
+	}
+
+	plans, err := c.ListTrackingPlans()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tracking plans")
+	}
+	for i := range plans.TrackingPlans {
+		p := &plans.TrackingPlans[i]
+		add(Event{Kind: KindTrackingPlan, Name: p.Name, TrackingPlan: p})
+	}
+	return resources, nil
+}
+
+// version returns what changes when a resource is modified: its update time, or its create time
+// for sources, which the Config API does not give an update time
+func version(e Event) string {
+	var t *time.Time
+	switch {
+	case e.Source != nil:
+		t = e.Source.CreateTime
+	case e.Destination != nil:
+		t = e.Destination.UpdateTime
+	case e.TrackingPlan != nil:
+		t = e.TrackingPlan.UpdateTime
+	}
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,177 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fenderdigital/segment-apis-go/segment"
+	"github.com/fenderdigital/segment-apis-go/segment/internal/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWorkspace() *fakeapi.Server {
+	api := fakeapi.New()
+	api.AddSource("ws", "js", "catalog/sources/javascript")
+	api.AddSource("ws", "ios", "catalog/sources/ios")
+	api.AddDestination("ws", "js", "google-analytics", true)
+	api.AddTrackingPlan("ws", "rs_1", "Kicks App", segment.Rules{})
+	return api
+}
+
+// changeWorkspace adds, modifies and removes resources an hour after they were created
+func changeWorkspace(api *fakeapi.Server) {
+	later := fakeapi.DefaultTime.Add(time.Hour)
+	api.Now = func() time.Time { return later }
+	api.AddDestination("ws", "js", "mixpanel", true)
+	api.Update("ws", func(w *fakeapi.Workspace) {
+		w.Destinations["js"][0].Enabled = false
+		w.Destinations["js"][0].UpdateTime = &later
+		w.TrackingPlans[0].UpdateTime = &later
+		w.Sources = w.Sources[:1]
+		delete(w.Destinations, "ios")
+	})
+}
+
+func eventStrings(events []Event) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.String())
+	}
+	return s
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	w := NewWatcher(api.Client("ws"), Options{})
+	events, err := w.Poll()
+	assert.NoError(t, err)
+	assert.Empty(t, events, "the first poll records the workspace")
+	assert.Len(t, w.Checkpoint().Versions, 4)
+
+	events, err = w.Poll()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	changeWorkspace(api)
+	events, err = w.Poll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"source ios removed",
+		"destination js/google-analytics modified",
+		"destination js/mixpanel added",
+		"tracking-plan rs_1 modified",
+	}, eventStrings(events))
+	assert.False(t, events[1].Destination.Enabled)
+	assert.Equal(t, "workspaces/ws/sources/js/destinations/mixpanel", events[2].Destination.Name)
+	assert.Equal(t, "Kicks App", events[3].TrackingPlan.DisplayName)
+	assert.Equal(t, Event{Op: Removed, Kind: KindSource, Name: "workspaces/ws/sources/ios"}, events[0])
+}
+
+func TestWatcher_Poll_Error(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	w := NewWatcher(api.Client("ws"), Options{})
+	_, err := w.Poll()
+	assert.NoError(t, err)
+	checkpoint := w.Checkpoint()
+
+	api.Fail("GET", "sources/js/destinations")
+	_, err = w.Poll()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to list destinations of source js")
+	}
+	assert.Equal(t, checkpoint, w.Checkpoint(), "a failed poll does not move the checkpoint")
+}
+
+func TestWatcher_Run(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	var mu sync.Mutex
+	var checkpoints []Checkpoint
+	w := NewWatcher(api.Client("ws"), Options{
+		Interval: time.Millisecond,
+		Jitter:   time.Millisecond,
+		OnCheckpoint: func(c Checkpoint) error {
+			mu.Lock()
+			defer mu.Unlock()
+			checkpoints = append(checkpoints, c)
+			if len(checkpoints) == 1 {
+				changeWorkspace(api)
+			}
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []Event
+	err := w.Run(ctx, func(e Event) error {
+		events = append(events, e)
+		if len(events) == 4 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, events, 4)
+
+	// a failing handler stops the watcher before the checkpoint moves
+	changeWorkspace(api)
+	api.AddDestination("ws", "js", "amplitude", true)
+	checkpoint := w.Checkpoint()
+	err = w.Run(context.Background(), func(e Event) error {
+		return fmt.Errorf("failed to handle %s", e)
+	})
+	assert.EqualError(t, err, "failed to handle destination js/amplitude added")
+	assert.Equal(t, checkpoint, w.Checkpoint())
+}
+
+func TestWatcher_Run_OnError(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+	api.Fail("GET", "tracking-plans")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var errs []error
+	w := NewWatcher(api.Client("ws"), Options{
+		Interval: time.Millisecond,
+		OnError:  func(err error) { errs = append(errs, err) },
+		OnCheckpoint: func(c Checkpoint) error {
+			cancel()
+			return nil
+		},
+	})
+	err := w.Run(ctx, func(e Event) error { return nil })
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, errs, 1, "the watcher keeps polling after an error")
+}
+
+func TestWatcher_Events(t *testing.T) {
+	api := setupWorkspace()
+	defer api.Close()
+
+	// resuming from a checkpoint reports the changes made since
+	w := NewWatcher(api.Client("ws"), Options{})
+	_, err := w.Poll()
+	assert.NoError(t, err)
+	changeWorkspace(api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resumed := NewWatcher(api.Client("ws"), Options{Interval: time.Millisecond, Checkpoint: w.Checkpoint()})
+	var events []Event
+	for e := range resumed.Events(ctx) {
+		events = append(events, e)
+		if len(events) == 4 {
+			cancel()
+		}
+	}
+	assert.Len(t, events, 4)
+	assert.Equal(t, context.Canceled, resumed.Err())
+}